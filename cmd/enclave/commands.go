@@ -0,0 +1,1140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave"
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/audit"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/deadman"
+	"github.com/yangnei/enclave-go/enclave/funding"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+// subcommand splits an action such as "list" off args, returning def when args start with a flag.
+func subcommand(args []string, def string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return def, args
+}
+
+// orderFillClient returns the perps client for perps markets and the spot client otherwise.
+func orderFillClient(c client.Client, market string, perps bool) client.OrderFillClient {
+	if perps || util.IsPerpsMarket(market) {
+		return c.PerpsClient()
+	}
+	return c.SpotClient()
+}
+
+type marketRow struct {
+	Market         string          `json:"market"`
+	Base           string          `json:"base"`
+	Quote          string          `json:"quote"`
+	BaseIncrement  decimal.Decimal `json:"baseIncrement"`
+	QuoteIncrement decimal.Decimal `json:"quoteIncrement"`
+	Disabled       bool            `json:"disabled"`
+}
+
+func runMarkets(a *app, args []string) error {
+	fs := a.flagSet("markets")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	markets, err := c.GetMarkets()
+	if err != nil {
+		return err
+	}
+	if a.format == outputJSON {
+		return a.print(markets)
+	}
+
+	var rows []*marketRow
+	if markets.Spot != nil {
+		for _, tp := range markets.Spot.TradingPairs {
+			if tp.Pair == nil {
+				continue
+			}
+			rows = append(rows, &marketRow{
+				Market:         util.NewTradingPair(tp.Pair.Base, tp.Pair.Quote),
+				Base:           tp.Pair.Base,
+				Quote:          tp.Pair.Quote,
+				BaseIncrement:  tp.BaseIncrement,
+				QuoteIncrement: tp.QuoteIncrement,
+				Disabled:       tp.Disabled,
+			})
+		}
+	}
+	return a.print(rows)
+}
+
+type depthRow struct {
+	Side  model.OrderSide `json:"side"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+func runDepth(a *app, args []string) error {
+	fs := a.flagSet("depth")
+	market := fs.String("market", "", "market, e.g., AVAX-USDC or BTC-USD.P (required)")
+	depth := fs.Int("depth", 10, "number of price levels per side")
+	perps := fs.Bool("perps", false, "query the perps order book")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *market == "" {
+		return errors.New("--market is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	book, err := orderFillClient(c, *market, *perps).GetDepth(&api.GetDepthRequest{Market: *market, Depth: *depth})
+	if err != nil {
+		return err
+	}
+	if a.format == outputJSON {
+		return a.print(book)
+	}
+
+	var rows []*depthRow
+	// Asks are listed from the highest price down so the spread sits in the middle of the table.
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		if level := book.Asks[i]; len(level) >= 2 {
+			rows = append(rows, &depthRow{Side: model.OrderSideSell, Price: level[0], Size: level[1]})
+		}
+	}
+	for _, level := range book.Bids {
+		if len(level) >= 2 {
+			rows = append(rows, &depthRow{Side: model.OrderSideBuy, Price: level[0], Size: level[1]})
+		}
+	}
+	return a.print(rows)
+}
+
+func runOrders(a *app, args []string) error {
+	action, args := subcommand(args, "list")
+	switch action {
+	case "list":
+		return runOrdersList(a, args)
+	case "get":
+		return runOrdersGet(a, args)
+	case "place":
+		return runOrdersPlace(a, args)
+	case "cancel":
+		return runOrdersCancel(a, args)
+	case "cancel-all":
+		return runOrdersCancelAll(a, args)
+	case "replace":
+		return runOrdersReplace(a, args)
+	default:
+		return fmt.Errorf("unknown orders action %q, expected list, get, place, cancel, cancel-all or replace", action)
+	}
+}
+
+func runOrdersList(a *app, args []string) error {
+	fs := a.flagSet("orders list")
+	market := fs.String("market", "", "only list orders of this market")
+	status := fs.String("status", "", "only list orders with this status: open, fullyfilled or canceled")
+	limit := fs.Int("limit", 0, "maximum number of orders")
+	cursor := fs.String("cursor", "", "page cursor returned by a previous call")
+	perps := fs.Bool("perps", false, "list perps orders")
+	var start, end timeFlag
+	fs.Var(&start, "start", "only list orders created at or after this time")
+	fs.Var(&end, "end", "only list orders created before this time")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	req := &api.GetOrdersRequest{Market: *market, Status: model.OrderStatus(*status)}
+	req.Limit, req.Cursor = *limit, *cursor
+	req.StartMs, req.EndMs = start.ms, end.ms
+	resp, err := orderFillClient(c, *market, *perps).GetOrders(req)
+	if err != nil {
+		return err
+	}
+	if resp.PageInfo.NextCursor != "" {
+		fmt.Fprintln(a.stderr, "next cursor:", resp.PageInfo.NextCursor)
+	}
+	return a.print(resp.Orders)
+}
+
+func runOrdersGet(a *app, args []string) error {
+	fs := a.flagSet("orders get")
+	id := fs.String("id", "", "order ID")
+	clientID := fs.String("client-id", "", "client order ID")
+	perps := fs.Bool("perps", false, "get a perps order")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if (*id == "") == (*clientID == "") {
+		return errors.New("exactly one of --id and --client-id is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	order, err := orderFillClient(c, "", *perps).GetOrder(&api.GetOrderRequest{OrderID: *id, ClientOrderID: *clientID})
+	if err != nil {
+		return err
+	}
+	return a.print(order)
+}
+
+func runOrdersPlace(a *app, args []string) error {
+	fs := a.flagSet("orders place")
+	market := fs.String("market", "", "market, e.g., AVAX-USDC or BTC-USD.P (required)")
+	side := fs.String("side", "", "buy or sell (required)")
+	orderType := fs.String("type", string(model.OrderTypeLimit), "limit or market")
+	tif := fs.String("tif", "", "time in force: GTC or IOC")
+	postOnly := fs.Bool("post-only", false, "reject the order if it would take liquidity")
+	clientID := fs.String("client-id", "", "client order ID")
+	perps := fs.Bool("perps", false, "place a perps order")
+	var size, quoteSize, price decimalFlag
+	fs.Var(&size, "size", "order size in the base asset")
+	fs.Var(&quoteSize, "quote-size", "market order size in the quote asset")
+	fs.Var(&price, "price", "limit price")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	req := &api.AddOrderRequest{
+		ClientOrderID: *clientID,
+		Market:        *market,
+		Price:         price.value,
+		QuoteSize:     quoteSize.value,
+		Side:          model.OrderSide(strings.ToLower(*side)),
+		Size:          size.value,
+		Type:          model.OrderType(strings.ToLower(*orderType)),
+		TimeInForce:   model.TimeInForce(strings.ToUpper(*tif)),
+		PostOnly:      *postOnly,
+	}
+	switch {
+	case req.Market == "":
+		return errors.New("--market is required")
+	case req.Side != model.OrderSideBuy && req.Side != model.OrderSideSell:
+		return fmt.Errorf("invalid --side %q, expected buy or sell", *side)
+	case req.Type != model.OrderTypeLimit && req.Type != model.OrderTypeMarket:
+		return fmt.Errorf("invalid --type %q, expected limit or market", *orderType)
+	case req.Type == model.OrderTypeLimit && (!size.set || !price.set):
+		return errors.New("limit orders require --size and --price")
+	case req.Type == model.OrderTypeMarket && size.set == quoteSize.set:
+		return errors.New("market orders require exactly one of --size and --quote-size")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	order, err := orderFillClient(c, req.Market, *perps).AddOrder(req)
+	if err != nil {
+		return err
+	}
+	return a.print(order)
+}
+
+func runOrdersCancel(a *app, args []string) error {
+	fs := a.flagSet("orders cancel")
+	id := fs.String("id", "", "order ID")
+	clientID := fs.String("client-id", "", "client order ID")
+	perps := fs.Bool("perps", false, "cancel a perps order")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if (*id == "") == (*clientID == "") {
+		return errors.New("exactly one of --id and --client-id is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	order, err := orderFillClient(c, "", *perps).CancelOrder(&api.CancelOrderRequest{OrderID: *id, ClientOrderID: *clientID})
+	if err != nil {
+		return err
+	}
+	return a.print(order)
+}
+
+type replaceRow struct {
+	OriginalOrderID string          `json:"originalOrderId"`
+	OrderID         string          `json:"orderId"`
+	Price           decimal.Decimal `json:"price"`
+	Filled          decimal.Decimal `json:"filled"`
+	Remaining       decimal.Decimal `json:"remaining"`
+	FullyFilled     bool            `json:"fullyFilled"`
+}
+
+func runOrdersReplace(a *app, args []string) error {
+	fs := a.flagSet("orders replace")
+	id := fs.String("id", "", "order ID")
+	clientID := fs.String("client-id", "", "client order ID")
+	newClientID := fs.String("new-client-id", "", "client order ID of the new order")
+	postOnly := fs.Bool("post-only", false, "reject the new order if it would take liquidity")
+	perps := fs.Bool("perps", false, "replace a perps order")
+	var size, price decimalFlag
+	fs.Var(&price, "price", "new limit price (required)")
+	fs.Var(&size, "size", "new total size, including what the order already filled (default: unchanged)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if (*id == "") == (*clientID == "") {
+		return errors.New("exactly one of --id and --client-id is required")
+	}
+	if !price.set {
+		return errors.New("--price is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	res, err := oms.Replace(orderFillClient(c, "", *perps), &oms.ReplaceRequest{
+		OrderID:          *id,
+		ClientOrderID:    *clientID,
+		Price:            price.value,
+		Size:             size.value,
+		NewClientOrderID: *newClientID,
+		PostOnly:         *postOnly,
+	})
+	if res != nil {
+		row := replaceRow{OriginalOrderID: res.OriginalOrderID, Filled: res.Filled, Remaining: res.Remaining, FullyFilled: res.FullyFilled}
+		if res.Order != nil {
+			row.OrderID, row.Price = res.Order.OrderID, res.Order.Price
+		}
+		if printErr := a.print([]replaceRow{row}); printErr != nil && err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+type cancelRow struct {
+	OrderID string `json:"orderId"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+func runOrdersCancelAll(a *app, args []string) error {
+	fs := a.flagSet("orders cancel-all")
+	market := fs.String("market", "", "only cancel orders of this market")
+	perps := fs.Bool("perps", false, "cancel perps orders")
+	side := fs.String("side", "", "only cancel orders of this side: buy or sell")
+	olderThan := fs.Duration("older-than", 0, "only cancel orders at least this old")
+	clientPrefix := fs.String("client-prefix", "", "only cancel orders whose client order ID has this prefix")
+	concurrency := fs.Int("concurrency", oms.DefaultConcurrency, "maximum number of cancellations in flight when filtering")
+	var minPrice, maxPrice decimalFlag
+	fs.Var(&minPrice, "min-price", "only cancel orders at or above this price")
+	fs.Var(&maxPrice, "max-price", "only cancel orders at or below this price")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	filter := &oms.CancelFilter{
+		Market:         *market,
+		Side:           model.OrderSide(strings.ToLower(*side)),
+		MinPrice:       minPrice.value,
+		MaxPrice:       maxPrice.value,
+		OlderThan:      *olderThan,
+		ClientIDPrefix: *clientPrefix,
+	}
+	if filter.Side != "" && filter.Side != model.OrderSideBuy && filter.Side != model.OrderSideSell {
+		return fmt.Errorf("invalid --side %q, expected buy or sell", *side)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ofc := orderFillClient(c, *market, *perps)
+
+	if filter.Side == "" && !minPrice.set && !maxPrice.set && filter.OlderThan == 0 && filter.ClientIDPrefix == "" {
+		if err := ofc.CancelOrders(&api.CancelOrdersRequest{Market: *market}); err != nil {
+			return err
+		}
+		fmt.Fprintln(a.stderr, "canceled all open orders")
+		return nil
+	}
+
+	results, err := oms.CancelMatching(ofc, filter, &oms.BatchOptions{Concurrency: *concurrency})
+	if err != nil {
+		return err
+	}
+	rows := make([]cancelRow, len(results))
+	failed := 0
+	for i, r := range results {
+		rows[i] = cancelRow{OrderID: r.OrderID, Status: "canceled"}
+		if r.Err != nil {
+			rows[i].Status, rows[i].Error = "failed", r.Err.Error()
+			failed++
+		}
+	}
+	if err := a.print(rows); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to cancel %d of %d orders", failed, len(results))
+	}
+	return nil
+}
+
+func runFills(a *app, args []string) error {
+	fs := a.flagSet("fills")
+	market := fs.String("market", "", "only list fills of this market")
+	orderID := fs.String("order-id", "", "only list fills of this order")
+	clientID := fs.String("client-id", "", "only list fills of the order with this client order ID")
+	limit := fs.Int("limit", 0, "maximum number of fills")
+	cursor := fs.String("cursor", "", "page cursor returned by a previous call")
+	perps := fs.Bool("perps", false, "list perps fills")
+	var start, end timeFlag
+	fs.Var(&start, "start", "only list fills at or after this time")
+	fs.Var(&end, "end", "only list fills before this time")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ofc := orderFillClient(c, *market, *perps)
+	var fills []*model.Fill
+	if *orderID != "" || *clientID != "" {
+		fills, err = ofc.GetFillsByID(&api.GetFillsByIDRequest{OrderID: *orderID, ClientOrderID: *clientID})
+	} else {
+		req := &api.GetFillsRequest{Market: *market}
+		req.Limit, req.Cursor = *limit, *cursor
+		req.StartMs, req.EndMs = start.ms, end.ms
+		fills, err = ofc.GetFills(req)
+	}
+	if err != nil {
+		return err
+	}
+	return a.print(fills)
+}
+
+func runPositions(a *app, args []string) error {
+	fs := a.flagSet("positions")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	positions, err := c.PerpsClient().GetPositions()
+	if err != nil {
+		return err
+	}
+	return a.print(positions)
+}
+
+func runBalance(a *app, args []string) error {
+	fs := a.flagSet("balance")
+	wallet := fs.String("wallet", string(model.WalletMargin), "margin or main")
+	asset := fs.String("asset", "", "only show this asset of the main wallet")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *asset != "" {
+		*wallet = string(model.WalletMain)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	switch model.Wallet(*wallet) {
+	case model.WalletMargin:
+		balance, err := c.PerpsClient().GetBalance()
+		if err != nil {
+			return err
+		}
+		return a.print(balance)
+	case model.WalletMain:
+		if *asset != "" {
+			balance, err := c.GetAssetBalance(&api.GetAssetBalanceRequest{Symbol: *asset})
+			if err != nil {
+				return err
+			}
+			return a.print(balance)
+		}
+		balances, err := c.GetAssetBalances()
+		if err != nil {
+			return err
+		}
+		return a.print(balances)
+	default:
+		return fmt.Errorf("invalid --wallet %q, expected margin or main", *wallet)
+	}
+}
+
+func runTransfers(a *app, args []string) error {
+	action, args := subcommand(args, "list")
+	switch action {
+	case "list":
+		return runTransfersList(a, args)
+	case "send":
+		return runTransfersSend(a, args)
+	default:
+		return fmt.Errorf("unknown transfers action %q, expected list or send", action)
+	}
+}
+
+func runTransfersList(a *app, args []string) error {
+	fs := a.flagSet("transfers list")
+	transferType := fs.String("type", "", "only list transfers of this type: margin or subaccount")
+	counterparty := fs.String("counterparty", "", "only list transfers from or to this account ID")
+	limit := fs.Int("limit", 0, "maximum number of transfers")
+	cursor := fs.String("cursor", "", "page cursor returned by a previous call")
+	var start, end timeFlag
+	fs.Var(&start, "start", "only list transfers at or after this time")
+	fs.Var(&end, "end", "only list transfers before this time")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	req := &api.GetTransferHistoryRequest{Counterparty: *counterparty}
+	req.Limit, req.Cursor = *limit, *cursor
+	req.StartMs, req.EndMs = start.ms, end.ms
+	switch *transferType {
+	case "":
+	case "margin":
+		t := model.TransferTypeMargin
+		req.Type = &t
+	case "subaccount":
+		t := model.TransferTypeSubAccount
+		req.Type = &t
+	default:
+		return fmt.Errorf("invalid --type %q, expected margin or subaccount", *transferType)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	page, err := c.GetTransferHistory(req)
+	if err != nil {
+		return err
+	}
+	return a.print(page)
+}
+
+func runTransfersSend(a *app, args []string) error {
+	fs := a.flagSet("transfers send")
+	symbol := fs.String("symbol", "USDC", "symbol to transfer")
+	from := fs.String("from", string(model.WalletMain), "source wallet: main or margin")
+	to := fs.String("to", string(model.WalletMargin), "destination wallet: main or margin")
+	fromAccount := fs.String("from-account", "", "source account ID, defaults to the API key's account")
+	toAccount := fs.String("to-account", "", "destination account ID, defaults to the API key's account")
+	var amount decimalFlag
+	fs.Var(&amount, "amount", "amount to transfer (required)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if !amount.set {
+		return errors.New("--amount is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	transfer, err := c.Transfer(&api.WalletTransferRequest{
+		Symbol: *symbol,
+		Amount: amount.value,
+		From:   model.AccountWalletKey{ID: *fromAccount, Wallet: model.Wallet(*from)},
+		To:     model.AccountWalletKey{ID: *toAccount, Wallet: model.Wallet(*to)},
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(transfer)
+}
+
+func runFunding(a *app, args []string) error {
+	action, args := subcommand(args, "rates")
+	switch action {
+	case "rates":
+		return runFundingRates(a, args)
+	case "history":
+		return runFundingHistory(a, args)
+	case "stats":
+		return runFundingStats(a, args)
+	case "scan":
+		return runFundingScan(a, args)
+	case "fees":
+		return runFundingFees(a, args)
+	default:
+		return fmt.Errorf("unknown funding action %q, expected rates, history, stats, scan or fees", action)
+	}
+}
+
+func runFundingRates(a *app, args []string) error {
+	fs := a.flagSet("funding rates")
+	market := fs.String("market", "", "perps market, defaults to every market with a mark price")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	pc := c.PerpsClient()
+
+	markets := []string{*market}
+	if *market == "" {
+		marks, err := pc.GetMarkPrices()
+		if err != nil {
+			return err
+		}
+		markets = markets[:0]
+		for m := range marks {
+			markets = append(markets, m)
+		}
+		sort.Strings(markets)
+	}
+
+	rates := make([]*model.FundingRate, 0, len(markets))
+	for _, m := range markets {
+		rate, err := pc.GetFundingRates(&api.GetFundingRatesRequest{Market: m})
+		if err != nil {
+			return fmt.Errorf("failed to get funding rate of %s: %w", m, err)
+		}
+		rates = append(rates, rate)
+	}
+	return a.print(rates)
+}
+
+func runFundingHistory(a *app, args []string) error {
+	fs := a.flagSet("funding history")
+	market := fs.String("market", "", "perps market (required)")
+	limit := fs.Int("limit", 0, "maximum number of rates")
+	cursor := fs.String("cursor", "", "page cursor returned by a previous call")
+	var start, end timeFlag
+	fs.Var(&start, "start", "only list rates at or after this time")
+	fs.Var(&end, "end", "only list rates before this time")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *market == "" {
+		return errors.New("--market is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	req := &api.GetFundingRateHistoryRequest{Market: *market}
+	req.Limit, req.Cursor = *limit, *cursor
+	req.StartMs, req.EndMs = start.ms, end.ms
+	rates, err := c.PerpsClient().GetFundingRateHistory(req)
+	if err != nil {
+		return err
+	}
+	return a.print(rates)
+}
+
+// marketList parses a comma-separated market list.
+func marketList(s string) []string {
+	var markets []string
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			markets = append(markets, m)
+		}
+	}
+	return markets
+}
+
+// readPredictions reads recorded funding estimates from JSON lines holding either funding rates or strategy
+// funding events.
+func readPredictions(path string) ([]*model.FundingRate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rates []*model.FundingRate
+	dec := json.NewDecoder(f)
+	for {
+		var line struct {
+			model.FundingRate
+			Funding *model.FundingRate `json:"funding"`
+		}
+		if err := dec.Decode(&line); err == io.EOF {
+			return rates, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read predictions: %w", err)
+		}
+		switch {
+		case line.Funding != nil:
+			rates = append(rates, line.Funding)
+		case line.Market != "":
+			rates = append(rates, &line.FundingRate)
+		}
+	}
+}
+
+type fundingStatsRow struct {
+	Rank              int             `json:"rank"`
+	Market            string          `json:"market"`
+	Interval          string          `json:"interval"`
+	Current           decimal.Decimal `json:"current"`
+	CurrentAnnualized decimal.Decimal `json:"currentAnnualized"`
+	Last              decimal.Decimal `json:"last"`
+	Average           decimal.Decimal `json:"average"`
+	AverageAnnualized decimal.Decimal `json:"averageAnnualized"`
+	MovingAverage     decimal.Decimal `json:"movingAverage"`
+	Samples           int             `json:"samples"`
+	Predictions       int             `json:"predictions"`
+	Bias              string          `json:"bias"`
+	MeanAbsError      string          `json:"meanAbsError"`
+}
+
+func runFundingStats(a *app, args []string) error {
+	fs := a.flagSet("funding stats")
+	markets := fs.String("markets", "", "comma-separated perps markets, defaults to every market with a mark price")
+	lookback := fs.Duration("lookback", 7*24*time.Hour, "period of history to analyze")
+	window := fs.Int("window", 24, "number of rates in the moving average")
+	rankBy := fs.String("rank-by", string(funding.RankByAverage), "rank by current, average or magnitude")
+	predictions := fs.String("predictions", "", "JSON lines of recorded funding estimates to compare realized rates with")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	switch by := funding.RankBy(*rankBy); by {
+	case funding.RankByCurrent, funding.RankByAverage, funding.RankByMagnitude:
+	default:
+		return fmt.Errorf("invalid --rank-by %q, expected current, average or magnitude", *rankBy)
+	}
+	opts := funding.AnalyzeOptions{
+		Markets:  marketList(*markets),
+		Lookback: *lookback,
+		Window:   *window,
+		RankBy:   funding.RankBy(*rankBy),
+	}
+	if *predictions != "" {
+		rates, err := readPredictions(*predictions)
+		if err != nil {
+			return err
+		}
+		opts.Predictions = rates
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	stats, err := funding.Analyze(c.PerpsClient(), opts)
+	if err != nil {
+		return err
+	}
+	if a.format == outputJSON {
+		return a.print(stats)
+	}
+	rows := make([]fundingStatsRow, len(stats))
+	for i, s := range stats {
+		rows[i] = fundingStatsRow{
+			Rank:              s.Rank,
+			Market:            s.Market,
+			Interval:          s.Interval.String(),
+			Current:           s.Current,
+			CurrentAnnualized: s.CurrentAnnualized,
+			Last:              s.Last,
+			Average:           s.Average,
+			AverageAnnualized: s.AverageAnnualized,
+			MovingAverage:     s.MovingAverage,
+			Samples:           s.Samples,
+		}
+		if p := s.Prediction; p != nil {
+			rows[i].Predictions, rows[i].Bias, rows[i].MeanAbsError = p.Count, p.Bias.String(), p.MeanAbs.String()
+		}
+	}
+	return a.print(rows)
+}
+
+func runFundingScan(a *app, args []string) error {
+	fs := a.flagSet("funding scan")
+	markets := fs.String("markets", "", "comma-separated perps markets, defaults to every market with a mark price")
+	quotes := fs.String("spot-quotes", "USDC", "comma-separated spot quote coins tried when the perps quote has no spot market")
+	horizon := fs.Duration("horizon", 24*time.Hour, "holding period to estimate the carry over")
+	interval := fs.Duration("interval", funding.DefaultInterval, "funding interval")
+	var perpsFee, spotFee, minNet decimalFlag
+	fs.Var(&perpsFee, "perps-fee", "fee of each perps fill as a fraction, e.g., 0.0005")
+	fs.Var(&spotFee, "spot-fee", "fee of each spot fill as a fraction, e.g., 0.001")
+	fs.Var(&minNet, "min-net-bps", "only list opportunities netting at least this many basis points")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	cfg := funding.ScanConfig{
+		Markets:    marketList(*markets),
+		SpotQuotes: marketList(*quotes),
+		Horizon:    *horizon,
+		Interval:   *interval,
+		PerpsFee:   perpsFee.value,
+		SpotFee:    spotFee.value,
+	}
+	if minNet.set {
+		cfg.MinNetBps = &minNet.value
+	}
+	opportunities, err := funding.Scan(c, cfg)
+	if err != nil {
+		return err
+	}
+	return a.print(opportunities)
+}
+
+func runFundingFees(a *app, args []string) error {
+	fs := a.flagSet("funding fees")
+	markets := fs.String("markets", "", "comma-separated perps markets, defaults to every market with a mark price")
+	payments := fs.Bool("payments", false, "list every payment instead of the totals per market and direction")
+	pageSize := fs.Int("page-size", funding.DefaultPageSize, "number of fees requested per page")
+	var start, end timeFlag
+	fs.Var(&start, "start", "only include fees paid at or after this time")
+	fs.Var(&end, "end", "only include fees paid before this time")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	opts := funding.ReportOptions{Markets: marketList(*markets), PageSize: *pageSize}
+	if start.ms != 0 {
+		opts.Start = time.UnixMilli(start.ms)
+	}
+	if end.ms != 0 {
+		opts.End = time.UnixMilli(end.ms)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	report, err := funding.BuildReport(c.PerpsClient(), opts)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.format == outputJSON:
+		return a.print(report)
+	case a.format == outputCSV && *payments:
+		return funding.WritePaymentsCSV(a.stdout, report)
+	case a.format == outputCSV:
+		return funding.WriteSummariesCSV(a.stdout, report)
+	case *payments:
+		return a.print(report.Payments)
+	}
+	total := *report.Total
+	total.Market = "total"
+	return a.print(append(report.Summaries, &total))
+}
+
+func runStopOrders(a *app, args []string) error {
+	action, args := subcommand(args, "list")
+	switch action {
+	case "list":
+		return runStopOrdersList(a, args)
+	case "set":
+		return runStopOrdersSet(a, args)
+	case "remove":
+		return runStopOrdersRemove(a, args)
+	default:
+		return fmt.Errorf("unknown stop-orders action %q, expected list, set or remove", action)
+	}
+}
+
+func runStopOrdersList(a *app, args []string) error {
+	fs := a.flagSet("stop-orders list")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	stopOrders, err := c.PerpsClient().GetStopOrders()
+	if err != nil {
+		return err
+	}
+	return a.print(stopOrders)
+}
+
+func parseStopOrderType(s string) (model.StopOrderType, error) {
+	switch strings.ToLower(s) {
+	case "stoploss", "stop-loss":
+		return model.StopOrderTypeStopLoss, nil
+	case "takeprofit", "take-profit":
+		return model.StopOrderTypeTakeProfit, nil
+	default:
+		return "", fmt.Errorf("invalid --type %q, expected stop-loss or take-profit", s)
+	}
+}
+
+func runStopOrdersSet(a *app, args []string) error {
+	fs := a.flagSet("stop-orders set")
+	market := fs.String("market", "", "perps market (required)")
+	direction := fs.String("direction", "", "direction of the position to protect: long or short (required)")
+	stopType := fs.String("type", "", "stop-loss or take-profit (required)")
+	var price decimalFlag
+	fs.Var(&price, "price", "trigger price (required)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	t, err := parseStopOrderType(*stopType)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *market == "":
+		return errors.New("--market is required")
+	case *direction != string(model.PositionDirectionLong) && *direction != string(model.PositionDirectionShort):
+		return fmt.Errorf("invalid --direction %q, expected long or short", *direction)
+	case !price.set:
+		return errors.New("--price is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	stopOrders, err := c.PerpsClient().SetStopOrder(&api.SetStopOrderRequest{
+		Market:            *market,
+		PositionDirection: *direction,
+		Type:              t,
+		TriggerPrice:      price.value,
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(stopOrders)
+}
+
+func runStopOrdersRemove(a *app, args []string) error {
+	fs := a.flagSet("stop-orders remove")
+	market := fs.String("market", "", "perps market (required)")
+	stopType := fs.String("type", "", "stop-loss or take-profit (required)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	t, err := parseStopOrderType(*stopType)
+	if err != nil {
+		return err
+	}
+	if *market == "" {
+		return errors.New("--market is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	stopOrders, err := c.PerpsClient().RemoveStopOrder(&api.RemoveStopOrderRequest{Market: *market, Type: string(t)})
+	if err != nil {
+		return err
+	}
+	return a.print(stopOrders)
+}
+
+func runDeposits(a *app, args []string) error {
+	fs := a.flagSet("deposits")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	deposits, err := c.GetDeposits()
+	if err != nil {
+		return err
+	}
+	return a.print(deposits)
+}
+
+func runWithdrawals(a *app, args []string) error {
+	fs := a.flagSet("withdrawals")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	withdrawals, err := c.GetWithdrawals()
+	if err != nil {
+		return err
+	}
+	return a.print(withdrawals)
+}
+
+func runAudit(a *app, args []string) error {
+	action, args := subcommand(args, "verify")
+	if action != "verify" {
+		return fmt.Errorf("unknown audit action %q, expected verify", action)
+	}
+
+	fs := a.flagSet("audit verify")
+	path := fs.String("log", "", "audit log to verify, defaults to --audit-log")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *path == "" {
+		*path = a.auditLog
+	}
+	if *path == "" {
+		return errors.New("--log is required")
+	}
+
+	key, err := auditKey()
+	if err != nil {
+		return err
+	}
+	head, err := audit.VerifyFile(*path, key)
+	if err != nil {
+		return err
+	}
+	return a.print(head)
+}
+
+type canceledRow struct {
+	Venue    deadman.Venue   `json:"venue"`
+	Market   string          `json:"market"`
+	OrderID  string          `json:"orderId"`
+	Side     model.OrderSide `json:"side"`
+	Price    decimal.Decimal `json:"price"`
+	Size     decimal.Decimal `json:"size"`
+	Filled   decimal.Decimal `json:"filledSize"`
+	Reason   deadman.Reason  `json:"reason"`
+	Canceled time.Time       `json:"canceledAt"`
+}
+
+// deadmanMarkets parses a comma-separated market list, where "all" selects every market of the venue.
+func deadmanMarkets(s string) (markets []string, enabled bool) {
+	if s == "" {
+		return nil, false
+	}
+	if s == "all" {
+		return nil, true
+	}
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			markets = append(markets, m)
+		}
+	}
+	return markets, true
+}
+
+func runDeadman(a *app, args []string) error {
+	fs := a.flagSet("deadman")
+	heartbeat := fs.String("heartbeat-file", "", "file the application touches as a heartbeat")
+	after := fs.Duration("after", 30*time.Second, "cancel when the heartbeat file is not touched for this long")
+	spot := fs.String("spot", "", "comma-separated spot markets to cancel, or all")
+	perps := fs.String("perps", "", "comma-separated perps markets to cancel, or all")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *heartbeat == "" {
+		return errors.New("--heartbeat-file is required")
+	}
+	spotMarkets, spotEnabled := deadmanMarkets(*spot)
+	perpsMarkets, perpsEnabled := deadmanMarkets(*perps)
+	if !spotEnabled && !perpsEnabled {
+		return errors.New("at least one of --spot and --perps is required")
+	}
+	settings, err := a.settings()
+	if err != nil {
+		return err
+	}
+	// Cancellations are writes: without the opt-in the guard would only reject them once the switch fires.
+	if settings.Env == enclave.EnvironmentProd && !settings.AllowProductionWrites {
+		return errors.New("deadman cancels orders and requires --allow-prod against prod")
+	}
+	if settings.ReadOnly {
+		return errors.New("deadman cancels orders and cannot run with a read-only profile")
+	}
+	c, err := a.clientFrom(settings)
+	if err != nil {
+		return err
+	}
+
+	// The sidecar starts armed: a missing heartbeat file counts from now.
+	if err := deadman.TouchHeartbeat(*heartbeat); err != nil {
+		return err
+	}
+	cfg := deadman.Config{
+		Timeout:       *after,
+		HeartbeatFile: *heartbeat,
+		HandleSignals: true,
+		OnTrigger: func(r *deadman.Report) {
+			var rows []canceledRow
+			for _, res := range r.Results {
+				for _, o := range res.Canceled {
+					rows = append(rows, canceledRow{
+						Venue:    res.Venue,
+						Market:   o.Market,
+						OrderID:  o.OrderID,
+						Side:     o.Side,
+						Price:    o.Price,
+						Size:     o.Size,
+						Filled:   o.FilledSize,
+						Reason:   r.Reason,
+						Canceled: r.Time,
+					})
+				}
+			}
+			fmt.Fprintf(a.stderr, "%s: canceled %d open orders, last heartbeat %s\n", r.Reason, len(rows), r.LastHeartbeat.Format(time.RFC3339))
+			if len(rows) > 0 {
+				if err := a.print(rows); err != nil {
+					fmt.Fprintln(a.stderr, "enclave:", err)
+				}
+			}
+		},
+	}
+	if spotEnabled {
+		cfg.Spot, cfg.SpotMarkets = c.SpotClient(), spotMarkets
+	}
+	if perpsEnabled {
+		cfg.Perps, cfg.PerpsMarkets = c.PerpsClient(), perpsMarkets
+	}
+	sw, err := deadman.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "watching %s, canceling after %s without a heartbeat\n", *heartbeat, *after)
+	err = sw.Run(context.Background(), func(err error) {
+		fmt.Fprintln(a.stderr, "enclave:", err)
+	})
+	if errors.Is(err, deadman.ErrInterrupted) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// decimalFlag is a flag.Value holding an optional decimal.
+type decimalFlag struct {
+	value decimal.Decimal
+	set   bool
+}
+
+func (f *decimalFlag) String() string {
+	if !f.set {
+		return ""
+	}
+	return f.value.String()
+}
+
+func (f *decimalFlag) Set(s string) error {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	f.value, f.set = d, true
+	return nil
+}
+
+// timeFlag is a flag.Value holding a Unix timestamp in milliseconds.
+// It accepts RFC 3339 timestamps, dates, Unix milliseconds, and durations that are subtracted from now, e.g., "24h".
+type timeFlag struct {
+	ms  int64
+	now func() time.Time
+}
+
+func (f *timeFlag) String() string {
+	if f.ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(f.ms).UTC().Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(s string) error {
+	ms, err := parseTime(s, f.now)
+	if err != nil {
+		return err
+	}
+	f.ms = ms
+	return nil
+}
+
+func parseTime(s string, now func() time.Time) (int64, error) {
+	if now == nil {
+		now = time.Now
+	}
+	s = strings.TrimSpace(s)
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now().Add(-d.Abs()).UnixMilli(), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %q, expected RFC 3339, YYYY-MM-DD, Unix milliseconds or a duration such as 24h", s)
+}
@@ -0,0 +1,201 @@
+// Command enclave is a command-line tool for day-to-day Enclave Markets account operations.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/audit"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/config"
+)
+
+// app holds the global options shared by every subcommand.
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	env        string
+	url        string
+	output     string
+	profile    string
+	configPath string
+	timeout    time.Duration
+	allowProd  bool
+	auditLog   string
+
+	format outputFormat
+}
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"markets":     {"list spot markets", runMarkets},
+	"depth":       {"show the order book of a market", runDepth},
+	"orders":      {"list, get, place, cancel, cancel-all or replace orders", runOrders},
+	"fills":       {"list fills", runFills},
+	"positions":   {"list perps positions", runPositions},
+	"balance":     {"show margin or main wallet balances", runBalance},
+	"transfers":   {"list or send transfers between wallets", runTransfers},
+	"funding":     {"show, analyze or scan funding rates and report funding fees", runFunding},
+	"stop-orders": {"list, set or remove perps stop orders", runStopOrders},
+	"deposits":    {"list deposits", runDeposits},
+	"withdrawals": {"list withdrawals", runWithdrawals},
+	"audit":       {"verify an audit log", runAudit},
+	"deadman":     {"cancel open orders when a heartbeat file goes stale", runDeadman},
+}
+
+func main() {
+	a := &app{stdout: os.Stdout, stderr: os.Stderr}
+	if err := a.main(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "enclave:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func (a *app) main(args []string) error {
+	fs := a.flagSet("enclave")
+	fs.Usage = a.usage
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		a.usage()
+		return flag.ErrHelp
+	}
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		a.usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd.run(a, fs.Args()[1:])
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "Usage: enclave [--env sandbox|dev|prod] [--output table|json|csv] [--profile name] <command> [flags]")
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Settings are taken from flags, then environment variables such as "+config.EnvKeyID+" and "+config.EnvSecret+",")
+	fmt.Fprintln(a.stderr, "then the selected profile of the config file.")
+}
+
+// flagSet returns a FlagSet with the global flags registered, so they may be given before or after the subcommand.
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	if a.output == "" {
+		a.output = string(outputTable)
+	}
+	fs.StringVar(&a.env, "env", a.env, "environment: sandbox, dev or prod (default sandbox)")
+	fs.StringVar(&a.url, "url", a.url, "base API URL, overrides --env")
+	fs.StringVar(&a.output, "output", a.output, "output format: table, json or csv")
+	fs.StringVar(&a.profile, "profile", a.profile, "profile name in the config file")
+	fs.StringVar(&a.configPath, "config", a.configPath, "path of the config file (default ~/.config/enclave/config.toml)")
+	fs.DurationVar(&a.timeout, "timeout", a.timeout, "HTTP request timeout")
+	fs.BoolVar(&a.allowProd, "allow-prod", a.allowProd, "allow orders, cancels, transfers and stop order changes against prod")
+	fs.StringVar(&a.auditLog, "audit-log", a.auditLog, "append every mutating request to this hash-chained audit log, keyed with $"+audit.EnvKey)
+	return fs
+}
+
+// parse parses subcommand flags and validates the global options.
+func (a *app) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	format, err := parseOutputFormat(a.output)
+	if err != nil {
+		return err
+	}
+	a.format = format
+	return nil
+}
+
+// settings resolves the global options, environment variables and config file.
+func (a *app) settings() (*config.Settings, error) {
+	var (
+		cfg *config.Config
+		err error
+	)
+	if a.configPath != "" {
+		cfg, err = config.Load(a.configPath)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Resolve(a.profile, &config.Overrides{
+		Env:     a.env,
+		BaseURL: a.url,
+		Timeout: a.timeout,
+
+		AllowProductionWrites: a.allowProd,
+	})
+}
+
+// client builds a Client from the global options, environment variables and config file.
+func (a *app) client() (client.Client, error) {
+	settings, err := a.settings()
+	if err != nil {
+		return nil, err
+	}
+	return a.clientFrom(settings)
+}
+
+func (a *app) clientFrom(settings *config.Settings) (client.Client, error) {
+	if a.auditLog == "" {
+		return settings.NewClient(), nil
+	}
+
+	key, err := auditKey()
+	if err != nil {
+		return nil, err
+	}
+	log, err := audit.Open(a.auditLog, key)
+	if err != nil {
+		return nil, err
+	}
+	base := audit.NewBaseClient(settings.NewBaseClient(), log, func(err error) {
+		fmt.Fprintln(a.stderr, "enclave:", err)
+	})
+	return client.NewClientWithBase(base), nil
+}
+
+// auditKey returns the key of the audit log, read from the environment so that it is not stored with the log.
+func auditKey() ([]byte, error) {
+	key := os.Getenv(audit.EnvKey)
+	if key == "" {
+		return nil, fmt.Errorf("%s is required to write or verify an audit log", audit.EnvKey)
+	}
+	return []byte(key), nil
+}
+
+func (a *app) print(v any) error {
+	return render(a.stdout, a.format, v)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputCSV   outputFormat = "csv"
+)
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch f := outputFormat(strings.ToLower(s)); f {
+	case outputTable, outputJSON, outputCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q, expected table, json or csv", s)
+	}
+}
+
+// render writes v in the given format. Tables and CSV use the json tags of struct fields as column names.
+func render(w io.Writer, format outputFormat, v any) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	header, rows := tabulate(v)
+	if format == outputCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// tabulate flattens a struct, a slice of structs or a map of structs into a header and rows.
+// Maps are rendered with a leading "key" column, sorted by key.
+func tabulate(v any) ([]string, [][]string) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		header := columns(rv.Type().Elem())
+		rows := make([][]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, cells(rv.Index(i)))
+		}
+		return header, rows
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		header := append([]string{"key"}, columns(rv.Type().Elem())...)
+		rows := make([][]string, 0, len(keys))
+		for _, k := range keys {
+			rows = append(rows, append([]string{fmt.Sprint(k)}, cells(rv.MapIndex(k))...))
+		}
+		return header, rows
+	default:
+		return columns(rv.Type()), [][]string{cells(rv)}
+	}
+}
+
+func columns(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isScalar(t) {
+		return []string{"value"}
+	}
+
+	var res []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := fieldName(t.Field(i)); ok {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func cells(v reflect.Value) []string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || isScalar(v.Type()) {
+		return []string{format(v)}
+	}
+
+	var res []string
+	for i := 0; i < v.NumField(); i++ {
+		if _, ok := fieldName(v.Type().Field(i)); ok {
+			res = append(res, format(v.Field(i)))
+		}
+	}
+	return res
+}
+
+func fieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+var (
+	decimalType     = reflect.TypeOf(decimal.Decimal{})
+	nullDecimalType = reflect.TypeOf(decimal.NullDecimal{})
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+func isScalar(t reflect.Type) bool {
+	return t == decimalType || t == nullDecimalType || t == timeType
+}
+
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	switch x := v.Interface().(type) {
+	case decimal.Decimal:
+		return x.String()
+	case decimal.NullDecimal:
+		if !x.Valid {
+			return ""
+		}
+		return x.Decimal.String()
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.UTC().Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return format(v.Elem())
+	case reflect.Struct, reflect.Slice, reflect.Map:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,448 @@
+// Package algo implements client-side execution algorithms on top of the limit and market orders offered by the API.
+package algo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var (
+	errCanceled = errors.New("algo canceled")
+	errPaused   = errors.New("algo paused")
+	errExpired  = errors.New("order expired")
+)
+
+type State string
+
+const (
+	StatePending  State = "pending"  // Run was not called yet
+	StateRunning  State = "running"  // Submitting or tracking child orders
+	StatePaused   State = "paused"   // Waiting for Resume, without resting child orders except for TWAP slices in flight
+	StateDone     State = "done"     // Ran to completion, possibly with a partial fill
+	StateCanceled State = "canceled" // Stopped by Cancel or by the context of Run
+	StateFailed   State = "failed"   // Stopped by an error
+)
+
+// Algo is an execution algorithm working a parent order through child orders.
+type Algo interface {
+	// Run executes the algorithm until it completes, is canceled or fails, and returns its summary.
+	// Resting child orders are canceled before Run returns. A Cancel does not make Run return an error.
+	Run(ctx context.Context) (*Summary, error)
+	Progress() Progress
+	Pause()
+	Resume()
+	Cancel()
+}
+
+// Progress is a snapshot of a running algorithm.
+type Progress struct {
+	State     State           `json:"state"`
+	Size      decimal.Decimal `json:"size"`      // Parent size
+	Filled    decimal.Decimal `json:"filled"`    // Size filled by child orders so far
+	Remaining decimal.Decimal `json:"remaining"` // Size left to fill
+	Orders    int             `json:"orders"`    // Number of child orders submitted
+}
+
+// Summary describes a finished execution. Slippage is in basis points of the arrival mid, positive when the
+// average price is worse than the arrival mid for the side.
+type Summary struct {
+	Algo         string          `json:"algo"`
+	Market       string          `json:"market"`
+	Side         model.OrderSide `json:"side"`
+	State        State           `json:"state"`
+	Size         decimal.Decimal `json:"size"`
+	FilledSize   decimal.Decimal `json:"filledSize"`
+	FilledCost   decimal.Decimal `json:"filledCost"`
+	AveragePrice decimal.Decimal `json:"averagePrice"`
+	ArrivalMid   decimal.Decimal `json:"arrivalMid"`
+	SlippageBps  decimal.Decimal `json:"slippageBps"`
+	Orders       []string        `json:"orders"` // IDs of the child orders, in submission order
+	StartedAt    time.Time       `json:"startedAt"`
+	EndedAt      time.Time       `json:"endedAt"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// execution holds the state shared by every algorithm: control, child orders and fill accounting.
+type execution struct {
+	name   string
+	c      client.OrderFillClient
+	market string
+	side   model.OrderSide
+	size   decimal.Decimal
+	inc    decimal.Decimal
+	poll   time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	wake     chan struct{} // Closed and replaced on every state change
+	children map[string]*model.Order
+	orders   []string
+	arrival  decimal.Decimal
+	started  time.Time
+	ended    time.Time
+	err      error
+}
+
+func newExecution(name string, c client.OrderFillClient, market string, side model.OrderSide, size, inc decimal.Decimal, poll time.Duration) (*execution, error) {
+	if market == "" {
+		return nil, errors.New("market is required")
+	}
+	if side != model.OrderSideBuy && side != model.OrderSideSell {
+		return nil, fmt.Errorf("invalid side %q", side)
+	}
+	if !size.IsPositive() {
+		return nil, errors.New("size must be positive")
+	}
+	if poll <= 0 {
+		poll = time.Second
+	}
+
+	return &execution{
+		name:     name,
+		c:        c,
+		market:   market,
+		side:     side,
+		size:     size,
+		inc:      inc,
+		poll:     poll,
+		now:      time.Now,
+		state:    StatePending,
+		wake:     make(chan struct{}),
+		children: map[string]*model.Order{},
+	}, nil
+}
+
+// Pause stops submitting child orders. Algorithms with resting orders cancel them until Resume.
+func (e *execution) Pause() {
+	e.transition(StatePaused, StateRunning)
+}
+
+// Resume continues a paused algorithm.
+func (e *execution) Resume() {
+	e.transition(StateRunning, StatePaused)
+}
+
+// Cancel stops the algorithm. Run cancels resting child orders and returns.
+func (e *execution) Cancel() {
+	e.transition(StateCanceled, StatePending, StateRunning, StatePaused)
+}
+
+// transition moves to state if the current state is one of from.
+func (e *execution) transition(state State, from ...State) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range from {
+		if e.state == s {
+			e.state = state
+			close(e.wake)
+			e.wake = make(chan struct{})
+			return true
+		}
+	}
+	return false
+}
+
+func (e *execution) Progress() Progress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	filled, _ := e.totals()
+	return Progress{
+		State:     e.state,
+		Size:      e.size,
+		Filled:    filled,
+		Remaining: decimal.Max(e.size.Sub(filled), decimal.Zero),
+		Orders:    len(e.orders),
+	}
+}
+
+// totals sums the fills of every child order. It must be called with mu held.
+func (e *execution) totals() (size, cost decimal.Decimal) {
+	for _, o := range e.children {
+		size = size.Add(o.FilledSize)
+		cost = cost.Add(o.FilledCost)
+	}
+	return size, cost
+}
+
+// remaining returns the size left to fill, rounded down to the size increment.
+func (e *execution) remaining() decimal.Decimal {
+	return e.round(e.Progress().Remaining)
+}
+
+// round rounds size down to the size increment, if any.
+func (e *execution) round(size decimal.Decimal) decimal.Decimal {
+	if !e.inc.IsPositive() {
+		return size
+	}
+	return size.Div(e.inc).Floor().Mul(e.inc)
+}
+
+// begin starts running and records the arrival mid, unless the algorithm was canceled before Run.
+func (e *execution) begin() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.arrive()
+}
+
+// start moves a pending algorithm to running.
+func (e *execution) start() error {
+	if !e.transition(StateRunning, StatePending) {
+		return errCanceled
+	}
+	return nil
+}
+
+// arrive records the current mid as the arrival price and the start of the execution.
+func (e *execution) arrive() error {
+	book, err := e.c.GetDepth(&api.GetDepthRequest{Market: e.market, Depth: 1})
+	if err != nil {
+		return fmt.Errorf("failed to get arrival price: %w", err)
+	}
+	mid, _ := book.Mid()
+
+	e.mu.Lock()
+	e.arrival = mid
+	e.started = e.now()
+	e.mu.Unlock()
+	return nil
+}
+
+// finish records the outcome of Run and returns its result.
+func (e *execution) finish(ctx context.Context, err error) (*Summary, error) {
+	e.mu.Lock()
+	e.ended = e.now()
+	if e.started.IsZero() {
+		e.started = e.ended
+	}
+	switch {
+	case err == errCanceled:
+		err = nil
+		e.state = StateCanceled
+	case errors.Is(err, errCanceled), ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		// Canceled, possibly with a child order that could not be canceled.
+		e.state = StateCanceled
+		e.err = err
+	case err != nil:
+		e.state = StateFailed
+		e.err = err
+	default:
+		e.state = StateDone
+	}
+	e.mu.Unlock()
+
+	return e.Summary(), err
+}
+
+// Summary returns the execution summary so far.
+func (e *execution) Summary() *Summary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	filled, cost := e.totals()
+	s := &Summary{
+		Algo:       e.name,
+		Market:     e.market,
+		Side:       e.side,
+		State:      e.state,
+		Size:       e.size,
+		FilledSize: filled,
+		FilledCost: cost,
+		ArrivalMid: e.arrival,
+		Orders:     append([]string(nil), e.orders...),
+		StartedAt:  e.started,
+		EndedAt:    e.ended,
+	}
+	if filled.IsPositive() {
+		s.AveragePrice = cost.Div(filled)
+	}
+	if s.AveragePrice.IsPositive() && e.arrival.IsPositive() {
+		diff := s.AveragePrice.Sub(e.arrival)
+		if e.side == model.OrderSideSell {
+			diff = diff.Neg()
+		}
+		s.SlippageBps = diff.Div(e.arrival).Mul(decimal.NewFromInt(10000)).Round(2)
+	}
+	if e.err != nil {
+		s.Error = e.err.Error()
+	}
+	return s
+}
+
+// wait blocks for d, until the state changes or until ctx is done, and returns the current state.
+func (e *execution) wait(ctx context.Context, d time.Duration) (State, error) {
+	e.mu.Lock()
+	wake := e.wake
+	e.mu.Unlock()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-wake:
+	case <-timer.C:
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.state, nil
+}
+
+// waitRunning blocks while the algorithm is paused.
+func (e *execution) waitRunning(ctx context.Context) error {
+	for {
+		e.mu.Lock()
+		state, wake := e.state, e.wake
+		e.mu.Unlock()
+
+		switch state {
+		case StateCanceled:
+			return errCanceled
+		case StatePaused:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-wake:
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// sleep waits for d of running time; time spent paused does not count.
+func (e *execution) sleep(ctx context.Context, d time.Duration) error {
+	for d > 0 {
+		if err := e.waitRunning(ctx); err != nil {
+			return err
+		}
+		start := e.now()
+		if _, err := e.wait(ctx, d); err != nil {
+			return err
+		}
+		d -= e.now().Sub(start)
+	}
+	return e.waitRunning(ctx)
+}
+
+// sleepUntil waits until t on the wall clock. With interrupt set, it returns errPaused as soon as the algorithm is paused.
+func (e *execution) sleepUntil(ctx context.Context, t time.Time, interrupt bool) error {
+	for {
+		if interrupt {
+			if err := e.interrupted(); err != nil {
+				return err
+			}
+		} else if err := e.waitRunning(ctx); err != nil {
+			return err
+		}
+		d := t.Sub(e.now())
+		if d <= 0 {
+			return nil
+		}
+		if _, err := e.wait(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// interrupted returns errCanceled or errPaused when the algorithm is no longer running.
+func (e *execution) interrupted() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch e.state {
+	case StateCanceled:
+		return errCanceled
+	case StatePaused:
+		return errPaused
+	}
+	return nil
+}
+
+// submit places a child order for the algorithm's market and side.
+func (e *execution) submit(req *api.AddOrderRequest) (*model.Order, error) {
+	req.Market = e.market
+	req.Side = e.side
+	o, err := e.c.AddOrder(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s child order: %w", e.name, err)
+	}
+
+	e.mu.Lock()
+	e.orders = append(e.orders, o.OrderID)
+	e.mu.Unlock()
+	e.update(o)
+	return o, nil
+}
+
+// update records the latest snapshot of a child order.
+func (e *execution) update(o *model.Order) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.children[o.OrderID] = o
+}
+
+// track polls a child order until it is no longer open. It cancels the order and returns errExpired when deadline
+// passes, or the reason when the algorithm is paused or canceled with interrupt set, or ctx is done.
+func (e *execution) track(ctx context.Context, o *model.Order, deadline time.Time, interrupt bool) (*model.Order, error) {
+	for o.Status == model.OrderStatusOpen {
+		d := e.poll
+		if !deadline.IsZero() {
+			if left := deadline.Sub(e.now()); left <= 0 {
+				return e.cancelChild(o, errExpired)
+			} else if left < d {
+				d = left
+			}
+		}
+
+		state, err := e.wait(ctx, d)
+		if err != nil {
+			return e.cancelChild(o, err)
+		}
+		switch {
+		case state == StateCanceled:
+			return e.cancelChild(o, errCanceled)
+		case state == StatePaused && interrupt:
+			return e.cancelChild(o, errPaused)
+		}
+
+		latest, err := e.c.GetOrder(&api.GetOrderRequest{OrderID: o.OrderID})
+		if err != nil {
+			return e.cancelChild(o, fmt.Errorf("failed to get %s child order: %w", e.name, err))
+		}
+		o = latest
+		e.update(o)
+	}
+	return o, nil
+}
+
+// cancelChild cancels an open child order, records its final fills and returns reason. When the order may still be
+// resting, the error returned no longer matches reason, so callers do not carry on as if it were canceled.
+func (e *execution) cancelChild(o *model.Order, reason error) (*model.Order, error) {
+	canceled, cancelErr := e.c.CancelOrder(&api.CancelOrderRequest{OrderID: o.OrderID})
+	if cancelErr != nil {
+		// The order may have filled in the meantime; its final state decides.
+		var err error
+		canceled, err = e.c.GetOrder(&api.GetOrderRequest{OrderID: o.OrderID})
+		if err != nil {
+			return o, fmt.Errorf("failed to cancel %s child order %s (%v): %w", e.name, o.OrderID, reason, err)
+		}
+		e.update(canceled)
+		if canceled.Status == model.OrderStatusOpen {
+			return canceled, fmt.Errorf("failed to cancel %s child order %s (%v), still open: %w", e.name, o.OrderID, reason, cancelErr)
+		}
+		return canceled, reason
+	}
+	e.update(canceled)
+	return canceled, reason
+}
@@ -0,0 +1,121 @@
+package algo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestCancelChild(t *testing.T) {
+	open := &model.Order{OrderID: "o1", Status: model.OrderStatusOpen}
+	tests := []struct {
+		name      string
+		cancelErr error
+		getErr    error
+		final     *model.Order
+		reason    error
+		wantMatch bool // Whether the error still matches reason
+	}{
+		{
+			name:      "canceled",
+			final:     &model.Order{OrderID: "o1", Status: model.OrderStatusCanceled},
+			reason:    errExpired,
+			wantMatch: true,
+		},
+		{
+			name:      "filled before the cancel",
+			cancelErr: errors.New("order is not open"),
+			final:     &model.Order{OrderID: "o1", Status: model.OrderStatusFullyFilled},
+			reason:    errExpired,
+			wantMatch: true,
+		},
+		{
+			name:      "cancel failed and the order still rests",
+			cancelErr: errors.New("503 service unavailable"),
+			final:     &model.Order{OrderID: "o1", Status: model.OrderStatusOpen},
+			reason:    errPaused,
+		},
+		{
+			name:      "expired cancel failed",
+			cancelErr: errors.New("503 service unavailable"),
+			getErr:    errors.New("503 service unavailable"),
+			reason:    errExpired,
+		},
+		{
+			name:      "paused cancel failed",
+			cancelErr: errors.New("timeout"),
+			getErr:    errors.New("timeout"),
+			reason:    errPaused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockSpotClient(ctrl)
+			if tt.cancelErr != nil {
+				c.EXPECT().CancelOrder(gomock.Any()).Return(nil, tt.cancelErr)
+				c.EXPECT().GetOrder(gomock.Any()).Return(tt.final, tt.getErr)
+			} else {
+				c.EXPECT().CancelOrder(gomock.Any()).Return(tt.final, nil)
+			}
+
+			e, err := newExecution("test", c, "AVAX-USDC", model.OrderSideBuy, decimal.NewFromInt(1), decimal.Zero, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = e.cancelChild(open, tt.reason)
+			if err == nil {
+				t.Fatal("cancelChild() returned no error")
+			}
+			if got := errors.Is(err, tt.reason); got != tt.wantMatch {
+				t.Fatalf("errors.Is(%v, %v) = %v, want %v", err, tt.reason, got, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestSummarySlippage(t *testing.T) {
+	tests := []struct {
+		name    string
+		side    model.OrderSide
+		arrival string
+		fills   [][2]string // Size and cost of each child order
+		wantAvg string
+		wantBps string
+	}{
+		{name: "buy above arrival", side: model.OrderSideBuy, arrival: "100", fills: [][2]string{{"1", "100"}, {"1", "102"}}, wantAvg: "101", wantBps: "100"},
+		{name: "buy below arrival", side: model.OrderSideBuy, arrival: "100", fills: [][2]string{{"2", "199"}}, wantAvg: "99.5", wantBps: "-50"},
+		{name: "sell below arrival", side: model.OrderSideSell, arrival: "100", fills: [][2]string{{"1", "99"}}, wantAvg: "99", wantBps: "100"},
+		{name: "sell above arrival", side: model.OrderSideSell, arrival: "100", fills: [][2]string{{"3", "303"}}, wantAvg: "101", wantBps: "-100"},
+		{name: "nothing filled", side: model.OrderSideBuy, arrival: "100", wantAvg: "0", wantBps: "0"},
+		{name: "no arrival mid", side: model.OrderSideBuy, arrival: "0", fills: [][2]string{{"1", "100"}}, wantAvg: "100", wantBps: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := newExecution("test", nil, "AVAX-USDC", tt.side, decimal.NewFromInt(10), decimal.Zero, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.arrival = d(tt.arrival)
+			for i, f := range tt.fills {
+				e.update(&model.Order{
+					OrderID:    string(rune('a' + i)),
+					FilledSize: d(f[0]),
+					FilledCost: d(f[1]),
+				})
+			}
+
+			s := e.Summary()
+			if !s.AveragePrice.Equal(d(tt.wantAvg)) || !s.SlippageBps.Equal(d(tt.wantBps)) {
+				t.Fatalf("average %s slippage %s bps, want %s and %s bps", s.AveragePrice, s.SlippageBps, tt.wantAvg, tt.wantBps)
+			}
+		})
+	}
+}
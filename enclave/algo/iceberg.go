@@ -0,0 +1,100 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// IcebergConfig configures an Iceberg.
+type IcebergConfig struct {
+	Market        string
+	Side          model.OrderSide
+	Size          decimal.Decimal // Parent size
+	Price         decimal.Decimal // Limit price of every clip
+	Clip          decimal.Decimal // Visible size resting on the book
+	Randomize     float64         // Fraction between 0 and 1 by which each clip's size varies at random
+	PostOnly      bool            // Submit clips as post-only
+	SizeIncrement decimal.Decimal // Optional increment clip sizes are rounded down to
+	PollInterval  time.Duration   // Time between two polls of the resting clip, defaults to 1 second
+}
+
+// Iceberg keeps a single limit order of the clip size resting and replaces it once it is filled, until the parent
+// size is filled. Pausing cancels the resting clip; Resume places a new one.
+type Iceberg struct {
+	*execution
+	cfg IcebergConfig
+}
+
+var _ Algo = (*Iceberg)(nil)
+
+// NewIceberg initializes an Iceberg sending child orders through c.
+func NewIceberg(c client.OrderFillClient, cfg IcebergConfig) (*Iceberg, error) {
+	if !cfg.Price.IsPositive() {
+		return nil, errors.New("price must be positive")
+	}
+	if !cfg.Clip.IsPositive() {
+		return nil, errors.New("clip must be positive")
+	}
+	if cfg.Randomize < 0 || cfg.Randomize > 1 {
+		return nil, errors.New("randomize must be between 0 and 1")
+	}
+
+	e, err := newExecution("iceberg", c, cfg.Market, cfg.Side, cfg.Size, cfg.SizeIncrement, cfg.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &Iceberg{execution: e, cfg: cfg}, nil
+}
+
+func (b *Iceberg) Run(ctx context.Context) (*Summary, error) {
+	return b.finish(ctx, b.run(ctx))
+}
+
+func (b *Iceberg) run(ctx context.Context) error {
+	if err := b.begin(); err != nil {
+		return err
+	}
+
+	for {
+		if err := b.waitRunning(ctx); err != nil {
+			return err
+		}
+		remaining := b.remaining()
+		if !remaining.IsPositive() {
+			return nil
+		}
+		size := decimal.Min(b.round(jitterSize(b.cfg.Clip, b.cfg.Randomize)), remaining)
+		if !size.IsPositive() {
+			size = remaining
+		}
+
+		o, err := b.submit(&api.AddOrderRequest{
+			Price:       b.cfg.Price,
+			Size:        size,
+			Type:        model.OrderTypeLimit,
+			TimeInForce: model.TimeInForceGTC,
+			PostOnly:    b.cfg.PostOnly,
+		})
+		if err != nil {
+			return err
+		}
+		o, err = b.track(ctx, o, time.Time{}, true)
+		switch {
+		case errors.Is(err, errPaused):
+			continue
+		case err != nil:
+			return err
+		case o.Status == model.OrderStatusCanceled:
+			// Replacing a clip the exchange or another process canceled could loop forever.
+			return fmt.Errorf("iceberg clip %s was canceled: %s", o.OrderID, o.CancelReason)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package algo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestIcebergReplenishes(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      string
+		clip      string
+		increment string
+		wantClips []string
+	}{
+		{name: "last clip takes the rest", size: "5", clip: "2", increment: "0", wantClips: []string{"2", "2", "1"}},
+		{name: "single clip", size: "1.5", clip: "2", increment: "0", wantClips: []string{"1.5"}},
+		{name: "clip rounded to the increment", size: "3", clip: "1.25", increment: "0.5", wantClips: []string{"1", "1", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockSpotClient(ctrl)
+			c.EXPECT().GetDepth(gomock.Any()).Return(depth(), nil)
+
+			// Each clip rests until the next poll finds it filled.
+			resting := map[string]*model.Order{}
+			var clips []string
+			rests := false
+			c.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+				if rests {
+					t.Error("clip placed while the previous one was still resting")
+				}
+				rests = true
+				if req.Type != model.OrderTypeLimit || !req.Price.Equal(d("99")) || !req.PostOnly {
+					t.Errorf("clip request = %+v, want a post-only limit order at 99", req)
+				}
+				o := &model.Order{OrderID: fmt.Sprint(len(clips)), Size: req.Size, Status: model.OrderStatusOpen}
+				clips = append(clips, req.Size.String())
+				resting[o.OrderID] = o
+				return o, nil
+			}).Times(len(tt.wantClips))
+			c.EXPECT().GetOrder(gomock.Any()).DoAndReturn(func(req *api.GetOrderRequest) (*model.Order, error) {
+				o := *resting[req.OrderID]
+				rests = false
+				o.Status, o.FilledSize, o.FilledCost = model.OrderStatusFullyFilled, o.Size, o.Size.Mul(d("99"))
+				return &o, nil
+			}).Times(len(tt.wantClips))
+
+			b, err := NewIceberg(c, IcebergConfig{
+				Market:        "AVAX-USDC",
+				Side:          model.OrderSideBuy,
+				Size:          d(tt.size),
+				Price:         d("99"),
+				Clip:          d(tt.clip),
+				PostOnly:      true,
+				SizeIncrement: d(tt.increment),
+				PollInterval:  time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := b.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(clips) != fmt.Sprint(tt.wantClips) {
+				t.Fatalf("clips = %v, want %v", clips, tt.wantClips)
+			}
+			if s.State != StateDone || !s.FilledSize.Equal(d(tt.size)) || !s.AveragePrice.Equal(d("99")) {
+				t.Fatalf("summary = %+v, want done with %s filled at 99", s, tt.size)
+			}
+		})
+	}
+}
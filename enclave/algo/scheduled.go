@@ -0,0 +1,99 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// ScheduledConfig configures a Scheduled order.
+type ScheduledConfig struct {
+	Order        api.AddOrderRequest // Order to submit; Market, Side and Size are required
+	SubmitAt     time.Time           // Wall-clock time to submit the order at, zero to submit immediately
+	CancelAt     time.Time           // Wall-clock time to cancel the order at if still open, zero for good-till-canceled
+	PollInterval time.Duration       // Time between two polls of the resting order, defaults to 1 second
+}
+
+// Scheduled submits an order at a wall-clock time and, as a good-till-time order, cancels what is left of it at
+// another. Pausing before SubmitAt delays the submission until Resume; pausing while the order rests cancels it,
+// and Resume submits the remaining size again unless CancelAt has passed.
+type Scheduled struct {
+	*execution
+	cfg ScheduledConfig
+}
+
+var _ Algo = (*Scheduled)(nil)
+
+// NewScheduled initializes a Scheduled order submitted through c.
+func NewScheduled(c client.OrderFillClient, cfg ScheduledConfig) (*Scheduled, error) {
+	if !cfg.SubmitAt.IsZero() && !cfg.CancelAt.IsZero() && !cfg.CancelAt.After(cfg.SubmitAt) {
+		return nil, errors.New("cancel time must be after submit time")
+	}
+
+	e, err := newExecution("scheduled", c, cfg.Order.Market, cfg.Order.Side, cfg.Order.Size, decimal.Zero, cfg.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduled{execution: e, cfg: cfg}, nil
+}
+
+// NewGoodTillTime initializes an order submitted immediately and canceled at cancelAt if still open.
+func NewGoodTillTime(c client.OrderFillClient, order api.AddOrderRequest, cancelAt time.Time) (*Scheduled, error) {
+	return NewScheduled(c, ScheduledConfig{Order: order, CancelAt: cancelAt})
+}
+
+func (s *Scheduled) Run(ctx context.Context) (*Summary, error) {
+	return s.finish(ctx, s.run(ctx))
+}
+
+func (s *Scheduled) run(ctx context.Context) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	// The arrival mid is taken when the order is due, not when Run is called.
+	if err := s.sleepUntil(ctx, s.cfg.SubmitAt, false); err != nil {
+		return err
+	}
+	if err := s.arrive(); err != nil {
+		return err
+	}
+
+	for {
+		if err := s.waitRunning(ctx); err != nil {
+			return err
+		}
+		if !s.cfg.CancelAt.IsZero() && !s.now().Before(s.cfg.CancelAt) {
+			return nil
+		}
+		remaining := s.remaining()
+		if !remaining.IsPositive() {
+			return nil
+		}
+
+		req := s.cfg.Order
+		req.Size = remaining
+		o, err := s.submit(&req)
+		if err != nil {
+			return err
+		}
+		o, err = s.track(ctx, o, s.cfg.CancelAt, true)
+		switch {
+		case errors.Is(err, errPaused):
+			continue
+		case errors.Is(err, errExpired):
+			return nil
+		case err != nil:
+			return err
+		}
+		// Market and IOC orders run once; a limit order canceled by someone else is not resubmitted.
+		if req.Type == model.OrderTypeMarket || req.TimeInForce == model.TimeInForceIOC || o.Status == model.OrderStatusCanceled {
+			return nil
+		}
+	}
+}
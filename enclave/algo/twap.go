@@ -0,0 +1,123 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// TWAPConfig configures a TWAP.
+type TWAPConfig struct {
+	Market        string
+	Side          model.OrderSide
+	Size          decimal.Decimal // Parent size
+	Duration      time.Duration   // Time over which the slices are spread, the first one being sent immediately
+	Slices        int             // Number of slices, defaults to one per minute of Duration
+	Randomize     float64         // Fraction between 0 and 1 by which each slice's size and delay vary at random
+	LimitPrice    decimal.Decimal // Optional worst price; slices are IOC limit orders at this price instead of market orders
+	SizeIncrement decimal.Decimal // Optional increment slice sizes are rounded down to
+	PollInterval  time.Duration   // Time between two polls of a slice that is not final yet, defaults to 1 second
+	SettleTimeout time.Duration   // Time after which a slice that is still open is canceled, defaults to 10 seconds
+}
+
+// TWAP slices a parent order into market or IOC limit orders sent at regular intervals.
+// Sizes adapt to what previous slices filled, so the last slice sends whatever remains.
+// Time spent paused is added to the schedule.
+type TWAP struct {
+	*execution
+	cfg TWAPConfig
+}
+
+var _ Algo = (*TWAP)(nil)
+
+// NewTWAP initializes a TWAP sending child orders through c.
+func NewTWAP(c client.OrderFillClient, cfg TWAPConfig) (*TWAP, error) {
+	if cfg.Duration < 0 {
+		return nil, errors.New("duration must not be negative")
+	}
+	if cfg.Randomize < 0 || cfg.Randomize > 1 {
+		return nil, errors.New("randomize must be between 0 and 1")
+	}
+	if cfg.Slices <= 0 {
+		cfg.Slices = max(int(cfg.Duration/time.Minute), 1)
+	}
+	if cfg.SettleTimeout <= 0 {
+		cfg.SettleTimeout = 10 * time.Second
+	}
+
+	e, err := newExecution("twap", c, cfg.Market, cfg.Side, cfg.Size, cfg.SizeIncrement, cfg.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &TWAP{execution: e, cfg: cfg}, nil
+}
+
+func (t *TWAP) Run(ctx context.Context) (*Summary, error) {
+	return t.finish(ctx, t.run(ctx))
+}
+
+func (t *TWAP) run(ctx context.Context) error {
+	if err := t.begin(); err != nil {
+		return err
+	}
+
+	interval := t.cfg.Duration / time.Duration(t.cfg.Slices)
+	for i := 0; i < t.cfg.Slices; i++ {
+		if i > 0 {
+			if err := t.sleep(ctx, jitterDuration(interval, t.cfg.Randomize)); err != nil {
+				return err
+			}
+		}
+
+		remaining := t.remaining()
+		if !remaining.IsPositive() {
+			return nil
+		}
+		size := remaining
+		if left := t.cfg.Slices - i; left > 1 {
+			size = t.round(jitterSize(remaining.Div(decimal.NewFromInt(int64(left))), t.cfg.Randomize))
+			size = decimal.Min(size, remaining)
+		}
+		if !size.IsPositive() {
+			continue
+		}
+
+		req := &api.AddOrderRequest{Size: size, Type: model.OrderTypeMarket}
+		if t.cfg.LimitPrice.IsPositive() {
+			req.Type = model.OrderTypeLimit
+			req.Price = t.cfg.LimitPrice
+			req.TimeInForce = model.TimeInForceIOC
+		}
+		o, err := t.submit(req)
+		if err != nil {
+			return err
+		}
+		if _, err := t.track(ctx, o, t.now().Add(t.cfg.SettleTimeout), false); err != nil && !errors.Is(err, errExpired) {
+			return err
+		}
+	}
+	return nil
+}
+
+// jitterSize varies size at random by up to fraction of itself.
+func jitterSize(size decimal.Decimal, fraction float64) decimal.Decimal {
+	if fraction == 0 {
+		return size
+	}
+	return size.Mul(decimal.NewFromFloat(1 + fraction*(2*rand.Float64()-1)))
+}
+
+// jitterDuration varies d at random by up to fraction of itself.
+func jitterDuration(d time.Duration, fraction float64) time.Duration {
+	if fraction == 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
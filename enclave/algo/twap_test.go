@@ -0,0 +1,128 @@
+package algo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+// depth returns a book whose mid is 100.
+func depth() *model.OrderBook {
+	return &model.OrderBook{
+		Bids: [][]decimal.Decimal{{d("99"), d("1")}},
+		Asks: [][]decimal.Decimal{{d("101"), d("1")}},
+	}
+}
+
+func TestTWAPSlicing(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       string
+		slices     int
+		increment  string
+		fill       []string // Fraction of each slice filled, the rest is canceled as an IOC
+		wantSizes  []string
+		wantFilled string
+	}{
+		{
+			name:       "even slices",
+			size:       "9",
+			slices:     3,
+			increment:  "0",
+			fill:       []string{"1", "1", "1"},
+			wantSizes:  []string{"3", "3", "3"},
+			wantFilled: "9",
+		},
+		{
+			name:       "slices rounded down, last takes the rest",
+			size:       "10",
+			slices:     3,
+			increment:  "0.1",
+			fill:       []string{"1", "1", "1"},
+			wantSizes:  []string{"3.3", "3.3", "3.4"},
+			wantFilled: "10",
+		},
+		{
+			name:       "partial fill is spread over later slices",
+			size:       "10",
+			slices:     3,
+			increment:  "0.1",
+			fill:       []string{"0.5", "1", "1"},
+			wantSizes:  []string{"3.3", "4.1", "4.2"},
+			wantFilled: "9.95",
+		},
+		{
+			name:       "remainder below the increment is left",
+			size:       "1",
+			slices:     3,
+			increment:  "0.3",
+			fill:       []string{"1", "1", "1"},
+			wantSizes:  []string{"0.3", "0.3", "0.3"},
+			wantFilled: "0.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockSpotClient(ctrl)
+			c.EXPECT().GetDepth(gomock.Any()).Return(depth(), nil)
+			var sizes []string
+			c.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+				if req.Type != model.OrderTypeLimit || req.TimeInForce != model.TimeInForceIOC || req.Market != "AVAX-USDC" {
+					t.Errorf("slice request = %+v, want an IOC limit order on AVAX-USDC", req)
+				}
+				filled := req.Size.Mul(d(tt.fill[len(sizes)]))
+				sizes = append(sizes, req.Size.String())
+				status := model.OrderStatusFullyFilled
+				if filled.LessThan(req.Size) {
+					status = model.OrderStatusCanceled
+				}
+				return &model.Order{
+					OrderID:    string(rune('a' + len(sizes))),
+					Size:       req.Size,
+					FilledSize: filled,
+					FilledCost: filled.Mul(d("100")),
+					Status:     status,
+				}, nil
+			}).Times(len(tt.wantSizes))
+
+			twap, err := NewTWAP(c, TWAPConfig{
+				Market:        "AVAX-USDC",
+				Side:          model.OrderSideBuy,
+				Size:          d(tt.size),
+				Slices:        tt.slices,
+				LimitPrice:    d("105"),
+				SizeIncrement: d(tt.increment),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := twap.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(sizes) != len(tt.wantSizes) {
+				t.Fatalf("slice sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+			for i := range sizes {
+				if !d(sizes[i]).Equal(d(tt.wantSizes[i])) {
+					t.Errorf("slice sizes = %v, want %v", sizes, tt.wantSizes)
+					break
+				}
+			}
+			if s.State != StateDone || !s.FilledSize.Equal(d(tt.wantFilled)) || len(s.Orders) != len(tt.wantSizes) {
+				t.Fatalf("summary = %+v, want done with %s filled by %d orders", s, tt.wantFilled, len(tt.wantSizes))
+			}
+		})
+	}
+}
@@ -43,8 +43,8 @@ type PagingAndTimeRange struct {
 }
 
 func (p *PagingAndTimeRange) GetUrlValues() url.Values {
-	values := p.GetUrlValues()
-	timeValues := p.GetUrlValues()
+	values := p.Paging.GetUrlValues()
+	timeValues := p.TimeRange.GetUrlValues()
 	for k, v := range timeValues {
 		for _, value := range v {
 			values.Add(k, value)
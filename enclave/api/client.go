@@ -1,14 +1,18 @@
 package api
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
 
 type GetAssetBalanceRequest struct {
-	Symbol string
+	Symbol string `json:"symbol"`
 }
 
 type GetWithdrawalStatusRequest struct {
-	CustomerWithdrawalId string `json:"customerWithdrawalId"`
-	WithdrawalId         string `json:"withdrawalId"`
+	CustomerWithdrawalId string `json:"customer_withdrawal_id,omitempty"`
+	WithdrawalId         string `json:"withdrawal_id,omitempty"`
 }
 
 type GetDepositAddressesRequest struct {
@@ -19,6 +23,15 @@ type GetDepositRequest struct {
 	TxId string `json:"txId"`
 }
 
+type GetDepositsRequest struct {
+	PagingAndTimeRange
+}
+
+type GetDepositsResponse struct {
+	PageInfo PageInfo
+	Deposits []*model.Deposit
+}
+
 type GetDepositsCSVRequest struct {
 	TimeRange
 }
@@ -27,6 +40,15 @@ type GetWithdrawalByTxIdRequest struct {
 	TxId string `json:"txId"`
 }
 
+type GetWithdrawalsRequest struct {
+	PagingAndTimeRange
+}
+
+type GetWithdrawalsResponse struct {
+	PageInfo    PageInfo
+	Withdrawals []*model.Withdrawal
+}
+
 type GetWithdrawalsCSVRequest struct {
 	TimeRange
 }
@@ -41,3 +63,34 @@ type WithdrawRequest struct {
 	CustomerWithdrawalID string          `json:"customer_withdrawal_id"` // Unique ID associated with the withdrawal
 	Symbol               string          `json:"symbol"`                 // Symbol of the coin to withdraw
 }
+
+// WalletTransferRequest represents an explicit transfer between two account wallets.
+// An empty account ID refers to the account that owns the API key.
+type WalletTransferRequest struct {
+	Symbol string                 `json:"symbol"` // Symbol to transfer, e.g., "USDC"
+	Amount decimal.Decimal        `json:"amount"` // Positive amount to transfer
+	From   model.AccountWalletKey `json:"from"`   // Account and wallet to pull funds from
+	To     model.AccountWalletKey `json:"to"`     // Account and wallet to send funds to
+}
+
+// GetTransferHistoryRequest holds optional filters for GetTransferHistory.
+type GetTransferHistoryRequest struct {
+	PagingAndTimeRange
+	Type         *model.TransferType // Only return transfers of this type
+	Counterparty string              // Only return transfers from or to this account ID
+}
+
+// GetTransferHistoryResponse holds a page of filtered transfers and the cursor of the next one.
+type GetTransferHistoryResponse struct {
+	PageInfo  PageInfo
+	Transfers []*model.Transfer
+}
+
+type CreateSubaccountRequest struct {
+	Name string `json:"name"`
+}
+
+type RenameSubaccountRequest struct {
+	ID   string `json:"-"`
+	Name string `json:"name"`
+}
@@ -68,6 +68,11 @@ type GetFillsRequest struct {
 	Market string
 }
 
+type GetFillsResponse struct {
+	PageInfo PageInfo
+	Fills    []*model.Fill
+}
+
 // GetFillsByIDRequest represents the request body for getting fills by ID.
 type GetFillsByIDRequest struct {
 	ClientOrderID string
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yangnei/enclave-go/enclave/client"
+)
+
+// redactedKeys are request body fields whose values never reach the log, matched case-insensitively.
+var redactedKeys = []string{"secret", "password", "passphrase", "signature", "apikey", "api_key", "token"}
+
+// auditBaseClient records every mutating request sent through a BaseClient.
+type auditBaseClient struct {
+	client.BaseClient
+	log     *Log
+	onError func(error)
+}
+
+// NewBaseClient wraps base so that every mutating request, see client.IsMutating, is recorded in log.
+// A request entry is written before the request is sent, and the request is not sent if that fails.
+// The response entry is written afterwards; failures to write it are reported to onError, which may be nil.
+func NewBaseClient(base client.BaseClient, log *Log, onError func(error)) client.BaseClient {
+	return &auditBaseClient{BaseClient: base, log: log, onError: onError}
+}
+
+func (c *auditBaseClient) Post(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.record(http.MethodPost, path, body, headers, func() (*http.Response, error) {
+		return c.BaseClient.Post(path, body, params, headers)
+	})
+}
+
+func (c *auditBaseClient) Delete(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.record(http.MethodDelete, path, body, headers, func() (*http.Response, error) {
+		return c.BaseClient.Delete(path, body, params, headers)
+	})
+}
+
+func (c *auditBaseClient) Put(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.record(http.MethodPut, path, body, headers, func() (*http.Response, error) {
+		return c.BaseClient.Put(path, body, params, headers)
+	})
+}
+
+func (c *auditBaseClient) record(method, path, body string, headers map[string]string, send func() (*http.Response, error)) (*http.Response, error) {
+	if !client.IsMutating(method, path) {
+		return send()
+	}
+
+	req := &Entry{
+		Kind:       EntryRequest,
+		Method:     method,
+		Path:       path,
+		Subaccount: headers[client.SubaccountHeader],
+		Body:       redact(body),
+	}
+	if err := c.log.Append(req); err != nil {
+		return nil, fmt.Errorf("failed to audit %s %s: %w", method, path, err)
+	}
+
+	resp, err := send()
+
+	res := &Entry{
+		Kind:       EntryResponse,
+		Request:    req.Seq,
+		Method:     method,
+		Path:       path,
+		Subaccount: req.Subaccount,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Status = resp.StatusCode
+		// The body is read to find exchange IDs and handed back to the caller unchanged.
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			res.Error = readErr.Error()
+		} else {
+			res.IDs = exchangeIDs(data)
+		}
+	}
+	if appendErr := c.log.Append(res); appendErr != nil && c.onError != nil {
+		c.onError(fmt.Errorf("failed to audit response of %s %s: %w", method, path, appendErr))
+	}
+	return resp, err
+}
+
+// redact returns body as JSON with sensitive fields replaced. Bodies that are not JSON are stored as a JSON string.
+func redact(body string) json.RawMessage {
+	if body == "" {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		data, _ := json.Marshal(body)
+		return data
+	}
+	data, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func redactValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, child := range x {
+			if isSensitive(k) {
+				x[k] = "[REDACTED]"
+			} else {
+				x[k] = redactValue(child)
+			}
+		}
+	case []any:
+		for i, child := range x {
+			x[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range redactedKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// exchangeIDs collects the string and number fields named like IDs, e.g., "orderId" or "withdrawal_id",
+// from the result of an API response. Arrays are searched element by element, keeping the first value of each key.
+func exchangeIDs(body []byte) map[string]string {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Result) == 0 {
+		return nil
+	}
+
+	var result any
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil
+	}
+	ids := map[string]string{}
+	collectIDs(result, ids)
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+func collectIDs(v any, ids map[string]string) {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, child := range x {
+			if !isIDKey(k) {
+				continue
+			}
+			if _, ok := ids[k]; ok {
+				continue
+			}
+			switch value := child.(type) {
+			case string:
+				if value != "" {
+					ids[k] = value
+				}
+			case float64:
+				ids[k] = fmt.Sprint(value)
+			}
+		}
+	case []any:
+		for _, child := range x {
+			collectIDs(child, ids)
+		}
+	}
+}
+
+func isIDKey(key string) bool {
+	lower := strings.ToLower(key)
+	return lower == "id" || lower == "txid" || strings.HasSuffix(key, "Id") || strings.HasSuffix(key, "ID") || strings.HasSuffix(lower, "_id")
+}
@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type EntryKind string
+
+const (
+	EntryRequest  EntryKind = "request"  // Written before a mutating request is sent
+	EntryResponse EntryKind = "response" // Written once the request completed or failed
+)
+
+// EnvKey is the environment variable the command-line tool reads the audit key from.
+const EnvKey = "ENCLAVE_AUDIT_KEY"
+
+// Entry is one line of the audit log. Hash covers every other field, including PrevHash, which chains the entries.
+type Entry struct {
+	Seq        int64             `json:"seq"`                  // Position in the log, starting at 1
+	Time       time.Time         `json:"time"`                 // Time the entry was written
+	Kind       EntryKind         `json:"kind"`                 // Request or response
+	Request    int64             `json:"request,omitempty"`    // Seq of the request entry a response belongs to
+	Method     string            `json:"method"`               // HTTP method
+	Path       string            `json:"path"`                 // Request path, including the query
+	Subaccount string            `json:"subaccount,omitempty"` // Subaccount the request acted on, if any
+	Body       json.RawMessage   `json:"body,omitempty"`       // Request body with sensitive fields redacted
+	Status     int               `json:"status,omitempty"`     // HTTP status of the response
+	IDs        map[string]string `json:"ids,omitempty"`        // Exchange IDs found in the response, e.g., orderId
+	Error      string            `json:"error,omitempty"`      // Error returned instead of a response
+	PrevHash   string            `json:"prevHash"`             // Hash of the previous entry, empty for the first one
+	Hash       string            `json:"hash"`                 // HMAC-SHA256 of the entry with an empty Hash
+}
+
+// computeHash returns the hex HMAC-SHA256 of e's JSON encoding with Hash cleared. Without the key, entries cannot be
+// rewritten or appended with a valid chain, even by someone who can write the log and its head.
+func (e Entry) computeHash(key []byte) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Head is the sequence number and hash of the last entry, kept next to the log so that truncation can be detected.
+type Head struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// HeadPath returns the path of the head file of the log at path.
+func HeadPath(path string) string {
+	return path + ".head"
+}
+
+// Log appends hash-chained entries to a JSON lines file.
+type Log struct {
+	path string
+	key  []byte
+	mu   sync.Mutex
+	head Head
+	now  func() time.Time
+}
+
+// Open opens the log at path, creating it if needed, and resumes the chain after its last entry. Entries are keyed
+// with key, which must be kept apart from the log and is needed again to verify it.
+// A log one entry ahead of its head, left by an interrupted Append, has its head completed.
+func Open(path string, key []byte) (*Log, error) {
+	if len(key) == 0 {
+		return nil, errors.New("audit key is required")
+	}
+	l := &Log{path: path, key: key, now: time.Now}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var last []byte
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if last == nil {
+		return l, nil
+	}
+
+	var e Entry
+	if err := json.Unmarshal(last, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode last audit entry: %w", err)
+	}
+	l.head = Head{Seq: e.Seq, Hash: e.Hash}
+	recorded, err := readHead(HeadPath(path))
+	if err != nil {
+		return nil, err
+	}
+	if recorded.Seq == e.Seq-1 && recorded.Hash == e.PrevHash {
+		if hash, err := e.computeHash(key); err == nil && hash == e.Hash {
+			if err := writeHead(HeadPath(path), l.head); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l, nil
+}
+
+// Path returns the path of the log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append completes e with its sequence number, time and hashes, then writes and syncs it along with the head file.
+// The entry and the head are written together: when either fails, the log is truncated back to its previous end.
+func (l *Log) Append(e *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.head.Seq + 1
+	e.Time = l.now().UTC()
+	e.PrevHash = l.head.Hash
+	hash, err := e.computeHash(l.key)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	size := info.Size()
+
+	head := Head{Seq: e.Seq, Hash: e.Hash}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		err = fmt.Errorf("failed to write audit log: %w", err)
+	} else if err = f.Sync(); err != nil {
+		err = fmt.Errorf("failed to sync audit log: %w", err)
+	} else {
+		err = writeHead(HeadPath(l.path), head)
+	}
+	if err != nil {
+		if terr := f.Truncate(size); terr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back audit log: %w", terr))
+		}
+		return err
+	}
+
+	l.head = head
+	return nil
+}
+
+// readHead reads the head file at path. A missing file records an empty log.
+func readHead(path string) (Head, error) {
+	var head Head
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return head, nil
+	case err != nil:
+		return head, fmt.Errorf("failed to read audit head: %w", err)
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return head, fmt.Errorf("failed to decode audit head: %w", err)
+	}
+	return head, nil
+}
+
+func writeHead(path string, head Head) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	return nil
+}
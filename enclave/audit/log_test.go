@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testKey = []byte("test key")
+
+func writeLog(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := l.Append(&Entry{Kind: EntryRequest, Method: "POST", Path: "/v1/orders"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestVerifyFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		tamper  func(t *testing.T, path string)
+		wantErr bool
+	}{
+		{name: "intact"},
+		{name: "wrong key", key: []byte("other key"), wantErr: true},
+		{
+			name: "entry edited",
+			tamper: func(t *testing.T, path string) {
+				data, _ := os.ReadFile(path)
+				os.WriteFile(path, bytes.Replace(data, []byte("/v1/orders"), []byte("/v1/ordersX"), 1), 0o600)
+			},
+			wantErr: true,
+		},
+		{
+			name: "last entry removed",
+			tamper: func(t *testing.T, path string) {
+				data, _ := os.ReadFile(path)
+				lines := bytes.SplitAfter(data, []byte("\n"))
+				os.WriteFile(path, bytes.Join(lines[:len(lines)-2], nil), 0o600)
+			},
+			wantErr: true,
+		},
+		{
+			name: "entry appended without the key",
+			tamper: func(t *testing.T, path string) {
+				forged, err := Open(path, []byte("guessed key"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := forged.Append(&Entry{Kind: EntryRequest, Method: "DELETE", Path: "/v1/orders"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, 3)
+			if tt.tamper != nil {
+				tt.tamper(t, path)
+			}
+			key := testKey
+			if tt.key != nil {
+				key = tt.key
+			}
+			head, err := VerifyFile(path, key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && head.Seq != 3 {
+				t.Fatalf("head = %+v, want entry 3", head)
+			}
+		})
+	}
+}
+
+func TestAppendRollsBackWhenHeadFails(t *testing.T) {
+	path := writeLog(t, 2)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Open(path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory in place of the temporary head file makes writing the head fail.
+	if err := os.Mkdir(HeadPath(path)+".tmp", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(&Entry{Kind: EntryRequest, Method: "POST", Path: "/v1/orders"}); err == nil {
+		t.Fatal("Append() succeeded without writing the head")
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("log kept the entry whose head could not be written")
+	}
+	if err := os.Remove(HeadPath(path) + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(&Entry{Kind: EntryRequest, Method: "POST", Path: "/v1/orders"}); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := VerifyFile(path, testKey); err != nil || head.Seq != 3 {
+		t.Fatalf("VerifyFile() = %+v, %v, want entry 3", head, err)
+	}
+}
+
+func TestOpenCompletesInterruptedAppend(t *testing.T) {
+	path := writeLog(t, 2)
+	head, err := readHead(HeadPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(&Entry{Kind: EntryResponse, Request: 2, Method: "POST", Path: "/v1/orders", Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	// Restore the head of entry 2, as if the process died before writing the head of entry 3.
+	if err := writeHead(HeadPath(path), head); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(path, testKey); !errors.Is(err, ErrTampered) {
+		t.Fatalf("VerifyFile() before Open = %v, want ErrTampered", err)
+	}
+
+	if _, err := Open(path, testKey); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := VerifyFile(path, testKey); err != nil || head.Seq != 3 {
+		t.Fatalf("VerifyFile() after Open = %+v, %v, want entry 3", head, err)
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxLineSize bounds a single audit entry, which mostly depends on the size of request bodies.
+const maxLineSize = 4 * 1024 * 1024
+
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// VerifyError locates the first entry that breaks the chain.
+type VerifyError struct {
+	Line   int    // 1-based line number, 0 when the log as a whole does not match its head
+	Reason string // What does not match
+}
+
+func (e *VerifyError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", ErrTampered, e.Reason)
+	}
+	return fmt.Sprintf("%s: line %d: %s", ErrTampered, e.Line, e.Reason)
+}
+
+func (e *VerifyError) Is(target error) bool {
+	return target == ErrTampered
+}
+
+// Verify reads a whole log and checks that every entry's hash, keyed with key, matches its content and links to the
+// previous entry, and that sequence numbers are contiguous from 1. It returns the head of the log.
+// Verify alone cannot detect the removal of trailing entries; use VerifyFile or compare the head to a trusted copy.
+func Verify(r io.Reader, key []byte) (*Head, error) {
+	if len(key) == 0 {
+		return nil, errors.New("audit key is required")
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	head := &Head{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			return nil, &VerifyError{Line: line, Reason: "empty line"}
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, &VerifyError{Line: line, Reason: fmt.Sprintf("invalid entry: %v", err)}
+		}
+		if e.Seq != head.Seq+1 {
+			return nil, &VerifyError{Line: line, Reason: fmt.Sprintf("sequence %d follows %d", e.Seq, head.Seq)}
+		}
+		if e.PrevHash != head.Hash {
+			return nil, &VerifyError{Line: line, Reason: "previous hash does not match the previous entry"}
+		}
+		hash, err := e.computeHash(key)
+		if err != nil {
+			return nil, err
+		}
+		if hash != e.Hash {
+			return nil, &VerifyError{Line: line, Reason: "hash does not match the entry's content"}
+		}
+		head.Seq, head.Hash = e.Seq, e.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return head, nil
+}
+
+// VerifyFile verifies the log at path and checks that it ends at the entry recorded in its head file,
+// which detects truncation. A missing head file is only accepted for an empty log.
+func VerifyFile(path string, key []byte) (*Head, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	head, err := Verify(f, key)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := readHead(HeadPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if head.Seq != expected.Seq {
+		return nil, &VerifyError{Reason: fmt.Sprintf("log ends at entry %d but its head records entry %d", head.Seq, expected.Seq)}
+	}
+	if head.Hash != expected.Hash {
+		return nil, &VerifyError{Reason: fmt.Sprintf("hash of entry %d does not match its head", head.Seq)}
+	}
+	return head, nil
+}
@@ -0,0 +1,205 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	bech32Charset  = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// BitcoinParams describes the address formats of a Bitcoin-style chain.
+type BitcoinParams struct {
+	HRP      string // Human-readable part of bech32 segwit addresses, e.g., "bc"
+	Versions []byte // Allowed base58check version bytes, e.g., 0x00 for P2PKH and 0x05 for P2SH
+}
+
+// ValidateBitcoin checks a segwit bech32/bech32m address or a legacy base58check address against params.
+func ValidateBitcoin(address string, params BitcoinParams) error {
+	if params.HRP != "" && strings.HasPrefix(strings.ToLower(address), params.HRP+"1") {
+		return validateSegwit(address, params.HRP)
+	}
+
+	payload, version, err := decodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 20 {
+		return fmt.Errorf("base58 address payload must be 20 bytes, got %d", len(payload))
+	}
+	if bytes.IndexByte(params.Versions, version) < 0 {
+		return fmt.Errorf("base58 address version 0x%02x is not valid on this network", version)
+	}
+	return nil
+}
+
+// ValidateBase58Length checks that address is plain base58 and decodes to size bytes, e.g., 32 for Solana.
+func ValidateBase58Length(address string, size int) error {
+	decoded, err := decodeBase58(address)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != size {
+		return fmt.Errorf("address must decode to %d bytes, got %d", size, len(decoded))
+	}
+	return nil
+}
+
+// ValidateBase58Check checks a base58check address with a 20-byte payload and the given version byte, e.g., 0x41 for Tron.
+func ValidateBase58Check(address string, version byte) error {
+	payload, v, err := decodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if v != version || len(payload) != 20 {
+		return fmt.Errorf("address is not a version 0x%02x base58check address", version)
+	}
+	return nil
+}
+
+func validateSegwit(address, hrp string) error {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return fmt.Errorf("bech32 address must not mix case")
+	}
+	address = strings.ToLower(address)
+	if len(address) > 90 {
+		return fmt.Errorf("bech32 address is too long")
+	}
+
+	sep := strings.LastIndexByte(address, '1')
+	if address[:sep] != hrp || len(address)-sep-1 < 6 {
+		return fmt.Errorf("bech32 address must start with %s1", hrp)
+	}
+
+	data := make([]byte, 0, len(address)-sep-1)
+	for _, c := range address[sep+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return fmt.Errorf("bech32 address contains invalid character %q", c)
+		}
+		data = append(data, byte(i))
+	}
+
+	checksum := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+	values := data[:len(data)-6]
+	if len(values) == 0 {
+		return fmt.Errorf("bech32 address has no witness version")
+	}
+
+	witnessVersion := values[0]
+	switch {
+	case witnessVersion == 0 && checksum != bech32Const:
+		return fmt.Errorf("segwit v0 address has an invalid bech32 checksum")
+	case witnessVersion > 0 && checksum != bech32mConst:
+		return fmt.Errorf("segwit v%d address has an invalid bech32m checksum", witnessVersion)
+	case witnessVersion > 16:
+		return fmt.Errorf("invalid witness version %d", witnessVersion)
+	}
+
+	program, err := convertBits(values[1:], 5, 8)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	if witnessVersion == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("segwit v0 program must be 20 or 32 bytes, got %d", len(program))
+	}
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	res := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		res = append(res, hrp[i]>>5)
+	}
+	res = append(res, 0)
+	for i := 0; i < len(hrp); i++ {
+		res = append(res, hrp[i]&31)
+	}
+	return res
+}
+
+// convertBits regroups 5-bit bech32 values into bytes, rejecting non-zero padding.
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+	var res []byte
+	for _, v := range data {
+		acc = acc<<from | uint(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			res = append(res, byte(acc>>bits&maxv))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxv != 0 {
+		return nil, fmt.Errorf("bech32 address has invalid padding")
+	}
+	return res, nil
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("address is empty")
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("address contains invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func decodeBase58Check(s string) (payload []byte, version byte, err error) {
+	decoded, err := decodeBase58(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(decoded) < 5 {
+		return nil, 0, fmt.Errorf("base58check address is too short")
+	}
+
+	body, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(body)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, 0, fmt.Errorf("base58check address has an invalid checksum")
+	}
+	return body[1:], body[0], nil
+}
@@ -0,0 +1,46 @@
+package chain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ValidateEVM checks an EVM address such as "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed".
+// Mixed-case addresses must carry a valid EIP-55 checksum; all-lowercase and all-uppercase addresses carry none.
+func ValidateEVM(address string) error {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return fmt.Errorf("EVM address must be 0x followed by 40 hex characters")
+	}
+	body := address[2:]
+	if _, err := hex.DecodeString(body); err != nil {
+		return fmt.Errorf("EVM address contains non-hex characters")
+	}
+
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return nil
+	}
+	if ChecksumEVM(address) != address {
+		return fmt.Errorf("EVM address has an invalid EIP-55 checksum")
+	}
+	return nil
+}
+
+// ChecksumEVM returns the EIP-55 checksummed form of a 0x-prefixed hex address.
+func ChecksumEVM(address string) string {
+	body := strings.ToLower(strings.TrimPrefix(address, "0x"))
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(body))
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	res := []byte(body)
+	for i, c := range res {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			res[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(res)
+}
@@ -0,0 +1,61 @@
+package chain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yangnei/enclave-go/enclave"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// ExplorerBaseURL returns the network's block explorer for the environment: mainnet in prod, testnet otherwise.
+func ExplorerBaseURL(network *model.BlockchainNetwork, env enclave.Environment) (string, error) {
+	base := network.TestnetBlockExplorerBaseUrl
+	if env.IsMainnet() {
+		base = network.MainnetBlockExplorerBaseUrl
+	}
+	if base == "" {
+		return "", fmt.Errorf("no block explorer configured for %s in %s", network.Type, env)
+	}
+	return strings.TrimRight(base, "/"), nil
+}
+
+// TxURL returns the block explorer page of a transaction on the network.
+func TxURL(network *model.BlockchainNetwork, env enclave.Environment, txID string) (string, error) {
+	if txID == "" {
+		return "", fmt.Errorf("txid is required")
+	}
+	base, err := ExplorerBaseURL(network, env)
+	if err != nil {
+		return "", err
+	}
+	return base + "/tx/" + url.PathEscape(txID), nil
+}
+
+// AddressURL returns the block explorer page of an address on the network.
+func AddressURL(network *model.BlockchainNetwork, env enclave.Environment, address string) (string, error) {
+	base, err := ExplorerBaseURL(network, env)
+	if err != nil {
+		return "", err
+	}
+	return base + "/address/" + url.PathEscape(address), nil
+}
+
+// DepositURL returns the block explorer page of a deposit's transaction.
+func (v *Validator) DepositURL(d *model.Deposit) (string, error) {
+	network, err := v.Network(d.Coin)
+	if err != nil {
+		return "", err
+	}
+	return TxURL(network, v.env, d.TxID)
+}
+
+// WithdrawalURL returns the block explorer page of a withdrawal's transaction, which is only known once it is broadcast.
+func (v *Validator) WithdrawalURL(w *model.Withdrawal) (string, error) {
+	network, err := v.Network(w.Coin)
+	if err != nil {
+		return "", err
+	}
+	return TxURL(network, v.env, w.TxID)
+}
@@ -0,0 +1,169 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yangnei/enclave-go/enclave"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var (
+	ErrUnknownNetwork = errors.New("unknown blockchain network")
+	ErrUnknownCoin    = errors.New("unknown coin")
+)
+
+// Rule validates addresses on one network. Testnet is used outside of the prod environment and defaults to Mainnet when nil.
+type Rule struct {
+	Mainnet func(address string) error
+	Testnet func(address string) error
+}
+
+func bitcoinRule(mainnet, testnet BitcoinParams) Rule {
+	return Rule{
+		Mainnet: func(address string) error { return ValidateBitcoin(address, mainnet) },
+		Testnet: func(address string) error { return ValidateBitcoin(address, testnet) },
+	}
+}
+
+// EVM validates addresses of EVM chains, which share one format on mainnet and testnet.
+var EVM = Rule{Mainnet: ValidateEVM}
+
+// Rules of networks without an EVM chain ID, registered under the BlockchainNetwork.Type the markets report for them.
+var (
+	Bitcoin = bitcoinRule(
+		BitcoinParams{HRP: "bc", Versions: []byte{0x00, 0x05}},
+		BitcoinParams{HRP: "tb", Versions: []byte{0x6f, 0xc4}},
+	)
+	Litecoin = bitcoinRule(
+		BitcoinParams{HRP: "ltc", Versions: []byte{0x30, 0x32, 0x05}},
+		BitcoinParams{HRP: "tltc", Versions: []byte{0x6f, 0x3a, 0xc4}},
+	)
+	Dogecoin = bitcoinRule(
+		BitcoinParams{Versions: []byte{0x1e, 0x16}},
+		BitcoinParams{Versions: []byte{0x71, 0xc4}},
+	)
+	Solana = Rule{Mainnet: func(address string) error { return ValidateBase58Length(address, 32) }}
+	Tron   = Rule{Mainnet: func(address string) error { return ValidateBase58Check(address, 0x41) }}
+)
+
+// Generic checks addresses of networks without a registered rule: it rejects what cannot be an address on any
+// network, but cannot tell a valid address from a mistyped one.
+var Generic = Rule{Mainnet: ValidateGeneric}
+
+// ValidateGeneric checks that address is 20 to 128 ASCII letters and digits, optionally with a "0x" prefix.
+func ValidateGeneric(address string) error {
+	body := strings.TrimPrefix(address, "0x")
+	if n := len(body); n < 20 || n > 128 {
+		return fmt.Errorf("address must be 20 to 128 characters, got %d", n)
+	}
+	for _, r := range body {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return fmt.Errorf("address contains invalid character %q", r)
+		}
+	}
+	return nil
+}
+
+// rules is keyed by the lowercase BlockchainNetwork.Type: the chain ID of EVM networks, listing the testnet of each
+// mainnet after it, and the native coin of the others.
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{
+		"1":        EVM, // Ethereum
+		"11155111": EVM, // Sepolia
+		"43114":    EVM, // Avalanche C-Chain
+		"43113":    EVM, // Avalanche Fuji
+		"42161":    EVM, // Arbitrum One
+		"421614":   EVM, // Arbitrum Sepolia
+		"10":       EVM, // OP Mainnet
+		"11155420": EVM, // OP Sepolia
+		"8453":     EVM, // Base
+		"84532":    EVM, // Base Sepolia
+		"137":      EVM, // Polygon
+		"80002":    EVM, // Polygon Amoy
+		"56":       EVM, // BNB Smart Chain
+		"97":       EVM, // BNB Smart Chain testnet
+		"btc":      Bitcoin,
+		"ltc":      Litecoin,
+		"doge":     Dogecoin,
+		"sol":      Solana,
+		"trx":      Tron,
+	}
+)
+
+// Register adds or replaces the rule for a network, keyed like BlockchainNetwork.Type, e.g., "43114" or "BTC".
+func Register(network string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[strings.ToLower(network)] = rule
+}
+
+// ValidateAddress checks address against the rule registered for network. Networks without a registered rule are
+// checked with Generic.
+func ValidateAddress(network, address string, env enclave.Environment) error {
+	rulesMu.RLock()
+	rule, ok := rules[strings.ToLower(network)]
+	rulesMu.RUnlock()
+	if !ok {
+		rule = Generic
+	}
+
+	check := rule.Mainnet
+	if !env.IsMainnet() && rule.Testnet != nil {
+		check = rule.Testnet
+	}
+	if err := check(address); err != nil {
+		return fmt.Errorf("invalid %s address %s: %w", network, address, err)
+	}
+	return nil
+}
+
+// Validator resolves coins to their blockchain network through market metadata and validates addresses and builds explorer URLs.
+type Validator struct {
+	markets *model.Market
+	env     enclave.Environment
+}
+
+// NewValidator initializes a Validator from the exchange's market metadata for the given environment.
+func NewValidator(markets *model.Market, env enclave.Environment) *Validator {
+	return &Validator{markets: markets, env: env}
+}
+
+// Network returns the blockchain network the coin is traded on.
+// TokenConfig.Network is matched against BlockchainNetwork.Type, then BlockchainNetwork.Coin.
+func (v *Validator) Network(coin string) (*model.BlockchainNetwork, error) {
+	var network string
+	for _, tc := range v.markets.TokenConfig {
+		if strings.EqualFold(tc.Id, coin) {
+			network = tc.Network
+			break
+		}
+	}
+	if network == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCoin, coin)
+	}
+
+	for _, bn := range v.markets.BlockchainNetwork {
+		if strings.EqualFold(bn.Type, network) {
+			return bn, nil
+		}
+	}
+	for _, bn := range v.markets.BlockchainNetwork {
+		if strings.EqualFold(bn.Coin, network) {
+			return bn, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, network)
+}
+
+// Validate checks that address can receive coin on its network.
+func (v *Validator) Validate(coin, address string) error {
+	network, err := v.Network(coin)
+	if err != nil {
+		return err
+	}
+	return ValidateAddress(network.Type, address, v.env)
+}
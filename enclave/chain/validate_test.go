@@ -0,0 +1,237 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yangnei/enclave-go/enclave"
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func loadMarkets(t *testing.T) *model.Market {
+	t.Helper()
+	data, err := os.ReadFile("testdata/markets.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp api.Response[*model.Market]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Result
+}
+
+func TestFixtureNetworksHaveRules(t *testing.T) {
+	for _, bn := range loadMarkets(t).BlockchainNetwork {
+		rulesMu.RLock()
+		_, ok := rules[strings.ToLower(bn.Type)]
+		rulesMu.RUnlock()
+		if !ok {
+			t.Errorf("no rule registered for %s (%s)", bn.Type, bn.MainnetName)
+		}
+	}
+}
+
+func TestValidatorValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		coin    string
+		address string
+		env     enclave.Environment
+		invalid bool
+		wantErr error
+	}{
+		{
+			name:    "checksummed address",
+			coin:    "AVAX",
+			address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			env:     enclave.EnvironmentProd,
+		},
+		{
+			name:    "lowercase address on testnet",
+			coin:    "USDC",
+			address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			env:     enclave.EnvironmentSandbox,
+		},
+		{
+			name:    "bad checksum",
+			coin:    "ETH",
+			address: "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			env:     enclave.EnvironmentProd,
+			invalid: true,
+		},
+		{
+			name:    "bitcoin address on an EVM chain",
+			coin:    "USDC",
+			address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			env:     enclave.EnvironmentProd,
+			invalid: true,
+		},
+		{
+			name:    "bitcoin",
+			coin:    "BTC",
+			address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			env:     enclave.EnvironmentProd,
+		},
+		{
+			name:    "bitcoin testnet address on mainnet",
+			coin:    "BTC",
+			address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			env:     enclave.EnvironmentProd,
+			invalid: true,
+		},
+		{
+			name:    "solana",
+			coin:    "SOL",
+			address: "11111111111111111111111111111111",
+			env:     enclave.EnvironmentProd,
+		},
+		{
+			name:    "token on tron",
+			coin:    "USDT",
+			address: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			env:     enclave.EnvironmentProd,
+		},
+		{
+			name:    "EVM address for a token on tron",
+			coin:    "USDT",
+			address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			env:     enclave.EnvironmentProd,
+			invalid: true,
+		},
+		{
+			name:    "unlisted coin",
+			coin:    "XRP",
+			address: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+			env:     enclave.EnvironmentProd,
+			wantErr: ErrUnknownCoin,
+		},
+	}
+
+	v := NewValidator(loadMarkets(t), enclave.EnvironmentProd)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v.env = tt.env
+			err := v.Validate(tt.coin, tt.address)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.invalid:
+				if err == nil {
+					t.Fatal("Validate() succeeded, want an invalid address")
+				}
+			case err != nil:
+				t.Fatalf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidatorUnknownNetwork(t *testing.T) {
+	markets := loadMarkets(t)
+	markets.TokenConfig = append(markets.TokenConfig, &model.TokenConfig{Id: "XRP", Network: "XRP"})
+	err := NewValidator(markets, enclave.EnvironmentProd).Validate("XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh")
+	if !errors.Is(err, ErrUnknownNetwork) {
+		t.Fatalf("Validate() error = %v, want %v", err, ErrUnknownNetwork)
+	}
+}
+
+func TestValidatorNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []*model.TokenConfig
+		wantType string
+	}{
+		{
+			name:     "matched by chain ID",
+			tokens:   []*model.TokenConfig{{Id: "AVAX", Network: "43114"}},
+			wantType: "43114",
+		},
+		{
+			name:     "matched by native coin",
+			tokens:   []*model.TokenConfig{{Id: "AVAX", Network: "avax"}},
+			wantType: "43114",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markets := loadMarkets(t)
+			markets.TokenConfig = tt.tokens
+			network, err := NewValidator(markets, enclave.EnvironmentProd).Network("avax")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if network.Type != tt.wantType {
+				t.Fatalf("Network() = %s, want %s", network.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		address string
+		env     enclave.Environment
+		valid   bool
+	}{
+		{"segwit mainnet", Bitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", enclave.EnvironmentProd, true},
+		{"taproot mainnet", Bitcoin, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", enclave.EnvironmentProd, true},
+		{"legacy mainnet", Bitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", enclave.EnvironmentProd, true},
+		{"segwit testnet", Bitcoin, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", enclave.EnvironmentSandbox, true},
+		{"mainnet address on testnet", Bitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", enclave.EnvironmentSandbox, false},
+		{"bad bech32 checksum", Bitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", enclave.EnvironmentProd, false},
+		{"bad base58 checksum", Bitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", enclave.EnvironmentProd, false},
+		{"solana", Solana, "11111111111111111111111111111111", enclave.EnvironmentProd, true},
+		{"solana too short", Solana, "1111111111111111111111111111111", enclave.EnvironmentProd, false},
+		{"tron", Tron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", enclave.EnvironmentProd, true},
+		{"tron with bitcoin version", Tron, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", enclave.EnvironmentProd, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Register("test", tt.rule)
+			t.Cleanup(func() {
+				rulesMu.Lock()
+				delete(rules, "test")
+				rulesMu.Unlock()
+			})
+			err := ValidateAddress("test", tt.address, tt.env)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateAddress() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateAddressGeneric(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		valid   bool
+	}{
+		{"base58", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", true},
+		{"hex", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"too short", "rHb9CJAWyB4rj91VRWn", false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"whitespace", "rHb9CJAWyB4rj91VRWn96 DkukG4bwdtyTh", false},
+		{"punctuation", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh!", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddress("xrp", tt.address, enclave.EnvironmentProd)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateAddress() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
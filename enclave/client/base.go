@@ -1,10 +1,12 @@
 package client
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +14,8 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/yangnei/enclave-go/enclave/api"
 )
 
@@ -30,17 +34,25 @@ type baseClient struct {
 	APISecret string
 	Client    *http.Client
 	UserAgent string
+	limiter   *rate.Limiter
+
+	productionWrites bool
+	readOnly         bool
 }
 
 // NewBaseClient initializes a new baseClient with the provided API credentials and base URL.
-func NewBaseClient(apiKey, apiSecret, baseURL string) BaseClient {
-	return &baseClient{
+func NewBaseClient(apiKey, apiSecret, baseURL string, opts ...Option) BaseClient {
+	c := &baseClient{
 		BaseURL:   strings.TrimRight(baseURL, "/"),
 		KeyID:     apiKey,
 		APISecret: apiSecret,
 		Client:    &http.Client{Timeout: 10 * time.Second},
 		UserAgent: "enclave-go",
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // doRequest sends an HTTP request with authentication and returns the HTTP response.
@@ -49,6 +61,9 @@ func (c *baseClient) doRequest(method, path, body string, params map[string]stri
 	if method != http.MethodGet && method != http.MethodPost && method != http.MethodDelete && method != http.MethodPut {
 		return nil, fmt.Errorf("unsupported HTTP method %s", method)
 	}
+	if err := c.guard(method, path); err != nil {
+		return nil, err
+	}
 
 	// Build URL with query parameters
 	u, err := url.Parse(c.BaseURL + path)
@@ -95,6 +110,13 @@ func (c *baseClient) doRequest(method, path, body string, params map[string]stri
 		req.Header.Set(k, v)
 	}
 
+	// Wait for the rate limiter before signing, so the timestamp is fresh when the request is sent
+	if c.limiter != nil {
+		if err := c.limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add authentication headers
 	err = c.addAuthHeaders(req, body)
 	if err != nil {
@@ -161,5 +183,39 @@ func (c *baseClient) HandleError(res *http.Response) error {
 	if err != nil {
 		return fmt.Errorf("failed to decode error response: %w", err)
 	}
-	return fmt.Errorf("API error: %s (code: %s)", apiErr.Error, apiErr.ErrorCode)
+	return &APIError{StatusCode: res.StatusCode, Message: apiErr.Error, Code: apiErr.ErrorCode}
+}
+
+// ErrNotFound matches API errors answering that the requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// APIError is an error response decoded from the API.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s (code: %s)", e.Message, e.Code)
+}
+
+// Is reports 404 responses as ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// getStream sends a GET request and returns the response body unread, closing it if the request failed.
+func getStream(c BaseClient, path string) (io.ReadCloser, error) {
+	resp, err := c.Get(path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.HandleError(resp)
+	}
+
+	return resp.Body, nil
 }
@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/yangnei/enclave-go/enclave/api"
@@ -23,15 +24,25 @@ type Client interface {
 	GetAssetBalances() ([]*model.AssetBalance, error)
 	GetDepositAddresses(req *api.GetDepositAddressesRequest) ([]*model.Address, error)
 	GetDeposits() ([]*model.Deposit, error)
+	GetDepositsPage(req *api.GetDepositsRequest) (*api.GetDepositsResponse, error)
 	GetDeposit(req *api.GetDepositRequest) (*model.Deposit, error)
 	GetDepositsCSV(req *api.GetDepositsCSVRequest) (string, error)
+	GetDepositsCSVStream(req *api.GetDepositsCSVRequest) (io.ReadCloser, error)
 	GetWithdrawals() ([]*model.Withdrawal, error)
+	GetWithdrawalsPage(req *api.GetWithdrawalsRequest) (*api.GetWithdrawalsResponse, error)
 	GetWithdrawal() (*model.Withdrawal, error)
 	GetWithdrawalLimit() (*model.WithdrawalLimit, error)
 	GetWithdrawalByTxId(req *api.GetWithdrawalByTxIdRequest) (*model.Withdrawal, error)
 	GetWithdrawalsCSV(req *api.GetWithdrawalsCSVRequest) (string, error)
+	GetWithdrawalsCSVStream(req *api.GetWithdrawalsCSVRequest) (io.ReadCloser, error)
 	ProvisionAddress(req *api.ProvisionAddressRequest) (*model.Address, error)
 	Withdraw(req *api.WithdrawRequest) (*model.NewWithdrawal, error)
+	Transfer(req *api.WalletTransferRequest) (*model.Transfer, error)
+	GetTransferHistory(req *api.GetTransferHistoryRequest) (*api.GetTransferHistoryResponse, error)
+	GetSubaccounts() ([]*model.Subaccount, error)
+	CreateSubaccount(req *api.CreateSubaccountRequest) (*model.Subaccount, error)
+	RenameSubaccount(req *api.RenameSubaccountRequest) (*model.Subaccount, error)
+	ForSubaccount(subaccountID string) Client
 }
 
 type client struct {
@@ -40,8 +51,8 @@ type client struct {
 	pc PerpsClient
 }
 
-func NewClient(apiKey, apiSecret, baseURL string) Client {
-	return NewClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL))
+func NewClient(apiKey, apiSecret, baseURL string, opts ...Option) Client {
+	return NewClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL, opts...))
 }
 
 func NewClientWithBase(base BaseClient) Client {
@@ -103,33 +114,161 @@ func (c *client) AuthenticatedHello() (*model.AuthenticatedHello, error) {
 }
 
 // GetAccount returns the account associated with the API key.
+// GET /v1/account
 func (c *client) GetAccount() (*model.Account, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v1/account", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.Account]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode account: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetAddressBook returns the user's address book.
+// GET /v0/address_book
 func (c *client) GetAddressBook() (*model.AddressBook, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v0/address_book", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address book: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.AddressBook]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode address book: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetMarkets returns the list of markets available on the exchange.
+// GET /v1/markets
 func (c *client) GetMarkets() (*model.Market, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v1/markets", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get markets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.Market]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode markets: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetAssetBalance returns the balance of a specific asset.
+// POST /v0/get_balance
 func (c *client) GetAssetBalance(req *api.GetAssetBalanceRequest) (*model.AssetBalance, error) {
-	return nil, fmt.Errorf("not implemented")
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post("/v0/get_balance", string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset balance: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.AssetBalance]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode asset balance: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetWithdrawalStatus returns the status of a withdrawal.
+// POST /v0/withdrawal_status
 func (c *client) GetWithdrawalStatus(req *api.GetWithdrawalStatusRequest) (*model.WithdrawalStatus, error) {
-	return nil, fmt.Errorf("not implemented")
+	if (req.CustomerWithdrawalId == "" && req.WithdrawalId == "") || (req.CustomerWithdrawalId != "" && req.WithdrawalId != "") {
+		return nil, fmt.Errorf("must provide exactly one of customerWithdrawalId or withdrawalId")
+	}
+
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post("/v0/withdrawal_status", string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawal status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.WithdrawalStatus]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode withdrawal status: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetAssetBalances returns the balances of all assets.
+// POST /v0/get_balances
 func (c *client) GetAssetBalances() ([]*model.AssetBalance, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Post("/v0/get_balances", "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset balances: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[[]*model.AssetBalance]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode asset balances: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetDepositAddresses returns the deposit addresses for the specified coins.
@@ -138,8 +277,59 @@ func (c *client) GetDepositAddresses(req *api.GetDepositAddressesRequest) ([]*mo
 }
 
 // GetDeposits returns the list of deposits.
+// GET /v1/deposits
 func (c *client) GetDeposits() ([]*model.Deposit, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v1/deposits", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deposits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[[]*model.Deposit]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deposits: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
+}
+
+// GetDepositsPage returns a page of deposits and the cursor of the next one.
+// GET /v1/deposits
+func (c *client) GetDepositsPage(req *api.GetDepositsRequest) (*api.GetDepositsResponse, error) {
+	path := "/v1/deposits"
+	if encodedQuery := req.GetUrlValues().Encode(); encodedQuery != "" {
+		path += "?" + encodedQuery
+	}
+
+	resp, err := c.Get(path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deposits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.PaginatedResponse[[]*model.Deposit]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deposits: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return &api.GetDepositsResponse{
+		PageInfo: apiResp.PageInfo,
+		Deposits: apiResp.Result,
+	}, nil
 }
 
 // GetDeposit returns the details of a specific deposit.
@@ -148,13 +338,88 @@ func (c *client) GetDeposit(req *api.GetDepositRequest) (*model.Deposit, error)
 }
 
 // GetDepositsCSV returns the list of deposits in CSV format.
+// GET /v1/deposits/csv
 func (c *client) GetDepositsCSV(req *api.GetDepositsCSVRequest) (string, error) {
-	return "", fmt.Errorf("not implemented")
+	body, err := c.GetDepositsCSVStream(req)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read deposits CSV: %w", err)
+	}
+
+	return string(bodyBytes), nil
+}
+
+// GetDepositsCSVStream returns the list of deposits in CSV format without buffering the response.
+// The caller must close the returned body.
+// GET /v1/deposits/csv
+func (c *client) GetDepositsCSVStream(req *api.GetDepositsCSVRequest) (io.ReadCloser, error) {
+	path := "/v1/deposits/csv"
+	if encodedQuery := req.GetUrlValues().Encode(); encodedQuery != "" {
+		path += "?" + encodedQuery
+	}
+
+	return getStream(c.BaseClient, path)
 }
 
 // GetWithdrawals returns the list of withdrawals.
+// GET /v1/withdrawals
 func (c *client) GetWithdrawals() ([]*model.Withdrawal, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v1/withdrawals", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawals: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[[]*model.Withdrawal]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode withdrawals: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
+}
+
+// GetWithdrawalsPage returns a page of withdrawals and the cursor of the next one.
+// GET /v1/withdrawals
+func (c *client) GetWithdrawalsPage(req *api.GetWithdrawalsRequest) (*api.GetWithdrawalsResponse, error) {
+	path := "/v1/withdrawals"
+	if encodedQuery := req.GetUrlValues().Encode(); encodedQuery != "" {
+		path += "?" + encodedQuery
+	}
+
+	resp, err := c.Get(path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawals: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.PaginatedResponse[[]*model.Withdrawal]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode withdrawals: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return &api.GetWithdrawalsResponse{
+		PageInfo:    apiResp.PageInfo,
+		Withdrawals: apiResp.Result,
+	}, nil
 }
 
 // GetWithdrawal returns the details of a specific withdrawal.
@@ -163,8 +428,27 @@ func (c *client) GetWithdrawal() (*model.Withdrawal, error) {
 }
 
 // GetWithdrawalLimit returns the withdrawal limits for the account.
+// GET /v1/withdrawals/limit
 func (c *client) GetWithdrawalLimit() (*model.WithdrawalLimit, error) {
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.Get("/v1/withdrawals/limit", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawal limit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.WithdrawalLimit]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode withdrawal limit: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
 
 // GetWithdrawalByTxId returns the details of a withdrawal by transaction ID.
@@ -173,8 +457,32 @@ func (c *client) GetWithdrawalByTxId(req *api.GetWithdrawalByTxIdRequest) (*mode
 }
 
 // GetWithdrawalsCSV returns the list of withdrawals in CSV format.
+// GET /v1/withdrawals/csv
 func (c *client) GetWithdrawalsCSV(req *api.GetWithdrawalsCSVRequest) (string, error) {
-	return "", fmt.Errorf("not implemented")
+	body, err := c.GetWithdrawalsCSVStream(req)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read withdrawals CSV: %w", err)
+	}
+
+	return string(bodyBytes), nil
+}
+
+// GetWithdrawalsCSVStream returns the list of withdrawals in CSV format without buffering the response.
+// The caller must close the returned body.
+// GET /v1/withdrawals/csv
+func (c *client) GetWithdrawalsCSVStream(req *api.GetWithdrawalsCSVRequest) (io.ReadCloser, error) {
+	path := "/v1/withdrawals/csv"
+	if encodedQuery := req.GetUrlValues().Encode(); encodedQuery != "" {
+		path += "?" + encodedQuery
+	}
+
+	return getStream(c.BaseClient, path)
 }
 
 // ProvisionAddress provisions a new deposit address for the specified coin.
@@ -183,6 +491,30 @@ func (c *client) ProvisionAddress(req *api.ProvisionAddressRequest) (*model.Addr
 }
 
 // Withdraw initiates a withdrawal to the specified address.
+// POST /v0/withdraw
 func (c *client) Withdraw(req *api.WithdrawRequest) (*model.NewWithdrawal, error) {
-	return nil, fmt.Errorf("not implemented")
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post("/v0/withdraw", string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit withdrawal: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.NewWithdrawal]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode withdrawal: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
 }
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yangnei/enclave-go/enclave"
+)
+
+var (
+	ErrProductionWrite = errors.New("mutating request against production requires the WithProductionWrites option")
+	ErrReadOnly        = errors.New("client is read-only")
+)
+
+// readOnlyPosts are POST endpoints that only read data.
+var readOnlyPosts = map[string]bool{
+	"/v0/get_balance":       true,
+	"/v0/get_balances":      true,
+	"/v0/withdrawal_status": true,
+}
+
+// WithProductionWrites allows mutating requests, such as orders, cancels, transfers, stop orders and withdrawals, against ProdApiUrl.
+// Without it they fail with ErrProductionWrite before anything is sent.
+func WithProductionWrites() Option {
+	return func(c *baseClient) {
+		c.productionWrites = true
+	}
+}
+
+// WithReadOnly makes every mutating request fail with ErrReadOnly, whatever the environment.
+func WithReadOnly() Option {
+	return func(c *baseClient) {
+		c.readOnly = true
+	}
+}
+
+// IsMutating reports whether a request with the given HTTP method and path changes account state.
+func IsMutating(method, path string) bool {
+	switch method {
+	case http.MethodGet:
+		return false
+	case http.MethodPost:
+		path, _, _ = strings.Cut(path, "?")
+		return !readOnlyPosts[path]
+	default:
+		return true
+	}
+}
+
+// guard rejects mutating requests on read-only clients and against production without opt-in.
+func (c *baseClient) guard(method, path string) error {
+	if !IsMutating(method, path) {
+		return nil
+	}
+	if c.readOnly {
+		return fmt.Errorf("%w: %s %s", ErrReadOnly, method, path)
+	}
+	if enclave.EnvironmentFromURL(c.BaseURL) == enclave.EnvironmentProd && !c.productionWrites {
+		return fmt.Errorf("%w: %s %s", ErrProductionWrite, method, path)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGuardDetectsProductionVariants(t *testing.T) {
+	urls := []string{
+		"https://api.enclave.market",
+		"http://api.enclave.market",
+		"https://API.ENCLAVE.MARKET",
+		"https://api.enclave.market:443",
+		"https://api.enclave.market/proxy",
+	}
+	for _, u := range urls {
+		c := NewBaseClient("key", "secret", u).(*baseClient)
+		if err := c.guard("POST", "/v1/orders"); !errors.Is(err, ErrProductionWrite) {
+			t.Errorf("guard on %s = %v, want ErrProductionWrite", u, err)
+		}
+		if err := c.guard("GET", "/v1/orders"); err != nil {
+			t.Errorf("guard on %s for a read = %v, want nil", u, err)
+		}
+
+		allowed := NewBaseClient("key", "secret", u, WithProductionWrites()).(*baseClient)
+		if err := allowed.guard("POST", "/v1/orders"); err != nil {
+			t.Errorf("guard on %s with production writes = %v, want nil", u, err)
+		}
+	}
+
+	sandbox := NewBaseClient("key", "secret", "https://api-sandbox.enclave.market").(*baseClient)
+	if err := sandbox.guard("POST", "/v1/orders"); err != nil {
+		t.Errorf("guard on sandbox = %v, want nil", err)
+	}
+}
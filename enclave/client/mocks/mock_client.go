@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -51,6 +52,35 @@ func (mr *MockClientMockRecorder) AuthenticatedHello() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticatedHello", reflect.TypeOf((*MockClient)(nil).AuthenticatedHello))
 }
 
+// CreateSubaccount mocks base method.
+func (m *MockClient) CreateSubaccount(arg0 *api.CreateSubaccountRequest) (*model.Subaccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubaccount", arg0)
+	ret0, _ := ret[0].(*model.Subaccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubaccount indicates an expected call of CreateSubaccount.
+func (mr *MockClientMockRecorder) CreateSubaccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubaccount", reflect.TypeOf((*MockClient)(nil).CreateSubaccount), arg0)
+}
+
+// ForSubaccount mocks base method.
+func (m *MockClient) ForSubaccount(arg0 string) client.Client {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForSubaccount", arg0)
+	ret0, _ := ret[0].(client.Client)
+	return ret0
+}
+
+// ForSubaccount indicates an expected call of ForSubaccount.
+func (mr *MockClientMockRecorder) ForSubaccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForSubaccount", reflect.TypeOf((*MockClient)(nil).ForSubaccount), arg0)
+}
+
 // GetAccount mocks base method.
 func (m *MockClient) GetAccount() (*model.Account, error) {
 	m.ctrl.T.Helper()
@@ -171,6 +201,36 @@ func (mr *MockClientMockRecorder) GetDepositsCSV(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDepositsCSV", reflect.TypeOf((*MockClient)(nil).GetDepositsCSV), arg0)
 }
 
+// GetDepositsCSVStream mocks base method.
+func (m *MockClient) GetDepositsCSVStream(arg0 *api.GetDepositsCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDepositsCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDepositsCSVStream indicates an expected call of GetDepositsCSVStream.
+func (mr *MockClientMockRecorder) GetDepositsCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDepositsCSVStream", reflect.TypeOf((*MockClient)(nil).GetDepositsCSVStream), arg0)
+}
+
+// GetDepositsPage mocks base method.
+func (m *MockClient) GetDepositsPage(arg0 *api.GetDepositsRequest) (*api.GetDepositsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDepositsPage", arg0)
+	ret0, _ := ret[0].(*api.GetDepositsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDepositsPage indicates an expected call of GetDepositsPage.
+func (mr *MockClientMockRecorder) GetDepositsPage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDepositsPage", reflect.TypeOf((*MockClient)(nil).GetDepositsPage), arg0)
+}
+
 // GetMarkets mocks base method.
 func (m *MockClient) GetMarkets() (*model.Market, error) {
 	m.ctrl.T.Helper()
@@ -186,6 +246,36 @@ func (mr *MockClientMockRecorder) GetMarkets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarkets", reflect.TypeOf((*MockClient)(nil).GetMarkets))
 }
 
+// GetSubaccounts mocks base method.
+func (m *MockClient) GetSubaccounts() ([]*model.Subaccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubaccounts")
+	ret0, _ := ret[0].([]*model.Subaccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubaccounts indicates an expected call of GetSubaccounts.
+func (mr *MockClientMockRecorder) GetSubaccounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubaccounts", reflect.TypeOf((*MockClient)(nil).GetSubaccounts))
+}
+
+// GetTransferHistory mocks base method.
+func (m *MockClient) GetTransferHistory(arg0 *api.GetTransferHistoryRequest) (*api.GetTransferHistoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferHistory", arg0)
+	ret0, _ := ret[0].(*api.GetTransferHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferHistory indicates an expected call of GetTransferHistory.
+func (mr *MockClientMockRecorder) GetTransferHistory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferHistory", reflect.TypeOf((*MockClient)(nil).GetTransferHistory), arg0)
+}
+
 // GetWithdrawal mocks base method.
 func (m *MockClient) GetWithdrawal() (*model.Withdrawal, error) {
 	m.ctrl.T.Helper()
@@ -276,6 +366,36 @@ func (mr *MockClientMockRecorder) GetWithdrawalsCSV(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsCSV", reflect.TypeOf((*MockClient)(nil).GetWithdrawalsCSV), arg0)
 }
 
+// GetWithdrawalsCSVStream mocks base method.
+func (m *MockClient) GetWithdrawalsCSVStream(arg0 *api.GetWithdrawalsCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalsCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalsCSVStream indicates an expected call of GetWithdrawalsCSVStream.
+func (mr *MockClientMockRecorder) GetWithdrawalsCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsCSVStream", reflect.TypeOf((*MockClient)(nil).GetWithdrawalsCSVStream), arg0)
+}
+
+// GetWithdrawalsPage mocks base method.
+func (m *MockClient) GetWithdrawalsPage(arg0 *api.GetWithdrawalsRequest) (*api.GetWithdrawalsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalsPage", arg0)
+	ret0, _ := ret[0].(*api.GetWithdrawalsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalsPage indicates an expected call of GetWithdrawalsPage.
+func (mr *MockClientMockRecorder) GetWithdrawalsPage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsPage", reflect.TypeOf((*MockClient)(nil).GetWithdrawalsPage), arg0)
+}
+
 // Hello mocks base method.
 func (m *MockClient) Hello() (*model.Hello, error) {
 	m.ctrl.T.Helper()
@@ -320,6 +440,21 @@ func (mr *MockClientMockRecorder) ProvisionAddress(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionAddress", reflect.TypeOf((*MockClient)(nil).ProvisionAddress), arg0)
 }
 
+// RenameSubaccount mocks base method.
+func (m *MockClient) RenameSubaccount(arg0 *api.RenameSubaccountRequest) (*model.Subaccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameSubaccount", arg0)
+	ret0, _ := ret[0].(*model.Subaccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameSubaccount indicates an expected call of RenameSubaccount.
+func (mr *MockClientMockRecorder) RenameSubaccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameSubaccount", reflect.TypeOf((*MockClient)(nil).RenameSubaccount), arg0)
+}
+
 // SpotClient mocks base method.
 func (m *MockClient) SpotClient() client.SpotClient {
 	m.ctrl.T.Helper()
@@ -334,6 +469,21 @@ func (mr *MockClientMockRecorder) SpotClient() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpotClient", reflect.TypeOf((*MockClient)(nil).SpotClient))
 }
 
+// Transfer mocks base method.
+func (m *MockClient) Transfer(arg0 *api.WalletTransferRequest) (*model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", arg0)
+	ret0, _ := ret[0].(*model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockClientMockRecorder) Transfer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockClient)(nil).Transfer), arg0)
+}
+
 // Withdraw mocks base method.
 func (m *MockClient) Withdraw(arg0 *api.WithdrawRequest) (*model.NewWithdrawal, error) {
 	m.ctrl.T.Helper()
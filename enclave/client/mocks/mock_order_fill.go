@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -139,6 +140,36 @@ func (mr *MockOrderFillClientMockRecorder) GetFillsCSV(req interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSV", reflect.TypeOf((*MockOrderFillClient)(nil).GetFillsCSV), req)
 }
 
+// GetFillsCSVStream mocks base method.
+func (m *MockOrderFillClient) GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsCSVStream", req)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsCSVStream indicates an expected call of GetFillsCSVStream.
+func (mr *MockOrderFillClientMockRecorder) GetFillsCSVStream(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSVStream", reflect.TypeOf((*MockOrderFillClient)(nil).GetFillsCSVStream), req)
+}
+
+// GetFillsPage mocks base method.
+func (m *MockOrderFillClient) GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsPage", req)
+	ret0, _ := ret[0].(*api.GetFillsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsPage indicates an expected call of GetFillsPage.
+func (mr *MockOrderFillClientMockRecorder) GetFillsPage(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsPage", reflect.TypeOf((*MockOrderFillClient)(nil).GetFillsPage), req)
+}
+
 // GetOrder mocks base method.
 func (m *MockOrderFillClient) GetOrder(req *api.GetOrderRequest) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -183,3 +214,18 @@ func (mr *MockOrderFillClientMockRecorder) GetOrdersCSV(req interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSV", reflect.TypeOf((*MockOrderFillClient)(nil).GetOrdersCSV), req)
 }
+
+// GetOrdersCSVStream mocks base method.
+func (m *MockOrderFillClient) GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersCSVStream", req)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersCSVStream indicates an expected call of GetOrdersCSVStream.
+func (mr *MockOrderFillClientMockRecorder) GetOrdersCSVStream(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSVStream", reflect.TypeOf((*MockOrderFillClient)(nil).GetOrdersCSVStream), req)
+}
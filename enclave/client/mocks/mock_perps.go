@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -154,6 +155,51 @@ func (mr *MockPerpsClientMockRecorder) GetFillsCSV(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSV", reflect.TypeOf((*MockPerpsClient)(nil).GetFillsCSV), arg0)
 }
 
+// GetFillsCSVStream mocks base method.
+func (m *MockPerpsClient) GetFillsCSVStream(arg0 *api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsCSVStream indicates an expected call of GetFillsCSVStream.
+func (mr *MockPerpsClientMockRecorder) GetFillsCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSVStream", reflect.TypeOf((*MockPerpsClient)(nil).GetFillsCSVStream), arg0)
+}
+
+// GetFillsPage mocks base method.
+func (m *MockPerpsClient) GetFillsPage(arg0 *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsPage", arg0)
+	ret0, _ := ret[0].(*api.GetFillsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsPage indicates an expected call of GetFillsPage.
+func (mr *MockPerpsClientMockRecorder) GetFillsPage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsPage", reflect.TypeOf((*MockPerpsClient)(nil).GetFillsPage), arg0)
+}
+
+// GetFundingFees mocks base method.
+func (m *MockPerpsClient) GetFundingFees(arg0 *api.GetFundingFeesRequest) (*api.GetFundingFeesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFundingFees", arg0)
+	ret0, _ := ret[0].(*api.GetFundingFeesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFundingFees indicates an expected call of GetFundingFees.
+func (mr *MockPerpsClientMockRecorder) GetFundingFees(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFundingFees", reflect.TypeOf((*MockPerpsClient)(nil).GetFundingFees), arg0)
+}
+
 // GetFundingRateHistory mocks base method.
 func (m *MockPerpsClient) GetFundingRateHistory(arg0 *api.GetFundingRateHistoryRequest) ([]*model.FundingRate, error) {
 	m.ctrl.T.Helper()
@@ -259,6 +305,21 @@ func (mr *MockPerpsClientMockRecorder) GetOrdersCSV(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSV", reflect.TypeOf((*MockPerpsClient)(nil).GetOrdersCSV), arg0)
 }
 
+// GetOrdersCSVStream mocks base method.
+func (m *MockPerpsClient) GetOrdersCSVStream(arg0 *api.GetOrdersCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersCSVStream indicates an expected call of GetOrdersCSVStream.
+func (mr *MockPerpsClientMockRecorder) GetOrdersCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSVStream", reflect.TypeOf((*MockPerpsClient)(nil).GetOrdersCSVStream), arg0)
+}
+
 // GetPositions mocks base method.
 func (m *MockPerpsClient) GetPositions() ([]*model.Position, error) {
 	m.ctrl.T.Helper()
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -140,6 +141,36 @@ func (mr *MockSpotClientMockRecorder) GetFillsCSV(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSV", reflect.TypeOf((*MockSpotClient)(nil).GetFillsCSV), arg0)
 }
 
+// GetFillsCSVStream mocks base method.
+func (m *MockSpotClient) GetFillsCSVStream(arg0 *api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsCSVStream indicates an expected call of GetFillsCSVStream.
+func (mr *MockSpotClientMockRecorder) GetFillsCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsCSVStream", reflect.TypeOf((*MockSpotClient)(nil).GetFillsCSVStream), arg0)
+}
+
+// GetFillsPage mocks base method.
+func (m *MockSpotClient) GetFillsPage(arg0 *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFillsPage", arg0)
+	ret0, _ := ret[0].(*api.GetFillsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFillsPage indicates an expected call of GetFillsPage.
+func (mr *MockSpotClientMockRecorder) GetFillsPage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFillsPage", reflect.TypeOf((*MockSpotClient)(nil).GetFillsPage), arg0)
+}
+
 // GetOrder mocks base method.
 func (m *MockSpotClient) GetOrder(arg0 *api.GetOrderRequest) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -184,3 +215,18 @@ func (mr *MockSpotClientMockRecorder) GetOrdersCSV(arg0 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSV", reflect.TypeOf((*MockSpotClient)(nil).GetOrdersCSV), arg0)
 }
+
+// GetOrdersCSVStream mocks base method.
+func (m *MockSpotClient) GetOrdersCSVStream(arg0 *api.GetOrdersCSVRequest) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersCSVStream", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersCSVStream indicates an expected call of GetOrdersCSVStream.
+func (mr *MockSpotClientMockRecorder) GetOrdersCSVStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCSVStream", reflect.TypeOf((*MockSpotClient)(nil).GetOrdersCSVStream), arg0)
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Option configures a client created by NewBaseClient, NewClient, NewSpotClient or NewPerpsClient.
+type Option func(c *baseClient)
+
+// WithHTTPClient sends requests through hc instead of a default http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *baseClient) {
+		c.Client = hc
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request, including reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *baseClient) {
+		hc := *c.Client
+		hc.Timeout = timeout
+		c.Client = &hc
+	}
+}
+
+// WithRateLimit limits requests to perSecond on average with bursts of up to burst requests.
+// Requests over the limit block until they are allowed. A burst below 1 is treated as 1.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *baseClient) {
+		c.limiter = rate.NewLimiter(rate.Limit(perSecond), max(burst, 1))
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with each request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *baseClient) {
+		c.UserAgent = userAgent
+	}
+}
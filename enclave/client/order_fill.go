@@ -17,12 +17,15 @@ type OrderFillClient interface {
 	GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error)
 	GetOrder(req *api.GetOrderRequest) (*model.Order, error)
 	GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, error)
+	GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error)
 	CancelOrder(req *api.CancelOrderRequest) (*model.Order, error)
 	CancelOrders(req *api.CancelOrdersRequest) error
 	GetDepth(req *api.GetDepthRequest) (*model.OrderBook, error)
 	GetFills(req *api.GetFillsRequest) ([]*model.Fill, error)
+	GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error)
 	GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.Fill, error)
 	GetFillsCSV(req *api.GetFillsCSVRequest) (string, error)
+	GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error)
 }
 
 // orderFillClient contains the orderFillClient-specific API and calls an instance of BaseClient to make requests and handle auth.
@@ -55,7 +58,7 @@ func (c *orderFillClient) AddOrder(req *api.AddOrderRequest) (*model.Order, erro
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return apiResp.Result, nil
@@ -92,7 +95,7 @@ func (c *orderFillClient) GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersRe
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return &api.GetOrdersResponse{
@@ -132,7 +135,7 @@ func (c *orderFillClient) GetOrder(req *api.GetOrderRequest) (*model.Order, erro
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return apiResp.Result, nil
@@ -141,6 +144,23 @@ func (c *orderFillClient) GetOrder(req *api.GetOrderRequest) (*model.Order, erro
 // GetOrdersCSV retrieves orders in CSV format that meet the optional parameters.
 // GET /v1/orders/csv
 func (c *orderFillClient) GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, error) {
+	body, err := c.GetOrdersCSVStream(req)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bodyBytes), nil
+}
+
+// GetOrdersCSVStream retrieves orders in CSV format without buffering the response. The caller must close the returned body.
+// GET /v1/orders/csv
+func (c *orderFillClient) GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error) {
 	query := req.GetUrlValues()
 	if req.Market != "" {
 		query.Set("market", req.Market)
@@ -154,22 +174,7 @@ func (c *orderFillClient) GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, er
 		path += "?" + encodedQuery
 	}
 
-	resp, err := c.Get(path, nil, nil)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", c.HandleError(resp)
-	}
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	return string(bodyBytes), nil
+	return getStream(c.BaseClient, path)
 }
 
 // CancelOrder cancels an order by client order ID or internal order ID.
@@ -203,7 +208,7 @@ func (c *orderFillClient) CancelOrder(req *api.CancelOrderRequest) (*model.Order
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return apiResp.Result, nil
@@ -238,7 +243,7 @@ func (c *orderFillClient) CancelOrders(req *api.CancelOrdersRequest) error {
 		return err
 	}
 	if !apiResp.Success {
-		return fmt.Errorf("API error: %c", apiResp.Error)
+		return fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return nil
@@ -278,7 +283,7 @@ func (c *orderFillClient) GetDepth(req *api.GetDepthRequest) (*model.OrderBook,
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return apiResp.Result, nil
@@ -287,6 +292,16 @@ func (c *orderFillClient) GetDepth(req *api.GetDepthRequest) (*model.OrderBook,
 // GetFills retrieves fills that meet the optional parameters.
 // GET /v1/fills
 func (c *orderFillClient) GetFills(req *api.GetFillsRequest) ([]*model.Fill, error) {
+	resp, err := c.GetFillsPage(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Fills, nil
+}
+
+// GetFillsPage retrieves a page of fills that meet the optional parameters and the cursor of the next one.
+// GET /v1/fills
+func (c *orderFillClient) GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
 	query := req.GetUrlValues()
 	if req.Market != "" {
 		query.Set("market", req.Market)
@@ -307,16 +322,19 @@ func (c *orderFillClient) GetFills(req *api.GetFillsRequest) ([]*model.Fill, err
 		return nil, c.HandleError(resp)
 	}
 
-	apiResp := api.Response[[]*model.Fill]{}
+	apiResp := api.PaginatedResponse[[]*model.Fill]{}
 
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
-	return apiResp.Result, nil
+	return &api.GetFillsResponse{
+		PageInfo: apiResp.PageInfo,
+		Fills:    apiResp.Result,
+	}, nil
 }
 
 // GetFillsByID retrieves fills by client order ID or internal order ID.
@@ -350,7 +368,7 @@ func (c *orderFillClient) GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.F
 		return nil, err
 	}
 	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %c", apiResp.Error)
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
 	}
 
 	return apiResp.Result, nil
@@ -359,30 +377,32 @@ func (c *orderFillClient) GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.F
 // GetFillsCSV retrieves fills in CSV format that meet the optional parameters.
 // GET /v1/fills/csv
 func (c *orderFillClient) GetFillsCSV(req *api.GetFillsCSVRequest) (string, error) {
-	query := req.GetUrlValues()
-	if req.Market != "" {
-		query.Set("market", req.Market)
-	}
-
-	path := fmt.Sprintf("%s/fills/csv", c.prefix)
-	if encodedQuery := query.Encode(); encodedQuery != "" {
-		path += "?" + encodedQuery
+	body, err := c.GetFillsCSVStream(req)
+	if err != nil {
+		return "", err
 	}
+	defer body.Close()
 
-	resp, err := c.Get(path, nil, nil)
+	bodyBytes, err := io.ReadAll(body)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", c.HandleError(resp)
+	return string(bodyBytes), nil
+}
+
+// GetFillsCSVStream retrieves fills in CSV format without buffering the response. The caller must close the returned body.
+// GET /v1/fills/csv
+func (c *orderFillClient) GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	query := req.GetUrlValues()
+	if req.Market != "" {
+		query.Set("market", req.Market)
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	path := fmt.Sprintf("%s/fills/csv", c.prefix)
+	if encodedQuery := query.Encode(); encodedQuery != "" {
+		path += "?" + encodedQuery
 	}
 
-	return string(bodyBytes), nil
+	return getStream(c.BaseClient, path)
 }
@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 
@@ -20,6 +21,7 @@ type PerpsClient interface {
 	GetMarkPrices() (map[string]*model.MarkPrice, error)
 	GetFundingRates(req *api.GetFundingRatesRequest) (*model.FundingRate, error)
 	GetFundingRateHistory(req *api.GetFundingRateHistoryRequest) ([]*model.FundingRate, error)
+	GetFundingFees(req *api.GetFundingFeesRequest) (*api.GetFundingFeesResponse, error)
 	GetStopOrders() ([]*model.StopOrder, error)
 	SetStopOrder(req *api.SetStopOrderRequest) ([]*model.StopOrder, error)
 	RemoveStopOrder(req *api.RemoveStopOrderRequest) ([]*model.StopOrder, error)
@@ -30,12 +32,15 @@ type PerpsClient interface {
 	GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error)
 	GetOrder(req *api.GetOrderRequest) (*model.Order, error)
 	GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, error)
+	GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error)
 	CancelOrder(req *api.CancelOrderRequest) (*model.Order, error)
 	CancelOrders(req *api.CancelOrdersRequest) error
 	GetDepth(req *api.GetDepthRequest) (*model.OrderBook, error)
 	GetFills(req *api.GetFillsRequest) ([]*model.Fill, error)
+	GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error)
 	GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.Fill, error)
 	GetFillsCSV(req *api.GetFillsCSVRequest) (string, error)
+	GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error)
 }
 
 // perpsClient provides methods specific to the perpsClient API, calling an instance of BaseClient for requests and handling authentication.
@@ -45,8 +50,8 @@ type perpsClient struct {
 }
 
 // NewPerpsClient initializes a new perpsClient client with the provided API key, API secret, and base URL.
-func NewPerpsClient(apiKey, apiSecret, baseURL string) PerpsClient {
-	return NewPerpsClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL))
+func NewPerpsClient(apiKey, apiSecret, baseURL string, opts ...Option) PerpsClient {
+	return NewPerpsClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL, opts...))
 }
 
 // NewPerpsClientWithBase initializes a new perpsClient client with the provided BaseClient.
@@ -252,6 +257,7 @@ func (p *perpsClient) GetFundingRateHistory(req *api.GetFundingRateHistoryReques
 }
 
 // GetFundingFees retrieves the historical funding fee payments in a market.
+// GET /v1/perps/funding_fees
 func (p *perpsClient) GetFundingFees(req *api.GetFundingFeesRequest) (*api.GetFundingFeesResponse, error) {
 	query := req.GetUrlValues()
 	if req.Market == "" {
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"io"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// ReadOnlyOrderFillClient is the subset of OrderFillClient that does not place or cancel orders.
+type ReadOnlyOrderFillClient interface {
+	GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error)
+	GetOrder(req *api.GetOrderRequest) (*model.Order, error)
+	GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, error)
+	GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error)
+	GetDepth(req *api.GetDepthRequest) (*model.OrderBook, error)
+	GetFills(req *api.GetFillsRequest) ([]*model.Fill, error)
+	GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error)
+	GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.Fill, error)
+	GetFillsCSV(req *api.GetFillsCSVRequest) (string, error)
+	GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error)
+}
+
+type ReadOnlySpotClient interface {
+	ReadOnlyOrderFillClient
+}
+
+// ReadOnlyPerpsClient is the subset of PerpsClient without orders, transfers or stop order changes.
+type ReadOnlyPerpsClient interface {
+	ReadOnlyOrderFillClient
+
+	GetPositions() ([]*model.Position, error)
+	GetBalance() (*model.Balance, error)
+	GetTransfers(req *api.GetTransferRequest) ([]*model.Transfer, error)
+	GetMarkPrices() (map[string]*model.MarkPrice, error)
+	GetFundingRates(req *api.GetFundingRatesRequest) (*model.FundingRate, error)
+	GetFundingRateHistory(req *api.GetFundingRateHistoryRequest) ([]*model.FundingRate, error)
+	GetFundingFees(req *api.GetFundingFeesRequest) (*api.GetFundingFeesResponse, error)
+	GetStopOrders() ([]*model.StopOrder, error)
+	GetOpenInterest() ([]*model.OpenInterest, error)
+	GetVolume() ([]*model.Volume, error)
+}
+
+// ReadOnlyClient is the subset of Client that cannot change account state, suitable for analytics and reporting.
+type ReadOnlyClient interface {
+	SpotClient() ReadOnlySpotClient
+	PerpsClient() ReadOnlyPerpsClient
+
+	Hello() (*model.Hello, error)
+	AuthenticatedHello() (*model.AuthenticatedHello, error)
+	GetAccount() (*model.Account, error)
+	GetAddressBook() (*model.AddressBook, error)
+	GetMarkets() (*model.Market, error)
+	GetAssetBalance(req *api.GetAssetBalanceRequest) (*model.AssetBalance, error)
+	GetWithdrawalStatus(req *api.GetWithdrawalStatusRequest) (*model.WithdrawalStatus, error)
+	GetAssetBalances() ([]*model.AssetBalance, error)
+	GetDepositAddresses(req *api.GetDepositAddressesRequest) ([]*model.Address, error)
+	GetDeposits() ([]*model.Deposit, error)
+	GetDepositsPage(req *api.GetDepositsRequest) (*api.GetDepositsResponse, error)
+	GetDeposit(req *api.GetDepositRequest) (*model.Deposit, error)
+	GetDepositsCSV(req *api.GetDepositsCSVRequest) (string, error)
+	GetDepositsCSVStream(req *api.GetDepositsCSVRequest) (io.ReadCloser, error)
+	GetWithdrawals() ([]*model.Withdrawal, error)
+	GetWithdrawalsPage(req *api.GetWithdrawalsRequest) (*api.GetWithdrawalsResponse, error)
+	GetWithdrawal() (*model.Withdrawal, error)
+	GetWithdrawalLimit() (*model.WithdrawalLimit, error)
+	GetWithdrawalByTxId(req *api.GetWithdrawalByTxIdRequest) (*model.Withdrawal, error)
+	GetWithdrawalsCSV(req *api.GetWithdrawalsCSVRequest) (string, error)
+	GetWithdrawalsCSVStream(req *api.GetWithdrawalsCSVRequest) (io.ReadCloser, error)
+	GetTransferHistory(req *api.GetTransferHistoryRequest) (*api.GetTransferHistoryResponse, error)
+	GetSubaccounts() ([]*model.Subaccount, error)
+	ForSubaccount(subaccountID string) ReadOnlyClient
+}
+
+// The full clients must stay usable wherever a read-only one is expected.
+var (
+	_ ReadOnlyOrderFillClient = OrderFillClient(nil)
+	_ ReadOnlySpotClient      = SpotClient(nil)
+	_ ReadOnlyPerpsClient     = PerpsClient(nil)
+)
+
+// NewReadOnlyClient initializes a client that lacks mutating methods and whose underlying requests are also refused
+// with ErrReadOnly. Hand it out together with a read-only API key.
+func NewReadOnlyClient(apiKey, apiSecret, baseURL string, opts ...Option) ReadOnlyClient {
+	opts = append(opts[:len(opts):len(opts)], WithReadOnly())
+	return ReadOnly(NewClient(apiKey, apiSecret, baseURL, opts...))
+}
+
+// ReadOnly wraps c, exposing only its read methods. The wrapper cannot be type-asserted back to Client.
+func ReadOnly(c Client) ReadOnlyClient {
+	return &readOnlyClient{
+		c:  c,
+		sc: &readOnlyOrderFillClient{c: c.SpotClient()},
+		pc: &readOnlyPerpsClient{readOnlyOrderFillClient: readOnlyOrderFillClient{c: c.PerpsClient()}, pc: c.PerpsClient()},
+	}
+}
+
+type readOnlyOrderFillClient struct {
+	c OrderFillClient
+}
+
+func (r *readOnlyOrderFillClient) GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error) {
+	return r.c.GetOrders(req)
+}
+
+func (r *readOnlyOrderFillClient) GetOrder(req *api.GetOrderRequest) (*model.Order, error) {
+	return r.c.GetOrder(req)
+}
+
+func (r *readOnlyOrderFillClient) GetOrdersCSV(req *api.GetOrdersCSVRequest) (string, error) {
+	return r.c.GetOrdersCSV(req)
+}
+
+func (r *readOnlyOrderFillClient) GetOrdersCSVStream(req *api.GetOrdersCSVRequest) (io.ReadCloser, error) {
+	return r.c.GetOrdersCSVStream(req)
+}
+
+func (r *readOnlyOrderFillClient) GetDepth(req *api.GetDepthRequest) (*model.OrderBook, error) {
+	return r.c.GetDepth(req)
+}
+
+func (r *readOnlyOrderFillClient) GetFills(req *api.GetFillsRequest) ([]*model.Fill, error) {
+	return r.c.GetFills(req)
+}
+
+func (r *readOnlyOrderFillClient) GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+	return r.c.GetFillsPage(req)
+}
+
+func (r *readOnlyOrderFillClient) GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.Fill, error) {
+	return r.c.GetFillsByID(req)
+}
+
+func (r *readOnlyOrderFillClient) GetFillsCSV(req *api.GetFillsCSVRequest) (string, error) {
+	return r.c.GetFillsCSV(req)
+}
+
+func (r *readOnlyOrderFillClient) GetFillsCSVStream(req *api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	return r.c.GetFillsCSVStream(req)
+}
+
+type readOnlyPerpsClient struct {
+	readOnlyOrderFillClient
+	pc PerpsClient
+}
+
+func (r *readOnlyPerpsClient) GetPositions() ([]*model.Position, error) {
+	return r.pc.GetPositions()
+}
+
+func (r *readOnlyPerpsClient) GetBalance() (*model.Balance, error) {
+	return r.pc.GetBalance()
+}
+
+func (r *readOnlyPerpsClient) GetTransfers(req *api.GetTransferRequest) ([]*model.Transfer, error) {
+	return r.pc.GetTransfers(req)
+}
+
+func (r *readOnlyPerpsClient) GetMarkPrices() (map[string]*model.MarkPrice, error) {
+	return r.pc.GetMarkPrices()
+}
+
+func (r *readOnlyPerpsClient) GetFundingRates(req *api.GetFundingRatesRequest) (*model.FundingRate, error) {
+	return r.pc.GetFundingRates(req)
+}
+
+func (r *readOnlyPerpsClient) GetFundingRateHistory(req *api.GetFundingRateHistoryRequest) ([]*model.FundingRate, error) {
+	return r.pc.GetFundingRateHistory(req)
+}
+
+func (r *readOnlyPerpsClient) GetFundingFees(req *api.GetFundingFeesRequest) (*api.GetFundingFeesResponse, error) {
+	return r.pc.GetFundingFees(req)
+}
+
+func (r *readOnlyPerpsClient) GetStopOrders() ([]*model.StopOrder, error) {
+	return r.pc.GetStopOrders()
+}
+
+func (r *readOnlyPerpsClient) GetOpenInterest() ([]*model.OpenInterest, error) {
+	return r.pc.GetOpenInterest()
+}
+
+func (r *readOnlyPerpsClient) GetVolume() ([]*model.Volume, error) {
+	return r.pc.GetVolume()
+}
+
+type readOnlyClient struct {
+	c  Client
+	sc ReadOnlySpotClient
+	pc ReadOnlyPerpsClient
+}
+
+func (r *readOnlyClient) SpotClient() ReadOnlySpotClient {
+	return r.sc
+}
+
+func (r *readOnlyClient) PerpsClient() ReadOnlyPerpsClient {
+	return r.pc
+}
+
+func (r *readOnlyClient) Hello() (*model.Hello, error) {
+	return r.c.Hello()
+}
+
+func (r *readOnlyClient) AuthenticatedHello() (*model.AuthenticatedHello, error) {
+	return r.c.AuthenticatedHello()
+}
+
+func (r *readOnlyClient) GetAccount() (*model.Account, error) {
+	return r.c.GetAccount()
+}
+
+func (r *readOnlyClient) GetAddressBook() (*model.AddressBook, error) {
+	return r.c.GetAddressBook()
+}
+
+func (r *readOnlyClient) GetMarkets() (*model.Market, error) {
+	return r.c.GetMarkets()
+}
+
+func (r *readOnlyClient) GetAssetBalance(req *api.GetAssetBalanceRequest) (*model.AssetBalance, error) {
+	return r.c.GetAssetBalance(req)
+}
+
+func (r *readOnlyClient) GetWithdrawalStatus(req *api.GetWithdrawalStatusRequest) (*model.WithdrawalStatus, error) {
+	return r.c.GetWithdrawalStatus(req)
+}
+
+func (r *readOnlyClient) GetAssetBalances() ([]*model.AssetBalance, error) {
+	return r.c.GetAssetBalances()
+}
+
+func (r *readOnlyClient) GetDepositAddresses(req *api.GetDepositAddressesRequest) ([]*model.Address, error) {
+	return r.c.GetDepositAddresses(req)
+}
+
+func (r *readOnlyClient) GetDeposits() ([]*model.Deposit, error) {
+	return r.c.GetDeposits()
+}
+
+func (r *readOnlyClient) GetDepositsPage(req *api.GetDepositsRequest) (*api.GetDepositsResponse, error) {
+	return r.c.GetDepositsPage(req)
+}
+
+func (r *readOnlyClient) GetDeposit(req *api.GetDepositRequest) (*model.Deposit, error) {
+	return r.c.GetDeposit(req)
+}
+
+func (r *readOnlyClient) GetDepositsCSV(req *api.GetDepositsCSVRequest) (string, error) {
+	return r.c.GetDepositsCSV(req)
+}
+
+func (r *readOnlyClient) GetDepositsCSVStream(req *api.GetDepositsCSVRequest) (io.ReadCloser, error) {
+	return r.c.GetDepositsCSVStream(req)
+}
+
+func (r *readOnlyClient) GetWithdrawals() ([]*model.Withdrawal, error) {
+	return r.c.GetWithdrawals()
+}
+
+func (r *readOnlyClient) GetWithdrawalsPage(req *api.GetWithdrawalsRequest) (*api.GetWithdrawalsResponse, error) {
+	return r.c.GetWithdrawalsPage(req)
+}
+
+func (r *readOnlyClient) GetWithdrawal() (*model.Withdrawal, error) {
+	return r.c.GetWithdrawal()
+}
+
+func (r *readOnlyClient) GetWithdrawalLimit() (*model.WithdrawalLimit, error) {
+	return r.c.GetWithdrawalLimit()
+}
+
+func (r *readOnlyClient) GetWithdrawalByTxId(req *api.GetWithdrawalByTxIdRequest) (*model.Withdrawal, error) {
+	return r.c.GetWithdrawalByTxId(req)
+}
+
+func (r *readOnlyClient) GetWithdrawalsCSV(req *api.GetWithdrawalsCSVRequest) (string, error) {
+	return r.c.GetWithdrawalsCSV(req)
+}
+
+func (r *readOnlyClient) GetWithdrawalsCSVStream(req *api.GetWithdrawalsCSVRequest) (io.ReadCloser, error) {
+	return r.c.GetWithdrawalsCSVStream(req)
+}
+
+func (r *readOnlyClient) GetTransferHistory(req *api.GetTransferHistoryRequest) (*api.GetTransferHistoryResponse, error) {
+	return r.c.GetTransferHistory(req)
+}
+
+func (r *readOnlyClient) GetSubaccounts() ([]*model.Subaccount, error) {
+	return r.c.GetSubaccounts()
+}
+
+func (r *readOnlyClient) ForSubaccount(subaccountID string) ReadOnlyClient {
+	return ReadOnly(r.c.ForSubaccount(subaccountID))
+}
@@ -13,6 +13,6 @@ func NewSpotClientWithBase(baseClient BaseClient) SpotClient {
 }
 
 // NewSpotClient initializes a new orderFillClient client with the provided API key and secret.
-func NewSpotClient(apiKey, apiSecret, baseURL string) SpotClient {
-	return NewSpotClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL))
+func NewSpotClient(apiKey, apiSecret, baseURL string, opts ...Option) SpotClient {
+	return NewSpotClientWithBase(NewBaseClient(apiKey, apiSecret, baseURL, opts...))
 }
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// SubaccountHeader is the header that scopes a request to a subaccount of the API key's account.
+const SubaccountHeader = "ENCLAVE-SUBACCOUNT-ID"
+
+// subaccountBaseClient wraps a BaseClient and scopes every request to a subaccount.
+type subaccountBaseClient struct {
+	BaseClient
+	subaccountID string
+}
+
+// NewSubaccountBaseClient initializes a BaseClient that acts on behalf of subaccountID using base's credentials.
+func NewSubaccountBaseClient(base BaseClient, subaccountID string) BaseClient {
+	return &subaccountBaseClient{
+		BaseClient:   base,
+		subaccountID: subaccountID,
+	}
+}
+
+func (c *subaccountBaseClient) withHeader(headers map[string]string) map[string]string {
+	scoped := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		scoped[k] = v
+	}
+	scoped[SubaccountHeader] = c.subaccountID
+	return scoped
+}
+
+func (c *subaccountBaseClient) Get(path string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.BaseClient.Get(path, params, c.withHeader(headers))
+}
+
+func (c *subaccountBaseClient) Post(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.BaseClient.Post(path, body, params, c.withHeader(headers))
+}
+
+func (c *subaccountBaseClient) Delete(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.BaseClient.Delete(path, body, params, c.withHeader(headers))
+}
+
+func (c *subaccountBaseClient) Put(path string, body string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return c.BaseClient.Put(path, body, params, c.withHeader(headers))
+}
+
+// ForSubaccount returns a Client whose requests, including balances, orders and transfers, act on behalf of subaccountID.
+func (c *client) ForSubaccount(subaccountID string) Client {
+	return NewClientWithBase(NewSubaccountBaseClient(c.BaseClient, subaccountID))
+}
+
+// GetSubaccounts returns the subaccounts of the account.
+// GET /v1/subaccounts
+func (c *client) GetSubaccounts() ([]*model.Subaccount, error) {
+	resp, err := c.Get("/v1/subaccounts", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subaccounts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[[]*model.Subaccount]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode subaccounts: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
+}
+
+// CreateSubaccount creates a new subaccount with the given name.
+// The account's DisabledFunctionality is checked before the request is sent.
+// POST /v1/subaccounts
+func (c *client) CreateSubaccount(req *api.CreateSubaccountRequest) (*model.Subaccount, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	account, err := c.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+	if account.DisabledFunctionality != nil && account.DisabledFunctionality.DisableSubaccounts {
+		return nil, ErrSubaccountsDisabled
+	}
+
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post("/v1/subaccounts", string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subaccount: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return c.decodeSubaccount(resp)
+}
+
+// RenameSubaccount changes the name of an existing subaccount.
+// PUT /v1/subaccounts/{id}
+func (c *client) RenameSubaccount(req *api.RenameSubaccountRequest) (*model.Subaccount, error) {
+	if req.ID == "" || req.Name == "" {
+		return nil, fmt.Errorf("id and name are required")
+	}
+
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Put(fmt.Sprintf("/v1/subaccounts/%s", req.ID), string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename subaccount: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return c.decodeSubaccount(resp)
+}
+
+func (c *client) decodeSubaccount(resp *http.Response) (*model.Subaccount, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.Subaccount]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode subaccount: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
+}
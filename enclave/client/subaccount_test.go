@@ -0,0 +1,242 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestCreateSubaccountChecksDisabledFunctionality(t *testing.T) {
+	tests := []struct {
+		name     string
+		disabled *model.DisabledFunctionality
+		wantErr  error
+	}{
+		{name: "no restrictions"},
+		{name: "transfers disabled", disabled: &model.DisabledFunctionality{DisableTransfers: true}},
+		{name: "subaccounts disabled", disabled: &model.DisabledFunctionality{DisableSubaccounts: true}, wantErr: ErrSubaccountsDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/account", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(api.Response[*model.Account]{Success: true, Result: &model.Account{DisabledFunctionality: tt.disabled}})
+			})
+			mux.HandleFunc("/v1/subaccounts", func(w http.ResponseWriter, r *http.Request) {
+				created++
+				json.NewEncoder(w).Encode(api.Response[*model.Subaccount]{Success: true, Result: &model.Subaccount{ID: "sub", Name: "mm"}})
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			_, err := NewClient("key", "secret", srv.URL).CreateSubaccount(&api.CreateSubaccountRequest{Name: "mm"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSubaccount() error = %v, want %v", err, tt.wantErr)
+			}
+			if want := map[bool]int{true: 1, false: 0}[tt.wantErr == nil]; created != want {
+				t.Fatalf("sent %d create requests, want %d", created, want)
+			}
+		})
+	}
+}
+
+func TestForSubaccountScopesRequests(t *testing.T) {
+	type request struct {
+		method, path, subaccount string
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, request{r.Method, r.URL.Path, r.Header.Get(SubaccountHeader)})
+		if r.Header.Get("ENCLAVE-SIGN") == "" {
+			t.Errorf("%s %s is not signed", r.Method, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/v1/subaccounts":
+			json.NewEncoder(w).Encode(api.Response[[]*model.Subaccount]{Success: true})
+		default:
+			json.NewEncoder(w).Encode(api.Response[*model.Order]{Success: true, Result: &model.Order{}})
+		}
+	}))
+	defer srv.Close()
+
+	parent := NewClient("key", "secret", srv.URL)
+	sub := parent.ForSubaccount("sub-1")
+	if _, err := sub.GetSubaccounts(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.SpotClient().CancelOrder(&api.CancelOrderRequest{OrderID: "o1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parent.GetSubaccounts(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []request{
+		{http.MethodGet, "/v1/subaccounts", "sub-1"},
+		{http.MethodDelete, "/v1/orders/o1", "sub-1"},
+		{http.MethodGet, "/v1/subaccounts", ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got requests %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSubaccountBaseClientKeepsHeaders(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	base := NewSubaccountBaseClient(NewBaseClient("key", "secret", srv.URL), "sub-1")
+	callerHeaders := map[string]string{"X-Trace": "t1"}
+	tests := []struct {
+		name string
+		send func() (*http.Response, error)
+	}{
+		{"get", func() (*http.Response, error) { return base.Get("/v1/a", nil, callerHeaders) }},
+		{"post", func() (*http.Response, error) { return base.Post("/v1/a", "{}", nil, callerHeaders) }},
+		{"delete", func() (*http.Response, error) { return base.Delete("/v1/a", "", nil, callerHeaders) }},
+		{"put", func() (*http.Response, error) { return base.Put("/v1/a", "{}", nil, callerHeaders) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.send()
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if headers.Get(SubaccountHeader) != "sub-1" || headers.Get("X-Trace") != "t1" {
+				t.Fatalf("headers = %v, want the subaccount and the caller's headers", headers)
+			}
+		})
+	}
+	if _, ok := callerHeaders[SubaccountHeader]; ok {
+		t.Fatal("the caller's header map was modified")
+	}
+}
+
+func TestGetSubaccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantIDs []string
+		wantErr bool
+	}{
+		{
+			name:    "list",
+			status:  http.StatusOK,
+			body:    `{"success":true,"result":[{"id":"s1","name":"mm","parentId":"p","createdAt":"2024-01-01T00:00:00Z"},{"id":"s2","name":"arb","parentId":"p"}]}`,
+			wantIDs: []string{"s1", "s2"},
+		},
+		{
+			name:   "none",
+			status: http.StatusOK,
+			body:   `{"success":true,"result":[]}`,
+		},
+		{
+			name:    "unsuccessful",
+			status:  http.StatusOK,
+			body:    `{"success":false,"error":"forbidden"}`,
+			wantErr: true,
+		},
+		{
+			name:    "http error",
+			status:  http.StatusForbidden,
+			body:    `{"error":"forbidden","error_code":"FORBIDDEN"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/v1/subaccounts" {
+					t.Errorf("request = %s %s, want GET /v1/subaccounts", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			subaccounts, err := NewClient("key", "secret", srv.URL).GetSubaccounts()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSubaccounts() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(subaccounts) != len(tt.wantIDs) {
+				t.Fatalf("got %d subaccounts, want %d", len(subaccounts), len(tt.wantIDs))
+			}
+			for i, s := range subaccounts {
+				if s.ID != tt.wantIDs[i] || s.ParentID != "p" {
+					t.Errorf("subaccount %d = %+v, want ID %s of parent p", i, s, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenameSubaccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *api.RenameSubaccountRequest
+		wantSent bool
+		wantErr  bool
+	}{
+		{name: "rename", req: &api.RenameSubaccountRequest{ID: "s1", Name: "arb"}, wantSent: true},
+		{name: "missing ID", req: &api.RenameSubaccountRequest{Name: "arb"}, wantErr: true},
+		{name: "missing name", req: &api.RenameSubaccountRequest{ID: "s1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := false
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent = true
+				var body map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				if r.Method != http.MethodPut || r.URL.Path != "/v1/subaccounts/s1" || len(body) != 1 || body["name"] != "arb" {
+					t.Errorf("request = %s %s %v, want PUT /v1/subaccounts/s1 with the name only", r.Method, r.URL.Path, body)
+				}
+				json.NewEncoder(w).Encode(api.Response[*model.Subaccount]{Success: true, Result: &model.Subaccount{ID: "s1", Name: body["name"]}})
+			}))
+			defer srv.Close()
+
+			s, err := NewClient("key", "secret", srv.URL).RenameSubaccount(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameSubaccount() error = %v, want error %v", err, tt.wantErr)
+			}
+			if sent != tt.wantSent {
+				t.Fatalf("request sent = %v, want %v", sent, tt.wantSent)
+			}
+			if !tt.wantErr && (s.ID != "s1" || s.Name != "arb") {
+				t.Fatalf("RenameSubaccount() = %+v, want s1 named arb", s)
+			}
+		})
+	}
+}
+
+func TestAccountSubaccount(t *testing.T) {
+	var account model.Account
+	body := `{"Subaccount":{"id":"s1","name":"mm","parentId":"p","createdAt":"2024-01-01T00:00:00Z"}}`
+	if err := json.Unmarshal([]byte(body), &account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Subaccount == nil || account.Subaccount.ID != "s1" || account.Subaccount.ParentID != "p" {
+		t.Fatalf("Subaccount = %+v, want s1 of parent p", account.Subaccount)
+	}
+}
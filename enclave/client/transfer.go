@@ -0,0 +1,182 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var (
+	ErrTransfersDisabled   = errors.New("transfers are disabled for this account")
+	ErrSubaccountsDisabled = errors.New("subaccounts are disabled for this account")
+)
+
+// Transfer moves funds between two account wallets, e.g., from a subaccount's margin wallet to the main wallet of the master account.
+// The account's DisabledFunctionality is checked before the request is sent.
+// POST /v1/perps/transfers
+func (c *client) Transfer(req *api.WalletTransferRequest) (*model.Transfer, error) {
+	if err := validateTransfer(req); err != nil {
+		return nil, err
+	}
+
+	account, err := c.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+	subaccount := false
+	if req.From.ID != "" || req.To.ID != "" {
+		own, err := c.accountID()
+		if err != nil {
+			return nil, err
+		}
+		subaccount = resolve(req.From.ID, own) != own || resolve(req.To.ID, own) != own
+	}
+	if disabled := account.DisabledFunctionality; disabled != nil {
+		if disabled.DisableTransfers {
+			return nil, ErrTransfersDisabled
+		}
+		if disabled.DisableSubaccounts && subaccount {
+			return nil, ErrSubaccountsDisabled
+		}
+	}
+
+	transfer := &model.Transfer{
+		Symbol: req.Symbol,
+		Amount: req.Amount,
+		From:   &req.From,
+		To:     &req.To,
+		Type:   model.TransferTypeMargin,
+	}
+	if subaccount {
+		transfer.Type = model.TransferTypeSubAccount
+	}
+
+	requestBody, err := json.Marshal(transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post("/v1/perps/transfers", string(requestBody), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.HandleError(resp)
+	}
+
+	apiResp := api.Response[*model.Transfer]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode transfer: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, nil
+}
+
+// GetTransferHistory returns a page of transfers filtered by type and counterparty account ID. The filters are applied
+// on the client, so pages of the server are fetched until Limit transfers match or the history ends; the page may then
+// hold more than Limit transfers, as dropping any would make the cursor skip them. PageInfo.NextCursor resumes after
+// the last server page fetched and is empty at the end of the history.
+// GET /v1/perps/transfers
+func (c *client) GetTransferHistory(req *api.GetTransferHistoryRequest) (*api.GetTransferHistoryResponse, error) {
+	page := req.PagingAndTimeRange
+	res := &api.GetTransferHistoryResponse{}
+	for {
+		transfers, info, err := c.getTransfers(&page)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range transfers {
+			if req.Type != nil && t.Type != *req.Type {
+				continue
+			}
+			if req.Counterparty != "" && !involves(t, req.Counterparty) {
+				continue
+			}
+			res.Transfers = append(res.Transfers, t)
+		}
+
+		res.PageInfo = info
+		if info.NextCursor == "" || info.NextCursor == page.Cursor || len(transfers) == 0 {
+			res.PageInfo.NextCursor = ""
+			return res, nil
+		}
+		if req.Limit > 0 && len(res.Transfers) >= req.Limit {
+			return res, nil
+		}
+		page.Cursor = info.NextCursor
+	}
+}
+
+// getTransfers fetches one page of transfers with its paging information.
+func (c *client) getTransfers(req *api.PagingAndTimeRange) ([]*model.Transfer, api.PageInfo, error) {
+	resp, err := c.Get("/v1/perps/transfers?"+req.GetUrlValues().Encode(), nil, nil)
+	if err != nil {
+		return nil, api.PageInfo{}, fmt.Errorf("failed to get transfers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.PageInfo{}, c.HandleError(resp)
+	}
+
+	apiResp := api.PaginatedResponse[[]*model.Transfer]{}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, api.PageInfo{}, fmt.Errorf("failed to decode transfers: %w", err)
+	}
+	if !apiResp.Success {
+		return nil, api.PageInfo{}, fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	return apiResp.Result, apiResp.PageInfo, nil
+}
+
+// accountID returns the ID of the account the client acts for, as reported with its asset balances.
+func (c *client) accountID() (string, error) {
+	balances, err := c.GetAssetBalances()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve account ID: %w", err)
+	}
+	for _, b := range balances {
+		if b.AccountID != "" {
+			return b.AccountID, nil
+		}
+	}
+	return "", errors.New("failed to resolve account ID: no balance reports it")
+}
+
+// resolve returns id, or own for the empty ID that refers to the client's account.
+func resolve(id, own string) string {
+	if id == "" {
+		return own
+	}
+	return id
+}
+
+func validateTransfer(req *api.WalletTransferRequest) error {
+	if req.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if !req.Amount.IsPositive() {
+		return fmt.Errorf("amount must be positive")
+	}
+	if req.From.Wallet == "" || req.To.Wallet == "" {
+		return fmt.Errorf("from and to wallets are required")
+	}
+	if req.From == req.To {
+		return fmt.Errorf("from and to must differ")
+	}
+	return nil
+}
+
+func involves(t *model.Transfer, accountID string) bool {
+	return (t.From != nil && t.From.ID == accountID) || (t.To != nil && t.To.ID == accountID)
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// transferServer serves three pages of two transfers, alternating margin and subaccount transfers, and records the
+// bodies of transfer requests.
+func transferServer(t *testing.T, posted *[]model.Transfer) *httptest.Server {
+	pages := map[string]struct {
+		types []model.TransferType
+		next  string
+	}{
+		"":   {[]model.TransferType{model.TransferTypeMargin, model.TransferTypeSubAccount}, "p2"},
+		"p2": {[]model.TransferType{model.TransferTypeMargin, model.TransferTypeSubAccount}, "p3"},
+		"p3": {[]model.TransferType{model.TransferTypeMargin, model.TransferTypeSubAccount}, ""},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/perps/transfers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var transfer model.Transfer
+			if err := json.NewDecoder(r.Body).Decode(&transfer); err != nil {
+				t.Error(err)
+			}
+			*posted = append(*posted, transfer)
+			json.NewEncoder(w).Encode(api.Response[*model.Transfer]{Success: true, Result: &transfer})
+			return
+		}
+		cursor := r.URL.Query().Get("cursor")
+		page := pages[cursor]
+		resp := api.PaginatedResponse[[]*model.Transfer]{PageInfo: api.PageInfo{NextCursor: page.next}}
+		resp.Success = true
+		for i, typ := range page.types {
+			resp.Result = append(resp.Result, &model.Transfer{ID: cursor + string(rune('a'+i)), Type: typ})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/v0/get_balances", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.Response[[]*model.AssetBalance]{Success: true, Result: []*model.AssetBalance{{AccountID: "own", Symbol: "USDC"}}})
+	})
+	mux.HandleFunc("/v1/account", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.Response[*model.Account]{Success: true, Result: &model.Account{}})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGetTransferHistoryPages(t *testing.T) {
+	subaccount := model.TransferTypeSubAccount
+	tests := []struct {
+		name       string
+		limit      int
+		cursor     string
+		want       int
+		wantCursor string
+	}{
+		{name: "every page", want: 3},
+		{name: "limit spans pages", limit: 2, want: 2, wantCursor: "p3"},
+		{name: "resumes at cursor", limit: 2, cursor: "p3", want: 1},
+	}
+
+	var posted []model.Transfer
+	srv := transferServer(t, &posted)
+	defer srv.Close()
+	c := NewClient("key", "secret", srv.URL)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &api.GetTransferHistoryRequest{Type: &subaccount}
+			req.Limit, req.Cursor = tt.limit, tt.cursor
+			page, err := c.GetTransferHistory(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Transfers) != tt.want || page.PageInfo.NextCursor != tt.wantCursor {
+				t.Fatalf("got %d transfers and cursor %q, want %d and %q", len(page.Transfers), page.PageInfo.NextCursor, tt.want, tt.wantCursor)
+			}
+			for _, tr := range page.Transfers {
+				if tr.Type != subaccount {
+					t.Fatalf("transfer %s has type %d, want %d", tr.ID, tr.Type, subaccount)
+				}
+			}
+		})
+	}
+}
+
+func TestTransferType(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     model.TransferType
+	}{
+		{name: "own wallets", want: model.TransferTypeMargin},
+		{name: "own wallets by ID", from: "own", want: model.TransferTypeMargin},
+		{name: "own wallets by both IDs", from: "own", to: "own", want: model.TransferTypeMargin},
+		{name: "to a subaccount", to: "sub", want: model.TransferTypeSubAccount},
+		{name: "from a subaccount to its owner", from: "sub", to: "own", want: model.TransferTypeSubAccount},
+		{name: "within a subaccount", from: "sub", to: "sub", want: model.TransferTypeSubAccount},
+	}
+
+	var posted []model.Transfer
+	srv := transferServer(t, &posted)
+	defer srv.Close()
+	c := NewClient("key", "secret", srv.URL, WithProductionWrites())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posted = posted[:0]
+			_, err := c.Transfer(&api.WalletTransferRequest{
+				Symbol: "USDC",
+				Amount: decimal.NewFromInt(1),
+				From:   model.AccountWalletKey{ID: tt.from, Wallet: model.WalletMain},
+				To:     model.AccountWalletKey{ID: tt.to, Wallet: model.WalletMargin},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(posted) != 1 || posted[0].Type != tt.want {
+				t.Fatalf("posted %+v, want one transfer of type %d", posted, tt.want)
+			}
+		})
+	}
+}
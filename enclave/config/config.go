@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultProfileName is used when neither a profile nor a default_profile is configured.
+const DefaultProfileName = "default"
+
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Config is the content of a config file, e.g.:
+//
+//	default_profile = "sandbox"
+//
+//	[profiles.sandbox]
+//	env = "sandbox"
+//	key_id = "..."
+//	secret = { env = "ENCLAVE_SANDBOX_SECRET" }
+//	timeout = "15s"
+//	rate_limit = 10
+//
+//	[profiles.prod]
+//	base_url = "https://api.enclave.market"
+//	key_id = "..."
+//	secret = { command = ["pass", "show", "enclave/prod"] }
+type Config struct {
+	DefaultProfile string              `toml:"default_profile"` // Profile used when none is selected
+	Profiles       map[string]*Profile `toml:"profiles"`        // Profiles by name
+}
+
+// Profile holds the connection settings of one account and environment.
+type Profile struct {
+	Env       string        `toml:"env"`        // Environment: sandbox, dev or prod
+	BaseURL   string        `toml:"base_url"`   // Base API URL, overrides Env
+	KeyID     string        `toml:"key_id"`     // API key ID
+	Secret    SecretRef     `toml:"secret"`     // Where to read the API secret from
+	Timeout   time.Duration `toml:"timeout"`    // HTTP request timeout, e.g., "10s"
+	RateLimit float64       `toml:"rate_limit"` // Maximum requests per second, 0 for no limit
+	RateBurst int           `toml:"rate_burst"` // Maximum burst of requests, defaults to 1
+
+	AllowProductionWrites bool `toml:"allow_production_writes"` // Allow orders, transfers and withdrawals against prod
+	ReadOnly              bool `toml:"read_only"`               // Refuse every mutating request
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/enclave/config.toml, or $HOME/.config/enclave/config.toml when XDG_CONFIG_HOME
+// is unset, on every platform.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "enclave", "config.toml"), nil
+}
+
+// Load reads the config file at path.
+func Load(path string) (*Config, error) {
+	var cfg Config
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key %s in config %s", undecoded[0], path)
+	}
+	return &cfg, nil
+}
+
+// Profile returns the named profile, or the default profile when name is empty.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return p, nil
+}
+
+// ProfileNames returns the names of all profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	res := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave"
+	"github.com/yangnei/enclave-go/enclave/client"
+)
+
+// Environment variables consulted by Resolve. They take precedence over the profile and are overridden by flags.
+const (
+	EnvConfig      = "ENCLAVE_CONFIG"  // Path of the config file
+	EnvProfile     = "ENCLAVE_PROFILE" // Profile name
+	EnvEnvironment = "ENCLAVE_ENV"     // Environment: sandbox, dev or prod
+	EnvBaseURL     = "ENCLAVE_URL"     // Base API URL, overrides ENCLAVE_ENV
+	EnvKeyID       = "ENCLAVE_KEY_ID"  // API key ID
+	EnvSecret      = "ENCLAVE_SECRET"  // API secret
+
+	// Names used by the original examples, still honored when the names above are unset.
+	legacyEnvKeyID  = "enclave_key"
+	legacyEnvSecret = "enclave_secret"
+)
+
+var ErrMissingCredentials = errors.New("missing API credentials")
+
+// Overrides holds settings given explicitly, e.g., on the command line. Zero fields are ignored.
+type Overrides struct {
+	Env       string
+	BaseURL   string
+	KeyID     string
+	Secret    string
+	Timeout   time.Duration
+	RateLimit float64
+	RateBurst int
+
+	AllowProductionWrites bool
+	ReadOnly              bool
+}
+
+// Settings are the fully resolved connection settings.
+type Settings struct {
+	Profile   string              // Name of the profile the settings started from, empty if none was found
+	Env       enclave.Environment // Environment of BaseURL, empty for custom URLs
+	BaseURL   string
+	KeyID     string
+	Secret    string
+	Timeout   time.Duration
+	RateLimit float64
+	RateBurst int
+
+	AllowProductionWrites bool // Mutating requests against prod are allowed, see client.WithProductionWrites
+	ReadOnly              bool // Mutating requests are refused, see client.WithReadOnly
+}
+
+// Options returns the client options implementing the settings.
+func (s *Settings) Options() []client.Option {
+	var opts []client.Option
+	if s.Timeout > 0 {
+		opts = append(opts, client.WithTimeout(s.Timeout))
+	}
+	if s.RateLimit > 0 {
+		opts = append(opts, client.WithRateLimit(s.RateLimit, s.RateBurst))
+	}
+	if s.AllowProductionWrites {
+		opts = append(opts, client.WithProductionWrites())
+	}
+	if s.ReadOnly {
+		opts = append(opts, client.WithReadOnly())
+	}
+	return opts
+}
+
+// NewBaseClient builds a BaseClient from the settings, e.g., to wrap it before building a Client with NewClientWithBase.
+// opts are applied after the settings' own options.
+func (s *Settings) NewBaseClient(opts ...client.Option) client.BaseClient {
+	return client.NewBaseClient(s.KeyID, s.Secret, s.BaseURL, append(s.Options(), opts...)...)
+}
+
+// NewClient builds a Client from the settings. opts are applied after the settings' own options.
+func (s *Settings) NewClient(opts ...client.Option) client.Client {
+	return client.NewClientWithBase(s.NewBaseClient(opts...))
+}
+
+// Resolve loads the config file and resolves the named profile, see Config.Resolve.
+// The file is read from $ENCLAVE_CONFIG or DefaultPath; a missing default file is treated as empty.
+func Resolve(profile string, flags *Overrides) (*Settings, error) {
+	cfg, err := LoadDefault()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Resolve(profile, flags)
+}
+
+// NewClient builds a fully configured Client from the named profile, or the default profile when name is empty.
+func NewClient(profile string, opts ...client.Option) (client.Client, error) {
+	s, err := Resolve(profile, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.NewClient(opts...), nil
+}
+
+// LoadDefault reads the config file from $ENCLAVE_CONFIG or DefaultPath. A missing default file yields an empty Config.
+func LoadDefault() (*Config, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return Load(path)
+	}
+	path, err := DefaultPath()
+	if err != nil {
+		return &Config{}, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	return Load(path)
+}
+
+// Resolve merges the named profile, environment variables and flags, in increasing order of precedence.
+// The profile name itself is taken from profile, then $ENCLAVE_PROFILE, then default_profile.
+// When no name is given anywhere and there is no "default" profile, settings come from the environment and flags alone.
+func (c *Config) Resolve(profile string, flags *Overrides) (*Settings, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if flags == nil {
+		flags = &Overrides{}
+	}
+
+	name := firstNonEmpty(profile, os.Getenv(EnvProfile), c.DefaultProfile)
+	p, err := c.Profile(name)
+	switch {
+	case err == nil:
+		name = firstNonEmpty(name, DefaultProfileName)
+	case errors.Is(err, ErrProfileNotFound) && name == "":
+		p = &Profile{}
+	default:
+		return nil, err
+	}
+
+	res := &Settings{
+		Profile:   name,
+		Timeout:   p.Timeout,
+		RateLimit: p.RateLimit,
+		RateBurst: p.RateBurst,
+
+		AllowProductionWrites: p.AllowProductionWrites || flags.AllowProductionWrites,
+		ReadOnly:              p.ReadOnly || flags.ReadOnly,
+	}
+
+	// Each layer may name an environment or a URL; within a layer the URL wins.
+	layers := []struct{ env, url string }{
+		{p.Env, p.BaseURL},
+		{os.Getenv(EnvEnvironment), os.Getenv(EnvBaseURL)},
+		{flags.Env, flags.BaseURL},
+	}
+	res.BaseURL = enclave.SandboxApiUrl
+	for _, l := range layers {
+		switch {
+		case l.url != "":
+			res.BaseURL = strings.TrimRight(l.url, "/")
+		case l.env != "":
+			env, err := enclave.ParseEnvironment(l.env)
+			if err != nil {
+				return nil, err
+			}
+			res.BaseURL = env.ApiUrl()
+		}
+	}
+	res.Env = enclave.EnvironmentFromURL(res.BaseURL)
+
+	if res.KeyID, res.Secret, err = credentials(name, p, flags); err != nil {
+		return nil, err
+	}
+
+	if flags.Timeout > 0 {
+		res.Timeout = flags.Timeout
+	}
+	if flags.RateLimit > 0 {
+		res.RateLimit = flags.RateLimit
+	}
+	if flags.RateBurst > 0 {
+		res.RateBurst = flags.RateBurst
+	}
+	return res, nil
+}
+
+// credentials returns the key ID and secret of the first layer, among flags, environment variables and the profile,
+// that sets either of them. Both must come from the same layer, so that a key is never signed with another key's secret.
+func credentials(name string, p *Profile, flags *Overrides) (keyID, secret string, err error) {
+	layers := []struct{ source, keyID, secret string }{
+		{"flags", flags.KeyID, flags.Secret},
+		{EnvKeyID + " and " + EnvSecret, os.Getenv(EnvKeyID), os.Getenv(EnvSecret)},
+		{legacyEnvKeyID + " and " + legacyEnvSecret, os.Getenv(legacyEnvKeyID), os.Getenv(legacyEnvSecret)},
+	}
+	for _, l := range layers {
+		switch {
+		case l.keyID == "" && l.secret == "":
+			continue
+		case l.keyID == "" || l.secret == "":
+			return "", "", fmt.Errorf("%w: %s set only one of the key ID and secret", ErrMissingCredentials, l.source)
+		}
+		return l.keyID, l.secret, nil
+	}
+
+	if p.KeyID == "" && p.Secret.IsZero() {
+		return "", "", fmt.Errorf("%w: set %s and %s or configure a profile", ErrMissingCredentials, EnvKeyID, EnvSecret)
+	}
+	if p.KeyID == "" || p.Secret.IsZero() {
+		return "", "", fmt.Errorf("%w: profile %s sets only one of the key ID and secret", ErrMissingCredentials, name)
+	}
+	// The profile's secret is only resolved when needed, since it may run a command.
+	if secret, err = p.Secret.Resolve(); err != nil {
+		return "", "", fmt.Errorf("failed to resolve secret of profile %s: %w", name, err)
+	}
+	if secret == "" {
+		return "", "", fmt.Errorf("%w: secret of profile %s is empty", ErrMissingCredentials, name)
+	}
+	return p.KeyID, secret, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveCredentialsAsPair(t *testing.T) {
+	profile := &Profile{KeyID: "profile-key", Secret: SecretRef{Env: "TEST_PROFILE_SECRET"}}
+	tests := []struct {
+		name       string
+		env        map[string]string
+		flags      Overrides
+		profile    *Profile
+		wantKeyID  string
+		wantSecret string
+		wantErr    bool
+	}{
+		{
+			name:       "profile",
+			profile:    profile,
+			wantKeyID:  "profile-key",
+			wantSecret: "profile-secret",
+		},
+		{
+			name:       "environment over profile",
+			env:        map[string]string{EnvKeyID: "env-key", EnvSecret: "env-secret"},
+			profile:    profile,
+			wantKeyID:  "env-key",
+			wantSecret: "env-secret",
+		},
+		{
+			name:       "flags over environment",
+			env:        map[string]string{EnvKeyID: "env-key", EnvSecret: "env-secret"},
+			flags:      Overrides{KeyID: "flag-key", Secret: "flag-secret"},
+			wantKeyID:  "flag-key",
+			wantSecret: "flag-secret",
+		},
+		{
+			name:       "legacy environment",
+			env:        map[string]string{legacyEnvKeyID: "legacy-key", legacyEnvSecret: "legacy-secret"},
+			wantKeyID:  "legacy-key",
+			wantSecret: "legacy-secret",
+		},
+		{
+			name:    "key ID from the environment and secret from the profile",
+			env:     map[string]string{EnvKeyID: "env-key"},
+			profile: profile,
+			wantErr: true,
+		},
+		{
+			name:    "secret flag alone",
+			env:     map[string]string{EnvKeyID: "env-key", EnvSecret: "env-secret"},
+			flags:   Overrides{Secret: "flag-secret"},
+			wantErr: true,
+		},
+		{
+			name:    "profile without secret",
+			profile: &Profile{KeyID: "profile-key"},
+			wantErr: true,
+		},
+		{
+			name:    "nothing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{EnvKeyID, EnvSecret, legacyEnvKeyID, legacyEnvSecret, EnvProfile, EnvEnvironment, EnvBaseURL} {
+				t.Setenv(name, tt.env[name])
+			}
+			t.Setenv("TEST_PROFILE_SECRET", "profile-secret")
+
+			cfg := &Config{}
+			if tt.profile != nil {
+				cfg.Profiles = map[string]*Profile{DefaultProfileName: tt.profile}
+			}
+			s, err := cfg.Resolve("", &tt.flags)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissingCredentials) {
+					t.Fatalf("Resolve() error = %v, want ErrMissingCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.KeyID != tt.wantKeyID || s.Secret != tt.wantSecret {
+				t.Fatalf("credentials = %s/%s, want %s/%s", s.KeyID, s.Secret, tt.wantKeyID, tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	tests := []struct {
+		name string
+		xdg  string
+		home string
+		want string
+	}{
+		{name: "XDG_CONFIG_HOME", xdg: "/xdg", home: "/home/u", want: filepath.Join("/xdg", "enclave", "config.toml")},
+		{name: "HOME fallback", home: "/home/u", want: filepath.Join("/home/u", ".config", "enclave", "config.toml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+			t.Setenv("HOME", tt.home)
+			got, err := DefaultPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("DefaultPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// SecretRef points at an API secret without storing it in the config file. Exactly one field must be set.
+type SecretRef struct {
+	Env     string   `toml:"env"`     // Name of an environment variable holding the secret
+	File    string   `toml:"file"`    // Path of a file holding the secret; a leading ~/ is expanded
+	Command []string `toml:"command"` // Command printing the secret on stdout, run without a shell
+}
+
+// IsZero reports whether no source is configured.
+func (s SecretRef) IsZero() bool {
+	return s.Env == "" && s.File == "" && len(s.Command) == 0
+}
+
+// Resolve reads the secret from its source. Surrounding whitespace is trimmed.
+func (s SecretRef) Resolve() (string, error) {
+	sources := 0
+	for _, set := range []bool{s.Env != "", s.File != "", len(s.Command) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return "", errors.New("secret must set exactly one of env, file and command")
+	}
+
+	var (
+		secret string
+		err    error
+	)
+	switch {
+	case s.Env != "":
+		secret = os.Getenv(s.Env)
+		if secret == "" {
+			return "", fmt.Errorf("secret environment variable %s is not set", s.Env)
+		}
+	case s.File != "":
+		secret, err = readSecretFile(s.File)
+	default:
+		secret, err = runSecretCommand(s.Command)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", errors.New("secret is empty")
+	}
+	return secret, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + string(os.PathSeparator) + rest
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return string(data), nil
+}
+
+func runSecretCommand(args []string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("secret command %s failed: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("secret command %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -1,7 +1,78 @@
 package enclave
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
 const (
 	SandboxApiUrl string = "https://api-sandbox.enclave.market"
 	DevApiUrl     string = "https://api-dev.enclavemarket.dev"
 	ProdApiUrl    string = "https://api.enclave.market"
 )
+
+type Environment string
+
+const (
+	EnvironmentSandbox Environment = "sandbox"
+	EnvironmentDev     Environment = "dev"
+	EnvironmentProd    Environment = "prod"
+)
+
+// ParseEnvironment returns the Environment named s, e.g., "sandbox".
+func ParseEnvironment(s string) (Environment, error) {
+	switch env := Environment(strings.ToLower(s)); env {
+	case EnvironmentSandbox, EnvironmentDev, EnvironmentProd:
+		return env, nil
+	default:
+		return "", fmt.Errorf("unknown environment %q, expected sandbox, dev or prod", s)
+	}
+}
+
+// EnvironmentFromURL returns the Environment whose API host baseURL points to, or "" if it matches none.
+// The scheme, port, path and case of baseURL are ignored.
+func EnvironmentFromURL(baseURL string) Environment {
+	host := hostname(baseURL)
+	if host == "" {
+		return ""
+	}
+	for _, env := range []Environment{EnvironmentSandbox, EnvironmentDev, EnvironmentProd} {
+		if host == hostname(env.ApiUrl()) {
+			return env
+		}
+	}
+	return ""
+}
+
+// hostname returns the lowercased host of rawURL without port, accepting URLs without a scheme.
+func hostname(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// ApiUrl returns the base API URL of the environment.
+func (e Environment) ApiUrl() string {
+	switch e {
+	case EnvironmentSandbox:
+		return SandboxApiUrl
+	case EnvironmentDev:
+		return DevApiUrl
+	case EnvironmentProd:
+		return ProdApiUrl
+	default:
+		return ""
+	}
+}
+
+// IsMainnet reports whether the environment settles on blockchain mainnets rather than testnets.
+func (e Environment) IsMainnet() bool {
+	return e == EnvironmentProd
+}
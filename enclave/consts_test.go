@@ -0,0 +1,31 @@
+package enclave
+
+import "testing"
+
+func TestEnvironmentFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Environment
+	}{
+		{"https://api.enclave.market", EnvironmentProd},
+		{"https://api.enclave.market/", EnvironmentProd},
+		{"http://api.enclave.market", EnvironmentProd},
+		{"https://API.Enclave.Market", EnvironmentProd},
+		{"https://api.enclave.market:443", EnvironmentProd},
+		{"https://api.enclave.market/v1", EnvironmentProd},
+		{"https://api.enclave.market./", EnvironmentProd},
+		{"api.enclave.market", EnvironmentProd},
+		{" https://api.enclave.market ", EnvironmentProd},
+		{"https://api-sandbox.enclave.market", EnvironmentSandbox},
+		{"https://api-dev.enclavemarket.dev:8443/prefix", EnvironmentDev},
+		{"https://api.enclave.market.example.com", ""},
+		{"https://evil.example.com/api.enclave.market", ""},
+		{"http://127.0.0.1:8080", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := EnvironmentFromURL(tt.url); got != tt.want {
+			t.Errorf("EnvironmentFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
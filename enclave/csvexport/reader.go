@@ -0,0 +1,197 @@
+package csvexport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrMissingColumn = errors.New("missing CSV column")
+	ErrUnknownColumn = errors.New("unknown CSV column")
+)
+
+// setter parses a single CSV value into a field of T.
+type setter[T any] func(dst *T, value string) error
+
+// schema maps normalized column names to setters. Several names may share a setter to cover aliases.
+type schema[T any] map[string]setter[T]
+
+// Reader decodes CSV rows into values of T, mapping columns by their header names.
+// Columns without a matching field are ignored, so exports with added columns remain readable, unless
+// DisallowUnknownColumns is called. Headers lacking a column that identifies the row are rejected.
+type Reader[T any] struct {
+	cr      *csv.Reader
+	columns []string
+	setters []setter[T]
+	line    int
+}
+
+// newReader reads the header and maps its columns with s. Each entry of required lists the aliases of a column
+// that must be present.
+func newReader[T any](r io.Reader, s schema[T], required [][]string) (*Reader[T], error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	res := &Reader[T]{
+		cr:      cr,
+		columns: make([]string, len(header)),
+		setters: make([]setter[T], len(header)),
+		line:    1,
+	}
+	present := make(map[string]bool, len(header))
+	for i, name := range header {
+		res.columns[i] = name
+		res.setters[i] = s[normalize(name)]
+		present[normalize(name)] = true
+	}
+	for _, aliases := range required {
+		found := false
+		for _, alias := range aliases {
+			found = found || present[alias]
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, strings.Join(aliases, " or "))
+		}
+	}
+	return res, nil
+}
+
+// DisallowUnknownColumns returns an error listing the header's columns without a matching field, if any.
+// Use it when reading files that must match the schema exactly rather than exports that may gain columns.
+func (r *Reader[T]) DisallowUnknownColumns() error {
+	var unknown []string
+	for i, name := range r.columns {
+		if r.setters[i] == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownColumn, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Read returns the next row, or io.EOF once every row has been read.
+func (r *Reader[T]) Read() (*T, error) {
+	record, err := r.cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.line++
+
+	var res T
+	for i, value := range record {
+		if i >= len(r.setters) || r.setters[i] == nil {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if err := r.setters[i](&res, value); err != nil {
+			return nil, fmt.Errorf("line %d, column %s: %w", r.line, r.columns[i], err)
+		}
+	}
+	return &res, nil
+}
+
+// ReadAll returns every remaining row.
+func (r *Reader[T]) ReadAll() ([]*T, error) {
+	var res []*T
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+}
+
+// normalize lowercases a column name and strips separators so "client_order_id", "Client Order ID" and "clientOrderId" match.
+func normalize(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if c == '_' || c == ' ' || c == '-' {
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func stringField[T any](field func(*T) *string) setter[T] {
+	return func(dst *T, value string) error {
+		*field(dst) = value
+		return nil
+	}
+}
+
+func typedField[T any, S ~string](field func(*T) *S) setter[T] {
+	return func(dst *T, value string) error {
+		*field(dst) = S(value)
+		return nil
+	}
+}
+
+func decimalField[T any](field func(*T) *decimal.Decimal) setter[T] {
+	return func(dst *T, value string) error {
+		d, err := decimal.NewFromString(value)
+		if err != nil {
+			return err
+		}
+		*field(dst) = d
+		return nil
+	}
+}
+
+func intField[T any](field func(*T) *int64) setter[T] {
+	return func(dst *T, value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field(dst) = n
+		return nil
+	}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// timeField accepts ISO 8601 timestamps and Unix timestamps in milliseconds.
+func timeField[T any](field func(*T) *time.Time) setter[T] {
+	return func(dst *T, value string) error {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			*field(dst) = time.UnixMilli(ms).UTC()
+			return nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				*field(dst) = t
+				return nil
+			}
+		}
+		return fmt.Errorf("unrecognized time %q", value)
+	}
+}
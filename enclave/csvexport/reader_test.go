@@ -0,0 +1,230 @@
+package csvexport
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+func open(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestOrderReader(t *testing.T) {
+	r, err := NewOrderReader(open(t, "orders.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("got %d orders, want 2", len(orders))
+	}
+
+	o := orders[0]
+	if o.OrderID != "o1" || o.ClientOrderID != "mm-1" || o.Market != "AVAX-USDC" || o.Side != model.OrderSideBuy ||
+		o.Type != model.OrderTypeLimit || o.Status != model.OrderStatusOpen {
+		t.Errorf("order 0 = %+v", o)
+	}
+	if !o.Price.Equal(d("20.5")) || !o.Size.Equal(d("10")) || !o.FilledSize.Equal(d("4")) {
+		t.Errorf("order 0 price %s size %s filled %s, want 20.5, 10 and 4", o.Price, o.Size, o.FilledSize)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.UTC); !o.CreatedAt.Equal(want) {
+		t.Errorf("order 0 created at %s, want %s", o.CreatedAt, want)
+	}
+
+	// Empty values leave their field unset.
+	if o := orders[1]; o.ClientOrderID != "" || !o.Price.IsZero() || o.Status != model.OrderStatusFullyFilled {
+		t.Errorf("order 1 = %+v", o)
+	}
+}
+
+func TestFillReader(t *testing.T) {
+	r, err := NewFillReader(open(t, "fills.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fills, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills, want 2", len(fills))
+	}
+	if f := fills[0]; f.ID != "f1" || f.OrderID != "o1" || !f.Fee.Equal(d("0.01")) || !f.Size.Equal(d("4")) {
+		t.Errorf("fill 0 = %+v", f)
+	}
+	if f := fills[1]; f.ID != "f2" || f.Side != model.OrderSideSell || !f.Price.Equal(d("20.4")) {
+		t.Errorf("fill 1 = %+v", f)
+	}
+}
+
+func TestDepositAndWithdrawalReaders(t *testing.T) {
+	dr, err := NewDepositReader(open(t, "deposits.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits, err := dr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 1 {
+		t.Fatalf("got %d deposits, want 1", len(deposits))
+	}
+	if dp := deposits[0]; dp.TxID != "0xabc" || dp.Coin != "USDC" || !dp.Size.Equal(d("100.25")) ||
+		dp.CurrentConfirmations != 3 || dp.RequiredConfirmations != 12 || dp.Status != "pending" {
+		t.Errorf("deposit = %+v", dp)
+	}
+
+	wr, err := NewWithdrawalReader(open(t, "withdrawals.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdrawals, err := wr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals) != 1 {
+		t.Fatalf("got %d withdrawals, want 1", len(withdrawals))
+	}
+	if w := withdrawals[0]; w.WithdrawalID != "w1" || w.Coin != "AVAX" || !w.Size.Equal(d("1.5")) || w.TxID != "" {
+		t.Errorf("withdrawal = %+v", w)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, name := range []string{"client_order_id", "Client Order ID", "clientOrderId", "CLIENT-ORDER-ID", "\ufeffclientOrderId"} {
+		if got := normalize(name); got != "clientorderid" {
+			t.Errorf("normalize(%q) = %q, want clientorderid", name, got)
+		}
+	}
+}
+
+func TestTimeField(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2024-01-02T03:04:05Z", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{value: "2024-01-02T03:04:05.5+01:00", want: time.Date(2024, 1, 2, 2, 4, 5, 5e8, time.UTC)},
+		{value: "2024-01-02 03:04:05.25Z", want: time.Date(2024, 1, 2, 3, 4, 5, 25e7, time.UTC)},
+		{value: "2024-01-02 03:04:05", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{value: "2024-01-02T03:04:05", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{value: "1704164645123", want: time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.UTC)},
+		{value: "02/01/2024", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	}
+
+	set := timeField(func(f *model.Fill) *time.Time { return &f.Time })
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var f model.Fill
+			err := set(&f, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !f.Time.Equal(tt.want) {
+				t.Fatalf("time = %s, want %s", f.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		strict  bool // Call DisallowUnknownColumns
+		wantErr error
+		wantAny bool // Any error is expected, on the header or a row
+	}{
+		{
+			name:    "missing identifier",
+			csv:     "market,side,price,size\nAVAX-USDC,buy,20,1\n",
+			wantErr: ErrMissingColumn,
+		},
+		{
+			name:    "missing market",
+			csv:     "fillId,side,price,size\nf1,buy,20,1\n",
+			wantErr: ErrMissingColumn,
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantAny: true,
+		},
+		{
+			name: "added column tolerated",
+			csv:  "id,market,side,price,size,venue\nf1,AVAX-USDC,buy,20,1,spot\n",
+		},
+		{
+			name:    "added column rejected when strict",
+			csv:     "id,market,side,price,size,venue\nf1,AVAX-USDC,buy,20,1,spot\n",
+			strict:  true,
+			wantErr: ErrUnknownColumn,
+		},
+		{
+			name:   "known columns accepted when strict",
+			csv:    "Fill ID,Market,Side,Price,Size\nf1,AVAX-USDC,buy,20,1\n",
+			strict: true,
+		},
+		{
+			name:    "invalid decimal",
+			csv:     "id,market,side,price,size\nf1,AVAX-USDC,buy,twenty,1\n",
+			wantAny: true,
+		},
+		{
+			name:    "invalid time",
+			csv:     "id,market,side,price,size,time\nf1,AVAX-USDC,buy,20,1,soon\n",
+			wantAny: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := func() error {
+				r, err := NewFillReader(strings.NewReader(tt.csv))
+				if err != nil {
+					return err
+				}
+				if tt.strict {
+					if err := r.DisallowUnknownColumns(); err != nil {
+						return err
+					}
+				}
+				_, err = r.ReadAll()
+				return err
+			}()
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAny:
+				if err == nil {
+					t.Fatal("succeeded, want an error")
+				}
+			case err != nil:
+				t.Fatalf("error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package csvexport
+
+import (
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var orderSchema = schema[model.Order]{
+	"canceledat":    timeField(func(o *model.Order) *time.Time { return &o.CanceledAt }),
+	"clientorderid": stringField(func(o *model.Order) *string { return &o.ClientOrderID }),
+	"createdat":     timeField(func(o *model.Order) *time.Time { return &o.CreatedAt }),
+	"fee":           decimalField(func(o *model.Order) *decimal.Decimal { return &o.Fee }),
+	"filledat":      timeField(func(o *model.Order) *time.Time { return &o.FilledAt }),
+	"filledcost":    decimalField(func(o *model.Order) *decimal.Decimal { return &o.FilledCost }),
+	"filledsize":    decimalField(func(o *model.Order) *decimal.Decimal { return &o.FilledSize }),
+	"market":        stringField(func(o *model.Order) *string { return &o.Market }),
+	"orderid":       stringField(func(o *model.Order) *string { return &o.OrderID }),
+	"id":            stringField(func(o *model.Order) *string { return &o.OrderID }),
+	"price":         decimalField(func(o *model.Order) *decimal.Decimal { return &o.Price }),
+	"side":          typedField(func(o *model.Order) *model.OrderSide { return &o.Side }),
+	"size":          decimalField(func(o *model.Order) *decimal.Decimal { return &o.Size }),
+	"status":        typedField(func(o *model.Order) *model.OrderStatus { return &o.Status }),
+	"type":          typedField(func(o *model.Order) *model.OrderType { return &o.Type }),
+	"timeinforce":   typedField(func(o *model.Order) *model.TimeInForce { return &o.TimeInForce }),
+	"cancelreason":  stringField(func(o *model.Order) *string { return &o.CancelReason }),
+}
+
+// orderColumns lists the columns an export must have, each with its aliases.
+var orderColumns = [][]string{{"orderid", "id"}, {"market"}, {"side"}}
+
+var fillSchema = schema[model.Fill]{
+	"clientorderid": stringField(func(f *model.Fill) *string { return &f.ClientOrderID }),
+	"fee":           decimalField(func(f *model.Fill) *decimal.Decimal { return &f.Fee }),
+	"filledcost":    decimalField(func(f *model.Fill) *decimal.Decimal { return &f.FilledCost }),
+	"id":            stringField(func(f *model.Fill) *string { return &f.ID }),
+	"fillid":        stringField(func(f *model.Fill) *string { return &f.ID }),
+	"market":        stringField(func(f *model.Fill) *string { return &f.Market }),
+	"orderid":       stringField(func(f *model.Fill) *string { return &f.OrderID }),
+	"price":         decimalField(func(f *model.Fill) *decimal.Decimal { return &f.Price }),
+	"side":          typedField(func(f *model.Fill) *model.OrderSide { return &f.Side }),
+	"size":          decimalField(func(f *model.Fill) *decimal.Decimal { return &f.Size }),
+	"time":          timeField(func(f *model.Fill) *time.Time { return &f.Time }),
+}
+
+var fillColumns = [][]string{{"id", "fillid"}, {"market"}, {"side"}, {"size"}, {"price"}}
+
+var depositSchema = schema[model.Deposit]{
+	"coin":                  stringField(func(d *model.Deposit) *string { return &d.Coin }),
+	"symbol":                stringField(func(d *model.Deposit) *string { return &d.Coin }),
+	"currentconfirmations":  intField(func(d *model.Deposit) *int64 { return &d.CurrentConfirmations }),
+	"requiredconfirmations": intField(func(d *model.Deposit) *int64 { return &d.RequiredConfirmations }),
+	"size":                  decimalField(func(d *model.Deposit) *decimal.Decimal { return &d.Size }),
+	"amount":                decimalField(func(d *model.Deposit) *decimal.Decimal { return &d.Size }),
+	"status":                stringField(func(d *model.Deposit) *string { return &d.Status }),
+	"time":                  timeField(func(d *model.Deposit) *time.Time { return &d.Time }),
+	"txid":                  stringField(func(d *model.Deposit) *string { return &d.TxID }),
+}
+
+var depositColumns = [][]string{{"txid"}, {"coin", "symbol"}, {"size", "amount"}}
+
+var withdrawalSchema = schema[model.Withdrawal]{
+	"address":      stringField(func(w *model.Withdrawal) *string { return &w.Address }),
+	"coin":         stringField(func(w *model.Withdrawal) *string { return &w.Coin }),
+	"symbol":       stringField(func(w *model.Withdrawal) *string { return &w.Coin }),
+	"size":         decimalField(func(w *model.Withdrawal) *decimal.Decimal { return &w.Size }),
+	"amount":       decimalField(func(w *model.Withdrawal) *decimal.Decimal { return &w.Size }),
+	"status":       stringField(func(w *model.Withdrawal) *string { return &w.Status }),
+	"time":         timeField(func(w *model.Withdrawal) *time.Time { return &w.Time }),
+	"txid":         stringField(func(w *model.Withdrawal) *string { return &w.TxID }),
+	"withdrawalid": stringField(func(w *model.Withdrawal) *string { return &w.WithdrawalID }),
+}
+
+var withdrawalColumns = [][]string{{"withdrawalid"}, {"coin", "symbol"}, {"size", "amount"}}
+
+// NewOrderReader reads the header of an orders CSV export and returns a Reader for its rows.
+func NewOrderReader(r io.Reader) (*Reader[model.Order], error) {
+	return newReader(r, orderSchema, orderColumns)
+}
+
+// NewFillReader reads the header of a fills CSV export and returns a Reader for its rows.
+func NewFillReader(r io.Reader) (*Reader[model.Fill], error) {
+	return newReader(r, fillSchema, fillColumns)
+}
+
+// NewDepositReader reads the header of a deposits CSV export and returns a Reader for its rows.
+func NewDepositReader(r io.Reader) (*Reader[model.Deposit], error) {
+	return newReader(r, depositSchema, depositColumns)
+}
+
+// NewWithdrawalReader reads the header of a withdrawals CSV export and returns a Reader for its rows.
+func NewWithdrawalReader(r io.Reader) (*Reader[model.Withdrawal], error) {
+	return newReader(r, withdrawalSchema, withdrawalColumns)
+}
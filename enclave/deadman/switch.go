@@ -0,0 +1,261 @@
+package deadman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+)
+
+// ErrInterrupted is returned by Run after orders were canceled because of SIGINT or SIGTERM.
+var ErrInterrupted = errors.New("interrupted by signal")
+
+type Reason string
+
+const (
+	ReasonTimeout Reason = "timeout" // No heartbeat arrived within the timeout
+	ReasonSignal  Reason = "signal"  // The process received SIGINT or SIGTERM
+	ReasonStopped Reason = "stopped" // Run's context was canceled with CancelOnStop set
+	ReasonManual  Reason = "manual"  // Trigger was called by the application
+)
+
+// Venue is the "spot" or "perps" side of a Result.
+type Venue string
+
+const (
+	VenueSpot  Venue = "spot"
+	VenuePerps Venue = "perps"
+)
+
+// Config configures a Switch. At least one of Spot and Perps must be set.
+type Config struct {
+	Timeout       time.Duration      // Maximum time between two heartbeats, defaults to 30 seconds
+	CheckInterval time.Duration      // Time between two checks of the last heartbeat, defaults to a tenth of Timeout
+	Spot          client.SpotClient  // Spot client to cancel orders with, or nil
+	SpotMarkets   []string           // Spot markets to cancel, empty for every market
+	Perps         client.PerpsClient // Perps client to cancel orders with, or nil
+	PerpsMarkets  []string           // Perps markets to cancel, empty for every market
+	HeartbeatFile string             // Optional file whose modification time also counts as a heartbeat, see TouchHeartbeat
+	HandleSignals bool               // Cancel and stop on SIGINT and SIGTERM
+	CancelOnStop  bool               // Cancel when Run's context is canceled
+	OnTrigger     func(r *Report)    // Called with the outcome of every cancellation
+}
+
+// Result is the outcome of canceling the orders of one market, or of every market when Market is empty.
+type Result struct {
+	Venue    Venue         `json:"venue"`
+	Market   string        `json:"market,omitempty"`
+	Canceled []model.Order `json:"canceled"`        // Orders that were open right before the cancellation
+	Error    string        `json:"error,omitempty"` // Error of the cancellation, or of listing the orders
+
+	failed bool // The cancellation itself failed, as opposed to only listing the orders
+}
+
+// Report describes one cancellation.
+type Report struct {
+	Time          time.Time `json:"time"`
+	Reason        Reason    `json:"reason"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	Results       []*Result `json:"results"`
+}
+
+// Err returns an error joining every failed cancellation, or nil.
+func (r *Report) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Error == "" {
+			continue
+		}
+		market := res.Market
+		if market == "" {
+			market = "all markets"
+		}
+		errs = append(errs, fmt.Errorf("%s %s: %s", res.Venue, market, res.Error))
+	}
+	return errors.Join(errs...)
+}
+
+// Switch cancels open orders when the application stops calling Heartbeat.
+type Switch struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	lastBeat  time.Time
+	triggered bool // Canceled successfully since the last heartbeat, so a hung process is only canceled once
+}
+
+// New initializes a Switch. The timeout starts counting immediately.
+func New(cfg Config) (*Switch, error) {
+	if cfg.Spot == nil && cfg.Perps == nil {
+		return nil, errors.New("at least one of spot and perps clients is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = cfg.Timeout / 10
+	}
+
+	return &Switch{cfg: cfg, now: time.Now, lastBeat: time.Now()}, nil
+}
+
+// Heartbeat signals that the application is alive and re-arms the switch after a cancellation.
+func (s *Switch) Heartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastBeat = s.now()
+	s.triggered = false
+}
+
+// TouchHeartbeat records a heartbeat in a file watched by a Switch in another process.
+func TouchHeartbeat(path string) error {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// LastHeartbeat returns the time of the latest heartbeat, in-process or through the heartbeat file.
+func (s *Switch) LastHeartbeat() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncFile()
+	return s.lastBeat
+}
+
+// syncFile counts a newer heartbeat file modification as a heartbeat. It must be called with mu held.
+func (s *Switch) syncFile() {
+	if s.cfg.HeartbeatFile == "" {
+		return
+	}
+	info, err := os.Stat(s.cfg.HeartbeatFile)
+	if err != nil {
+		return
+	}
+	if info.ModTime().After(s.lastBeat) {
+		s.lastBeat = info.ModTime()
+		s.triggered = false
+	}
+}
+
+// Run checks for missed heartbeats until ctx is done, canceling orders whenever the timeout elapses.
+// With HandleSignals set, it cancels orders and returns ErrInterrupted on SIGINT or SIGTERM.
+func (s *Switch) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	var signals chan os.Signal
+	if s.cfg.HandleSignals {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if s.cfg.CancelOnStop {
+				s.report(s.Trigger(ReasonStopped), onError)
+			}
+			return ctx.Err()
+		case sig := <-signals:
+			s.report(s.Trigger(ReasonSignal), onError)
+			return fmt.Errorf("%w: %s", ErrInterrupted, sig)
+		case <-ticker.C:
+			if r := s.check(); r != nil {
+				s.report(r, onError)
+			}
+		}
+	}
+}
+
+func (s *Switch) report(r *Report, onError func(error)) {
+	if err := r.Err(); err != nil && onError != nil {
+		onError(err)
+	}
+}
+
+// check cancels orders if the timeout elapsed since the last heartbeat and they were not canceled yet.
+func (s *Switch) check() *Report {
+	s.mu.Lock()
+	s.syncFile()
+	expired := !s.triggered && s.now().Sub(s.lastBeat) > s.cfg.Timeout
+	s.mu.Unlock()
+
+	if !expired {
+		return nil
+	}
+	return s.Trigger(ReasonTimeout)
+}
+
+// Trigger cancels the open orders of every configured market now and reports what was canceled.
+// If any cancellation fails, the switch stays armed and the next check after the timeout cancels again.
+func (s *Switch) Trigger(reason Reason) *Report {
+	s.mu.Lock()
+	s.triggered = true
+	last := s.lastBeat
+	s.mu.Unlock()
+
+	r := &Report{Time: s.now(), Reason: reason, LastHeartbeat: last}
+	if s.cfg.Spot != nil {
+		r.Results = append(r.Results, cancelAll(VenueSpot, s.cfg.Spot, s.cfg.SpotMarkets)...)
+	}
+	if s.cfg.Perps != nil {
+		r.Results = append(r.Results, cancelAll(VenuePerps, s.cfg.Perps, s.cfg.PerpsMarkets)...)
+	}
+	for _, res := range r.Results {
+		if !res.failed {
+			continue
+		}
+		s.mu.Lock()
+		// A heartbeat during the cancellation already re-armed the switch.
+		if s.lastBeat.Equal(last) {
+			s.triggered = false
+		}
+		s.mu.Unlock()
+		break
+	}
+
+	if s.cfg.OnTrigger != nil {
+		s.cfg.OnTrigger(r)
+	}
+	return r
+}
+
+func cancelAll(venue Venue, c client.OrderFillClient, markets []string) []*Result {
+	if len(markets) == 0 {
+		markets = []string{""}
+	}
+
+	res := make([]*Result, 0, len(markets))
+	for _, market := range markets {
+		r := &Result{Venue: venue, Market: market}
+		// Listing first is best effort; the cancellation is sent even if it fails.
+		open, listErr := oms.OpenOrders(c, market)
+		r.Canceled = open
+		if err := c.CancelOrders(&api.CancelOrdersRequest{Market: market}); err != nil {
+			r.Error = err.Error()
+			r.Canceled = nil
+			r.failed = true
+		} else if listErr != nil {
+			r.Error = fmt.Sprintf("canceled, but failed to list open orders: %v", listErr)
+		}
+		res = append(res, r)
+	}
+	return res
+}
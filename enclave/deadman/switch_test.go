@@ -0,0 +1,72 @@
+package deadman
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+)
+
+func TestCheckRetriesFailedCancellation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	spot := mocks.NewMockSpotClient(ctrl)
+	spot.EXPECT().GetOrders(gomock.Any()).Return(&api.GetOrdersResponse{}, nil).AnyTimes()
+	gomock.InOrder(
+		spot.EXPECT().CancelOrders(gomock.Any()).Return(errors.New("503 service unavailable")),
+		spot.EXPECT().CancelOrders(gomock.Any()).Return(nil),
+	)
+
+	s, err := New(Config{Timeout: time.Minute, Spot: spot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := s.lastBeat
+	s.now = func() time.Time { return now }
+
+	if r := s.check(); r != nil {
+		t.Fatalf("check before the timeout = %+v, want nil", r)
+	}
+
+	now = now.Add(2 * time.Minute)
+	r := s.check()
+	if r == nil || r.Err() == nil {
+		t.Fatalf("first check after the timeout = %+v, want a failed cancellation", r)
+	}
+
+	r = s.check()
+	if r == nil {
+		t.Fatal("check after a failed cancellation = nil, want a retry")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+
+	if r := s.check(); r != nil {
+		t.Fatalf("check after a successful cancellation = %+v, want nil until the next heartbeat", r)
+	}
+}
+
+func TestTriggerListFailureDoesNotRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	spot := mocks.NewMockSpotClient(ctrl)
+	spot.EXPECT().GetOrders(gomock.Any()).Return(nil, errors.New("timeout"))
+	spot.EXPECT().CancelOrders(gomock.Any()).Return(nil)
+
+	s, err := New(Config{Timeout: time.Minute, Spot: spot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := s.lastBeat.Add(2 * time.Minute)
+	s.now = func() time.Time { return now }
+
+	if r := s.check(); r == nil || r.Err() == nil {
+		t.Fatalf("check = %+v, want a listing error", r)
+	}
+	if r := s.check(); r != nil {
+		t.Fatalf("check after a successful cancellation = %+v, want nil", r)
+	}
+}
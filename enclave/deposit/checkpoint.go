@@ -0,0 +1,96 @@
+package deposit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Checkpoint is the last known state of a deposit, keyed by TxID.
+type Checkpoint struct {
+	Confirmations int64  `json:"confirmations"` // Last confirmation count reported
+	Status        string `json:"status"`        // Last status reported
+	Done          bool   `json:"done"`          // Whether the deposit was credited or failed and needs no further events
+}
+
+// CheckpointStore persists checkpoints so events are not emitted twice across restarts.
+type CheckpointStore interface {
+	Load() (map[string]*Checkpoint, error)
+	Save(checkpoints map[string]*Checkpoint) error
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory only.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]*Checkpoint
+}
+
+// NewMemoryCheckpointStore initializes an empty MemoryCheckpointStore.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string]*Checkpoint)}
+}
+
+func (s *MemoryCheckpointStore) Load() (map[string]*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(map[string]*Checkpoint, len(s.checkpoints))
+	for k, v := range s.checkpoints {
+		cp := *v
+		res[k] = &cp
+	}
+	return res, nil
+}
+
+func (s *MemoryCheckpointStore) Save(checkpoints map[string]*Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints = make(map[string]*Checkpoint, len(checkpoints))
+	for k, v := range checkpoints {
+		cp := *v
+		s.checkpoints[k] = &cp
+	}
+	return nil
+}
+
+// FileCheckpointStore keeps checkpoints in a single JSON file.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore initializes a FileCheckpointStore backed by path. The file is created on first save.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) Load() (map[string]*Checkpoint, error) {
+	checkpoints := make(map[string]*Checkpoint)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+func (s *FileCheckpointStore) Save(checkpoints map[string]*Checkpoint) error {
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,189 @@
+package deposit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Handlers holds the callbacks invoked by the Watcher. Any of them may be nil.
+//
+// Delivery is at-least-once: callbacks run before the checkpoints are saved, so events whose checkpoint was not saved,
+// because the process stopped or the save failed, are delivered again after a restart. Handlers should be idempotent,
+// e.g., by keying their effects on the deposit's TxID.
+type Handlers struct {
+	OnSeen         func(d *model.Deposit)                 // A deposit was seen for the first time
+	OnConfirmation func(d *model.Deposit, previous int64) // The confirmation count of a pending deposit increased
+	OnCredited     func(d *model.Deposit)                 // The deposit was credited to the account
+	OnFailed       func(d *model.Deposit)                 // The deposit failed
+}
+
+// Config configures a Watcher.
+type Config struct {
+	Coins    []string        // Coins to watch, all coins when empty
+	Interval time.Duration   // Time between two polls, defaults to 30 seconds
+	Store    CheckpointStore // Persists checkpoints across restarts, in memory when nil
+	Handlers Handlers
+}
+
+// Watcher polls deposits and invokes callbacks as they progress towards being credited.
+type Watcher struct {
+	client client.Client
+	cfg    Config
+	coins  map[string]struct{}
+
+	mu          sync.Mutex
+	checkpoints map[string]*Checkpoint
+}
+
+// NewWatcher initializes a Watcher.
+func NewWatcher(c client.Client, cfg Config) *Watcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryCheckpointStore()
+	}
+
+	coins := make(map[string]struct{}, len(cfg.Coins))
+	for _, coin := range cfg.Coins {
+		coins[strings.ToUpper(coin)] = struct{}{}
+	}
+
+	return &Watcher{
+		client: c,
+		cfg:    cfg,
+		coins:  coins,
+	}
+}
+
+// Run polls until ctx is done. Errors from individual polls are passed to onError, if non-nil, and do not stop the loop.
+func (w *Watcher) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches every page of deposits once, invokes callbacks for every change since the last checkpoint and saves the
+// new checkpoints.
+func (w *Watcher) Poll() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.checkpoints == nil {
+		checkpoints, err := w.cfg.Store.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load deposit checkpoints: %w", err)
+		}
+		w.checkpoints = checkpoints
+	}
+
+	deposits, err := w.deposits()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, d := range deposits {
+		if !w.watches(d.Coin) || d.TxID == "" {
+			continue
+		}
+		if w.observe(d) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := w.cfg.Store.Save(w.checkpoints); err != nil {
+		return fmt.Errorf("failed to save deposit checkpoints: %w", err)
+	}
+	return nil
+}
+
+// deposits lists every deposit, following pagination.
+func (w *Watcher) deposits() ([]*model.Deposit, error) {
+	var res []*model.Deposit
+	req := &api.GetDepositsRequest{}
+	for {
+		resp, err := w.client.GetDepositsPage(req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.Deposits...)
+		if resp.PageInfo.NextCursor == "" || resp.PageInfo.NextCursor == req.Cursor || len(resp.Deposits) == 0 {
+			return res, nil
+		}
+		req.Cursor = resp.PageInfo.NextCursor
+	}
+}
+
+// observe invokes the callbacks for d and reports whether its checkpoint changed.
+func (w *Watcher) observe(d *model.Deposit) bool {
+	h := w.cfg.Handlers
+
+	cp, ok := w.checkpoints[d.TxID]
+	if ok && cp.Done {
+		return false
+	}
+	if !ok {
+		cp = &Checkpoint{}
+		w.checkpoints[d.TxID] = cp
+		if h.OnSeen != nil {
+			h.OnSeen(d)
+		}
+	}
+
+	changed := !ok
+	if d.CurrentConfirmations > cp.Confirmations {
+		if ok && h.OnConfirmation != nil {
+			h.OnConfirmation(d, cp.Confirmations)
+		}
+		cp.Confirmations = d.CurrentConfirmations
+		changed = true
+	}
+	if d.Status != cp.Status {
+		cp.Status = d.Status
+		changed = true
+	}
+
+	switch d.Status {
+	case model.DepositStatusConfirmed:
+		cp.Done = true
+		if h.OnCredited != nil {
+			h.OnCredited(d)
+		}
+	case model.DepositStatusFailed:
+		cp.Done = true
+		if h.OnFailed != nil {
+			h.OnFailed(d)
+		}
+	}
+	return changed
+}
+
+func (w *Watcher) watches(coin string) bool {
+	if len(w.coins) == 0 {
+		return true
+	}
+	_, ok := w.coins[strings.ToUpper(coin)]
+	return ok
+}
@@ -0,0 +1,187 @@
+package deposit
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func deposit(txID, coin, status string, confirmations int64) *model.Deposit {
+	return &model.Deposit{TxID: txID, Coin: coin, Status: status, CurrentConfirmations: confirmations, RequiredConfirmations: 12}
+}
+
+// expectPages serves deposits to GetDepositsPage in pages of two.
+func expectPages(t *testing.T, c *mocks.MockClient, deposits ...*model.Deposit) {
+	t.Helper()
+	var calls []*gomock.Call
+	for i := 0; i == 0 || i < len(deposits); i += 2 {
+		cursor, next := "", ""
+		if i > 0 {
+			cursor = fmt.Sprintf("p%d", i)
+		}
+		if i+2 < len(deposits) {
+			next = fmt.Sprintf("p%d", i+2)
+		}
+		page := deposits[i:min(i+2, len(deposits))]
+		calls = append(calls, c.EXPECT().GetDepositsPage(gomock.Any()).DoAndReturn(func(req *api.GetDepositsRequest) (*api.GetDepositsResponse, error) {
+			if req.Cursor != cursor {
+				t.Errorf("GetDepositsPage() cursor = %q, want %q", req.Cursor, cursor)
+			}
+			return &api.GetDepositsResponse{Deposits: page, PageInfo: api.PageInfo{NextCursor: next}}, nil
+		}))
+	}
+	gomock.InOrder(calls...)
+}
+
+// recorder logs the events of a Watcher.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) handlers() Handlers {
+	return Handlers{
+		OnSeen: func(d *model.Deposit) { r.events = append(r.events, "seen "+d.TxID) },
+		OnConfirmation: func(d *model.Deposit, previous int64) {
+			r.events = append(r.events, fmt.Sprintf("confirmation %s %d->%d", d.TxID, previous, d.CurrentConfirmations))
+		},
+		OnCredited: func(d *model.Deposit) { r.events = append(r.events, "credited "+d.TxID) },
+		OnFailed:   func(d *model.Deposit) { r.events = append(r.events, "failed "+d.TxID) },
+	}
+}
+
+func (r *recorder) take() []string {
+	res := r.events
+	r.events = nil
+	return res
+}
+
+func TestWatcherTransitions(t *testing.T) {
+	polls := []struct {
+		deposits []*model.Deposit
+		want     []string
+	}{
+		{
+			deposits: []*model.Deposit{
+				deposit("a", "USDC", model.DepositStatusPending, 1),
+				deposit("b", "AVAX", model.DepositStatusPending, 0),
+				deposit("", "USDC", model.DepositStatusPending, 0),
+			},
+			want: []string{"seen a"},
+		},
+		{
+			deposits: []*model.Deposit{deposit("a", "usdc", model.DepositStatusPending, 3)},
+			want:     []string{"confirmation a 1->3"},
+		},
+		{
+			// An unchanged deposit emits nothing.
+			deposits: []*model.Deposit{deposit("a", "USDC", model.DepositStatusPending, 3)},
+		},
+		{
+			deposits: []*model.Deposit{
+				deposit("a", "USDC", model.DepositStatusConfirmed, 12),
+				deposit("d", "USDC", model.DepositStatusFailed, 0),
+			},
+			want: []string{"confirmation a 3->12", "credited a", "seen d", "failed d"},
+		},
+		{
+			// Credited and failed deposits are done.
+			deposits: []*model.Deposit{
+				deposit("a", "USDC", model.DepositStatusConfirmed, 13),
+				deposit("d", "USDC", model.DepositStatusFailed, 0),
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockClient(ctrl)
+	rec := &recorder{}
+	w := NewWatcher(c, Config{Coins: []string{"usdc"}, Handlers: rec.handlers()})
+	for i, p := range polls {
+		expectPages(t, c, p.deposits...)
+		if err := w.Poll(); err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if got := rec.take(); !reflect.DeepEqual(got, p.want) {
+			t.Fatalf("poll %d: events = %q, want %q", i, got, p.want)
+		}
+	}
+}
+
+func TestWatcherCheckpoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockClient(ctrl)
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "deposits.json"))
+	rec := &recorder{}
+
+	w := NewWatcher(c, Config{Store: store, Handlers: rec.handlers()})
+	expectPages(t, c,
+		deposit("a", "USDC", model.DepositStatusPending, 2),
+		deposit("b", "AVAX", model.DepositStatusConfirmed, 12),
+	)
+	if err := w.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	rec.take()
+
+	checkpoints, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*Checkpoint{
+		"a": {Confirmations: 2, Status: model.DepositStatusPending},
+		"b": {Confirmations: 12, Status: model.DepositStatusConfirmed, Done: true},
+	}
+	if !reflect.DeepEqual(checkpoints, want) {
+		t.Fatalf("saved checkpoints = %+v, want %+v", checkpoints, want)
+	}
+
+	// A restarted watcher resumes from the saved checkpoints.
+	w = NewWatcher(c, Config{Store: store, Handlers: rec.handlers()})
+	expectPages(t, c,
+		deposit("a", "USDC", model.DepositStatusPending, 4),
+		deposit("b", "AVAX", model.DepositStatusConfirmed, 12),
+	)
+	if err := w.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.take(), []string{"confirmation a 2->4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events after a restart = %q, want %q", got, want)
+	}
+}
+
+type failingStore struct {
+	*MemoryCheckpointStore
+	err error
+}
+
+func (s *failingStore) Save(map[string]*Checkpoint) error {
+	return s.err
+}
+
+func TestWatcherRedeliversUnsavedEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockClient(ctrl)
+	diskFull := errors.New("disk full")
+	store := &failingStore{MemoryCheckpointStore: NewMemoryCheckpointStore(), err: diskFull}
+	rec := &recorder{}
+
+	// Callbacks run before the save, so a failed save after a restart delivers the same events again.
+	for i := 0; i < 2; i++ {
+		w := NewWatcher(c, Config{Store: store, Handlers: rec.handlers()})
+		expectPages(t, c, deposit("a", "USDC", model.DepositStatusConfirmed, 12))
+		if err := w.Poll(); !errors.Is(err, diskFull) {
+			t.Fatalf("Poll() error = %v, want %v", err, diskFull)
+		}
+		if got, want := rec.take(), []string{"seen a", "credited a"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: events = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package funding analyzes perps funding rates, scans for funding carry opportunities and reports funding payments.
+package funding
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// DefaultInterval is the funding interval assumed when it cannot be inferred from history.
+const DefaultInterval = time.Hour
+
+const year = 365 * 24 * time.Hour
+
+var bpsFactor = decimal.NewFromInt(10000)
+
+// Annualize returns rate, paid every interval, as a simple yearly rate.
+func Annualize(rate decimal.Decimal, interval time.Duration) decimal.Decimal {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return rate.Mul(decimal.NewFromInt(int64(year / interval)))
+}
+
+// Interval infers the funding interval of history as the median gap between consecutive points, or returns zero
+// with fewer than two points.
+func Interval(history []*model.FundingRate) time.Duration {
+	times := make([]time.Time, len(history))
+	for i, r := range history {
+		times[i] = r.IntervalEnds
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	var gaps []time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// Point is one realized rate with the moving average ending at it.
+type Point struct {
+	Time    time.Time       `json:"time"`
+	Rate    decimal.Decimal `json:"rate"`
+	Average decimal.Decimal `json:"average"` // Mean of the last window rates, fewer at the start of the history
+}
+
+// MovingAverage returns the points of history in chronological order with their simple moving average over window
+// points.
+func MovingAverage(history []*model.FundingRate, window int) []Point {
+	if window <= 0 {
+		window = 1
+	}
+	sorted := append([]*model.FundingRate(nil), history...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IntervalEnds.Before(sorted[j].IntervalEnds) })
+
+	points := make([]Point, len(sorted))
+	sum := decimal.Zero
+	for i, r := range sorted {
+		sum = sum.Add(r.Rate)
+		if i >= window {
+			sum = sum.Sub(sorted[i-window].Rate)
+		}
+		n := min(i+1, window)
+		points[i] = Point{Time: r.IntervalEnds, Rate: r.Rate, Average: sum.Div(decimal.NewFromInt(int64(n)))}
+	}
+	return points
+}
+
+// PredictionError compares the last estimate of an interval with the rate that was realized.
+type PredictionError struct {
+	Market       string          `json:"market"`
+	IntervalEnds time.Time       `json:"intervalEnds"`
+	Predicted    decimal.Decimal `json:"predicted"`
+	Realized     decimal.Decimal `json:"realized"`
+	Error        decimal.Decimal `json:"error"` // Realized minus predicted
+}
+
+// CompareRealized matches estimates, as returned by GetFundingRates and recorded over time, to the realized rates of
+// the same market and interval. The last estimate given for an interval is used; intervals without estimates are
+// skipped.
+func CompareRealized(predicted, realized []*model.FundingRate) []PredictionError {
+	type key struct {
+		market string
+		ends   int64
+	}
+	last := make(map[key]decimal.Decimal, len(predicted))
+	for _, p := range predicted {
+		last[key{p.Market, p.IntervalEnds.Unix()}] = p.Rate
+	}
+
+	var errs []PredictionError
+	for _, r := range realized {
+		p, ok := last[key{r.Market, r.IntervalEnds.Unix()}]
+		if !ok {
+			continue
+		}
+		errs = append(errs, PredictionError{
+			Market:       r.Market,
+			IntervalEnds: r.IntervalEnds,
+			Predicted:    p,
+			Realized:     r.Rate,
+			Error:        r.Rate.Sub(p),
+		})
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].IntervalEnds.Before(errs[j].IntervalEnds) })
+	return errs
+}
+
+// ErrorStats summarizes prediction errors.
+type ErrorStats struct {
+	Count   int             `json:"count"`
+	Bias    decimal.Decimal `json:"bias"`    // Mean error, positive when estimates were too low
+	MeanAbs decimal.Decimal `json:"meanAbs"` // Mean absolute error
+	RMSE    decimal.Decimal `json:"rmse"`    // Root mean squared error
+}
+
+// SummarizeErrors returns the statistics of errs, or nil when there are none.
+func SummarizeErrors(errs []PredictionError) *ErrorStats {
+	if len(errs) == 0 {
+		return nil
+	}
+	var sum, abs, squares decimal.Decimal
+	for _, e := range errs {
+		sum = sum.Add(e.Error)
+		abs = abs.Add(e.Error.Abs())
+		squares = squares.Add(e.Error.Mul(e.Error))
+	}
+	n := decimal.NewFromInt(int64(len(errs)))
+	mse, _ := squares.Div(n).Float64()
+	return &ErrorStats{
+		Count:   len(errs),
+		Bias:    sum.Div(n),
+		MeanAbs: abs.Div(n),
+		RMSE:    decimal.NewFromFloat(math.Sqrt(mse)),
+	}
+}
+
+// Stats describes the funding of one perps market over a lookback period.
+type Stats struct {
+	Rank              int             `json:"rank"`
+	Market            string          `json:"market"`
+	Interval          time.Duration   `json:"interval"`
+	Current           decimal.Decimal `json:"current"` // Estimate for the running interval
+	CurrentAnnualized decimal.Decimal `json:"currentAnnualized"`
+	Last              decimal.Decimal `json:"last"` // Last realized rate
+	Average           decimal.Decimal `json:"average"`
+	AverageAnnualized decimal.Decimal `json:"averageAnnualized"`
+	MovingAverage     decimal.Decimal `json:"movingAverage"` // Moving average at the last realized rate
+	Samples           int             `json:"samples"`
+	Prediction        *ErrorStats     `json:"prediction,omitempty"` // Only with recorded estimates
+}
+
+// RankBy selects the value markets are ranked by, highest first.
+type RankBy string
+
+const (
+	RankByCurrent   RankBy = "current"   // Current estimate
+	RankByAverage   RankBy = "average"   // Average over the lookback
+	RankByMagnitude RankBy = "magnitude" // Absolute average, for carry in either direction
+)
+
+// AnalyzeOptions configures Analyze.
+type AnalyzeOptions struct {
+	Markets     []string             // Perps markets, defaults to every market with a mark price
+	Lookback    time.Duration        // Period of history, defaults to 7 days
+	Window      int                  // Number of points of the moving average, defaults to 24
+	Predictions []*model.FundingRate // Recorded estimates to compare realized rates with, optional
+	RankBy      RankBy               // Defaults to RankByAverage
+	Now         func() time.Time     // Defaults to time.Now
+}
+
+// Analyze fetches the current estimate and history of each market and returns their statistics, ranked.
+func Analyze(pc client.PerpsClient, opts AnalyzeOptions) ([]*Stats, error) {
+	if opts.Lookback <= 0 {
+		opts.Lookback = 7 * 24 * time.Hour
+	}
+	if opts.Window <= 0 {
+		opts.Window = 24
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	markets, err := perpsMarkets(pc, opts.Markets)
+	if err != nil {
+		return nil, err
+	}
+
+	now := opts.Now()
+	stats := make([]*Stats, 0, len(markets))
+	for _, market := range markets {
+		current, err := pc.GetFundingRates(&api.GetFundingRatesRequest{Market: market})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get funding rate of %s: %w", market, err)
+		}
+		req := &api.GetFundingRateHistoryRequest{Market: market}
+		req.StartMs, req.EndMs = now.Add(-opts.Lookback).UnixMilli(), now.UnixMilli()
+		history, err := pc.GetFundingRateHistory(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get funding history of %s: %w", market, err)
+		}
+		for _, r := range history {
+			if r.Market == "" {
+				r.Market = market
+			}
+		}
+		stats = append(stats, Summarize(market, current, history, opts.Window, opts.Predictions))
+	}
+	return Rank(stats, opts.RankBy), nil
+}
+
+// Summarize computes the statistics of one market from its current estimate, which may be nil, and its history.
+func Summarize(market string, current *model.FundingRate, history []*model.FundingRate, window int, predictions []*model.FundingRate) *Stats {
+	s := &Stats{Market: market, Interval: Interval(history), Samples: len(history)}
+	if s.Interval == 0 {
+		s.Interval = DefaultInterval
+	}
+	if current != nil {
+		s.Current = current.Rate
+		s.CurrentAnnualized = Annualize(current.Rate, s.Interval)
+	}
+
+	points := MovingAverage(history, window)
+	if len(points) > 0 {
+		sum := decimal.Zero
+		for _, p := range points {
+			sum = sum.Add(p.Rate)
+		}
+		last := points[len(points)-1]
+		s.Last, s.MovingAverage = last.Rate, last.Average
+		s.Average = sum.Div(decimal.NewFromInt(int64(len(points))))
+		s.AverageAnnualized = Annualize(s.Average, s.Interval)
+	}
+
+	var own []*model.FundingRate
+	for _, p := range predictions {
+		if p.Market == market {
+			own = append(own, p)
+		}
+	}
+	s.Prediction = SummarizeErrors(CompareRealized(own, history))
+	return s
+}
+
+// Rank sorts stats by the value selected by by, highest first, and numbers them from 1.
+func Rank(stats []*Stats, by RankBy) []*Stats {
+	value := func(s *Stats) decimal.Decimal {
+		switch by {
+		case RankByCurrent:
+			return s.CurrentAnnualized
+		case RankByMagnitude:
+			return s.AverageAnnualized.Abs()
+		default:
+			return s.AverageAnnualized
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return value(stats[i]).GreaterThan(value(stats[j])) })
+	for i, s := range stats {
+		s.Rank = i + 1
+	}
+	return stats
+}
+
+// perpsMarkets returns markets, or every market with a mark price when empty.
+func perpsMarkets(pc client.PerpsClient, markets []string) ([]string, error) {
+	if len(markets) > 0 {
+		return markets, nil
+	}
+	marks, err := pc.GetMarkPrices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mark prices: %w", err)
+	}
+	for m := range marks {
+		markets = append(markets, m)
+	}
+	if len(markets) == 0 {
+		return nil, errors.New("no perps markets")
+	}
+	sort.Strings(markets)
+	return markets, nil
+}
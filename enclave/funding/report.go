@@ -0,0 +1,240 @@
+package funding
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// DefaultPageSize is the number of funding fees requested per page.
+const DefaultPageSize = 100
+
+// FetchFees returns every funding fee of market paid between start and end, following pages until the last one.
+// Zero times leave the range open.
+func FetchFees(pc client.PerpsClient, market string, start, end time.Time, pageSize int) ([]*model.FundingFee, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	req := &api.GetFundingFeesRequest{Market: market}
+	req.Limit = pageSize
+	if !start.IsZero() {
+		req.StartMs = start.UnixMilli()
+	}
+	if !end.IsZero() {
+		req.EndMs = end.UnixMilli()
+	}
+
+	var res []*model.FundingFee
+	for {
+		resp, err := pc.GetFundingFees(req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.FundingFees...)
+		if resp.PageInfo.NextCursor == "" || resp.PageInfo.NextCursor == req.Cursor || len(resp.FundingFees) == 0 {
+			return res, nil
+		}
+		req.Cursor = resp.PageInfo.NextCursor
+	}
+}
+
+// Payment is one funding fee joined to the position it was charged on.
+type Payment struct {
+	Market       string                  `json:"market"`
+	Time         time.Time               `json:"time"`
+	Direction    model.PositionDirection `json:"direction"` // Direction of the position
+	PositionSize decimal.Decimal         `json:"positionSize"`
+	MarkPrice    decimal.Decimal         `json:"markPrice"`
+	Notional     decimal.Decimal         `json:"notional"` // Position size times mark price
+	Rate         decimal.Decimal         `json:"rate"`
+	Amount       decimal.Decimal         `json:"amount"` // Positive if earned, negative if paid
+}
+
+// Summary aggregates the payments of one market and position direction.
+type Summary struct {
+	Market    string                  `json:"market"`
+	Direction model.PositionDirection `json:"direction"`
+	Payments  int                     `json:"payments"`
+	Paid      decimal.Decimal         `json:"paid"`        // Sum of the amounts paid, positive
+	Earned    decimal.Decimal         `json:"earned"`      // Sum of the amounts earned
+	Net       decimal.Decimal         `json:"net"`         // Earned less paid
+	Notional  decimal.Decimal         `json:"avgNotional"` // Average notional of the positions charged
+}
+
+// Report lists the funding payments of a period with their aggregates.
+type Report struct {
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`
+	Payments  []*Payment `json:"payments"`
+	Summaries []*Summary `json:"summaries"`
+	Total     *Summary   `json:"total"` // Every market and direction
+}
+
+// ReportOptions configures BuildReport.
+type ReportOptions struct {
+	Markets  []string  // Perps markets, defaults to every market with a mark price
+	Start    time.Time // Zero for no lower bound
+	End      time.Time // Defaults to now
+	PageSize int       // Defaults to DefaultPageSize
+}
+
+// BuildReport fetches the funding fees of every market for the period and aggregates them.
+func BuildReport(pc client.PerpsClient, opts ReportOptions) (*Report, error) {
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	markets, err := perpsMarkets(pc, opts.Markets)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees []*model.FundingFee
+	for _, market := range markets {
+		page, err := FetchFees(pc, market, opts.Start, opts.End, opts.PageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get funding fees of %s: %w", market, err)
+		}
+		for _, f := range page {
+			if f.Market == "" {
+				f.Market = market
+			}
+		}
+		fees = append(fees, page...)
+	}
+	return NewReport(fees, opts.Start, opts.End), nil
+}
+
+// NewReport builds a Report from fees, sorting payments by time and summaries by market and direction.
+func NewReport(fees []*model.FundingFee, start, end time.Time) *Report {
+	r := &Report{Start: start, End: end, Total: &Summary{}}
+	type key struct {
+		market    string
+		direction model.PositionDirection
+	}
+	summaries := map[key]*Summary{}
+	for _, f := range fees {
+		p := &Payment{
+			Market:       f.Market,
+			Time:         f.Time,
+			Direction:    f.PositionDirection,
+			PositionSize: f.PositionSize,
+			MarkPrice:    f.MarkPrice,
+			Notional:     f.PositionSize.Abs().Mul(f.MarkPrice),
+			Rate:         f.Rate,
+			Amount:       f.Amount,
+		}
+		r.Payments = append(r.Payments, p)
+
+		k := key{p.Market, p.Direction}
+		s, ok := summaries[k]
+		if !ok {
+			s = &Summary{Market: p.Market, Direction: p.Direction}
+			summaries[k] = s
+			r.Summaries = append(r.Summaries, s)
+		}
+		s.add(p)
+		r.Total.add(p)
+	}
+
+	r.Total.average()
+	for _, s := range r.Summaries {
+		s.average()
+	}
+	sort.SliceStable(r.Payments, func(i, j int) bool { return r.Payments[i].Time.Before(r.Payments[j].Time) })
+	sort.Slice(r.Summaries, func(i, j int) bool {
+		a, b := r.Summaries[i], r.Summaries[j]
+		if a.Market != b.Market {
+			return a.Market < b.Market
+		}
+		return a.Direction < b.Direction
+	})
+	return r
+}
+
+// add accumulates p; Notional holds the sum until NewReport averages it.
+func (s *Summary) add(p *Payment) {
+	s.Payments++
+	if p.Amount.IsNegative() {
+		s.Paid = s.Paid.Add(p.Amount.Neg())
+	} else {
+		s.Earned = s.Earned.Add(p.Amount)
+	}
+	s.Net = s.Net.Add(p.Amount)
+	s.Notional = s.Notional.Add(p.Notional)
+}
+
+func (s *Summary) average() {
+	if s.Payments > 0 {
+		s.Notional = s.Notional.Div(decimal.NewFromInt(int64(s.Payments)))
+	}
+}
+
+var (
+	paymentHeader = []string{"time", "market", "direction", "position_size", "mark_price", "notional", "rate", "amount"}
+	summaryHeader = []string{"market", "direction", "payments", "paid", "earned", "net", "avg_notional"}
+)
+
+// WritePaymentsCSV writes one row per payment of r.
+func WritePaymentsCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(paymentHeader); err != nil {
+		return err
+	}
+
+	for _, p := range r.Payments {
+		row := []string{
+			p.Time.UTC().Format(time.RFC3339),
+			p.Market,
+			string(p.Direction),
+			p.PositionSize.String(),
+			p.MarkPrice.String(),
+			p.Notional.StringFixed(8),
+			p.Rate.String(),
+			p.Amount.StringFixed(8),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummariesCSV writes one row per market and direction of r, followed by a total row.
+func WriteSummariesCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(summaryHeader); err != nil {
+		return err
+	}
+
+	total := *r.Total
+	total.Market = "total"
+	rows := append(append([]*Summary(nil), r.Summaries...), &total)
+	for _, s := range rows {
+		row := []string{
+			s.Market,
+			string(s.Direction),
+			strconv.Itoa(s.Payments),
+			s.Paid.StringFixed(8),
+			s.Earned.StringFixed(8),
+			s.Net.StringFixed(8),
+			s.Notional.StringFixed(8),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,137 @@
+package funding
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fee(market string, direction model.PositionDirection, size, mark, rate, amount string, hour int) *model.FundingFee {
+	return &model.FundingFee{
+		Market:            market,
+		PositionDirection: direction,
+		PositionSize:      d(size),
+		MarkPrice:         d(mark),
+		Rate:              d(rate),
+		Amount:            d(amount),
+		Time:              start.Add(time.Duration(hour) * time.Hour),
+	}
+}
+
+func testReport() *Report {
+	return NewReport([]*model.FundingFee{
+		fee("BTC-USD.P", model.PositionDirectionLong, "0.5", "40000", "0.0001", "-2", 2),
+		fee("AVAX-USD.P", model.PositionDirectionShort, "-10", "20", "0.0001", "0.02", 1),
+		fee("BTC-USD.P", model.PositionDirectionLong, "1", "42000", "-0.00005", "2.1", 3),
+		fee("AVAX-USD.P", model.PositionDirectionLong, "10", "21", "0.0001", "-0.021", 0),
+	}, start, start.Add(24*time.Hour))
+}
+
+func TestNewReport(t *testing.T) {
+	r := testReport()
+
+	for i, p := range r.Payments {
+		if want := start.Add(time.Duration(i) * time.Hour); !p.Time.Equal(want) {
+			t.Fatalf("payment %d at %s, want %s", i, p.Time, want)
+		}
+	}
+
+	tests := []struct {
+		summary   *Summary
+		market    string
+		direction model.PositionDirection
+		payments  int
+		paid      string
+		earned    string
+		net       string
+		notional  string
+	}{
+		{r.Summaries[0], "AVAX-USD.P", model.PositionDirectionLong, 1, "0.021", "0", "-0.021", "210"},
+		{r.Summaries[1], "AVAX-USD.P", model.PositionDirectionShort, 1, "0", "0.02", "0.02", "200"},
+		{r.Summaries[2], "BTC-USD.P", model.PositionDirectionLong, 2, "2", "2.1", "0.1", "31000"},
+		{r.Total, "", "", 4, "2.021", "2.12", "0.099", "15602.5"},
+	}
+	if len(r.Summaries) != 3 {
+		t.Fatalf("got %d summaries, want 3", len(r.Summaries))
+	}
+	for _, tt := range tests {
+		s := tt.summary
+		if s.Market != tt.market || s.Direction != tt.direction || s.Payments != tt.payments {
+			t.Errorf("summary = %+v, want %d payments of %s %s", s, tt.payments, tt.market, tt.direction)
+			continue
+		}
+		if !s.Paid.Equal(d(tt.paid)) || !s.Earned.Equal(d(tt.earned)) || !s.Net.Equal(d(tt.net)) || !s.Notional.Equal(d(tt.notional)) {
+			t.Errorf("%s %s: paid %s earned %s net %s notional %s, want %s %s %s %s", s.Market, s.Direction,
+				s.Paid, s.Earned, s.Net, s.Notional, tt.paid, tt.earned, tt.net, tt.notional)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(*strings.Builder, *Report) error
+		r     *Report
+		want  string
+	}{
+		{
+			name:  "payments",
+			write: func(b *strings.Builder, r *Report) error { return WritePaymentsCSV(b, r) },
+			r:     testReport(),
+			want: `time,market,direction,position_size,mark_price,notional,rate,amount
+2024-01-01T00:00:00Z,AVAX-USD.P,long,10,21,210.00000000,0.0001,-0.02100000
+2024-01-01T01:00:00Z,AVAX-USD.P,short,-10,20,200.00000000,0.0001,0.02000000
+2024-01-01T02:00:00Z,BTC-USD.P,long,0.5,40000,20000.00000000,0.0001,-2.00000000
+2024-01-01T03:00:00Z,BTC-USD.P,long,1,42000,42000.00000000,-0.00005,2.10000000
+`,
+		},
+		{
+			name:  "summaries",
+			write: func(b *strings.Builder, r *Report) error { return WriteSummariesCSV(b, r) },
+			r:     testReport(),
+			want: `market,direction,payments,paid,earned,net,avg_notional
+AVAX-USD.P,long,1,0.02100000,0.00000000,-0.02100000,210.00000000
+AVAX-USD.P,short,1,0.00000000,0.02000000,0.02000000,200.00000000
+BTC-USD.P,long,2,2.00000000,2.10000000,0.10000000,31000.00000000
+total,,4,2.02100000,2.12000000,0.09900000,15602.50000000
+`,
+		},
+		{
+			name:  "empty summaries",
+			write: func(b *strings.Builder, r *Report) error { return WriteSummariesCSV(b, r) },
+			r:     NewReport(nil, start, start),
+			want: `market,direction,payments,paid,earned,net,avg_notional
+total,,0,0.00000000,0.00000000,0.00000000,0.00000000
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.write(&b, tt.r); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+
+	// Writing the total row must not rename the report's total.
+	r := testReport()
+	if err := WriteSummariesCSV(&strings.Builder{}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Total.Market != "" {
+		t.Fatalf("total market = %q after writing, want empty", r.Total.Market)
+	}
+}
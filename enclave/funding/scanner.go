@@ -0,0 +1,162 @@
+package funding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+// ScanConfig configures Scan. Fees are fractions of the notional of each fill.
+type ScanConfig struct {
+	Markets    []string         // Perps markets, defaults to every market with a mark price
+	SpotQuotes []string         // Quote coins tried, in order, when the perps quote has no spot market; defaults to USDC
+	Horizon    time.Duration    // Holding period the carry is estimated over, at least one interval; defaults to 24 hours
+	Interval   time.Duration    // Funding interval, defaults to DefaultInterval
+	PerpsFee   decimal.Decimal  // Fee of each perps fill
+	SpotFee    decimal.Decimal  // Fee of each spot fill
+	MinNetBps  *decimal.Decimal // Opportunities netting less are dropped; nil keeps every market with a spot match
+}
+
+// Opportunity is the estimated carry of holding a perps position hedged by the opposite spot position.
+// When funding is positive the perps leg is short, earning funding, and the spot leg long; when negative the perps
+// leg is long and the spot leg short, which requires borrowing the base coin.
+type Opportunity struct {
+	Market     string                  `json:"market"`
+	SpotMarket string                  `json:"spotMarket"`
+	Perps      model.PositionDirection `json:"perps"` // Direction of the perps leg
+	Mark       decimal.Decimal         `json:"mark"`
+	SpotMid    decimal.Decimal         `json:"spotMid"`
+	BasisBps   decimal.Decimal         `json:"basisBps"` // Mark premium over the spot mid
+	Rate       decimal.Decimal         `json:"rate"`     // Current funding estimate
+	Annualized decimal.Decimal         `json:"annualized"`
+	FundingBps decimal.Decimal         `json:"fundingBps"` // Funding collected over the horizon at the current rate
+	FeesBps    decimal.Decimal         `json:"feesBps"`    // Opening and closing both legs
+	NetBps     decimal.Decimal         `json:"netBps"`     // Funding plus basis convergence less fees
+	NetAPR     decimal.Decimal         `json:"netApr"`     // NetBps as a yearly fraction
+}
+
+// Scan compares the mark price of each perps market with the mid of its spot market and estimates the carry of the
+// hedged position over the horizon, assuming the basis converges to zero. Results are sorted by NetBps, best first.
+func Scan(c client.Client, cfg ScanConfig) ([]*Opportunity, error) {
+	if cfg.Horizon <= 0 {
+		cfg.Horizon = 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	// Funding is only collected for completed intervals.
+	if cfg.Horizon < cfg.Interval {
+		return nil, fmt.Errorf("horizon %s is shorter than the funding interval %s", cfg.Horizon, cfg.Interval)
+	}
+	if len(cfg.SpotQuotes) == 0 {
+		cfg.SpotQuotes = []string{"USDC"}
+	}
+	pc := c.PerpsClient()
+
+	marks, err := pc.GetMarkPrices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mark prices: %w", err)
+	}
+	markets := cfg.Markets
+	if len(markets) == 0 {
+		for m := range marks {
+			markets = append(markets, m)
+		}
+		sort.Strings(markets)
+	}
+	info, err := c.GetMarkets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get markets: %w", err)
+	}
+	spots := spotMarkets(info)
+
+	intervals := decimal.NewFromInt(int64(cfg.Horizon / cfg.Interval))
+	fees := cfg.PerpsFee.Add(cfg.SpotFee).Mul(decimal.NewFromInt(2)).Mul(bpsFactor)
+	var res []*Opportunity
+	for _, market := range markets {
+		mark, ok := marks[market]
+		if !ok || !mark.Price.IsPositive() {
+			continue
+		}
+		spot, ok := matchSpot(market, cfg.SpotQuotes, spots)
+		if !ok {
+			continue
+		}
+		book, err := c.SpotClient().GetDepth(&api.GetDepthRequest{Market: spot, Depth: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get depth of %s: %w", spot, err)
+		}
+		mid, ok := book.Mid()
+		if !ok || !mid.IsPositive() {
+			continue
+		}
+		rate, err := pc.GetFundingRates(&api.GetFundingRatesRequest{Market: market})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get funding rate of %s: %w", market, err)
+		}
+
+		o := &Opportunity{
+			Market:     market,
+			SpotMarket: spot,
+			Perps:      model.PositionDirectionShort,
+			Mark:       mark.Price,
+			SpotMid:    mid,
+			BasisBps:   mark.Price.Sub(mid).Div(mid).Mul(bpsFactor),
+			Rate:       rate.Rate,
+			Annualized: Annualize(rate.Rate, cfg.Interval),
+			FeesBps:    fees,
+		}
+		// Shorts receive positive funding and gain when a premium converges; longs the opposite.
+		sign := decimal.NewFromInt(1)
+		if rate.Rate.IsNegative() {
+			o.Perps, sign = model.PositionDirectionLong, sign.Neg()
+		}
+		o.FundingBps = rate.Rate.Abs().Mul(intervals).Mul(bpsFactor)
+		o.NetBps = o.FundingBps.Add(o.BasisBps.Mul(sign)).Sub(fees)
+		o.NetAPR = o.NetBps.Div(bpsFactor).Mul(decimal.NewFromInt(int64(year / cfg.Horizon)))
+		o.BasisBps, o.NetBps, o.NetAPR = o.BasisBps.Round(2), o.NetBps.Round(2), o.NetAPR.Round(4)
+		if cfg.MinNetBps != nil && o.NetBps.LessThan(*cfg.MinNetBps) {
+			continue
+		}
+		res = append(res, o)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].NetBps.GreaterThan(res[j].NetBps) })
+	return res, nil
+}
+
+// spotMarkets returns the enabled spot markets of info.
+func spotMarkets(info *model.Market) map[string]bool {
+	res := map[string]bool{}
+	if info.Spot == nil {
+		return res
+	}
+	for _, tp := range info.Spot.TradingPairs {
+		if tp.Pair != nil && !tp.Disabled {
+			res[util.NewTradingPair(tp.Pair.Base, tp.Pair.Quote)] = true
+		}
+	}
+	return res
+}
+
+// matchSpot returns the spot market of the base coin of a perps market such as "AVAX-USD.P", quoted in the perps
+// quote coin or else the first of quotes listed.
+func matchSpot(market string, quotes []string, spots map[string]bool) (string, bool) {
+	base, quote, ok := strings.Cut(strings.TrimSuffix(market, ".P"), "-")
+	if !ok {
+		return "", false
+	}
+	for _, q := range append([]string{quote}, quotes...) {
+		if spot := util.NewTradingPair(base, q); spots[spot] {
+			return spot, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,94 @@
+package funding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestScan(t *testing.T) {
+	zero := decimal.Zero
+	tests := []struct {
+		name      string
+		horizon   time.Duration
+		mark      string
+		minNetBps *decimal.Decimal
+		wantErr   bool
+		wantNet   []string
+	}{
+		{
+			name:    "premium converges in favor of the short",
+			horizon: 24 * time.Hour,
+			mark:    "101",
+			wantNet: []string{"124"},
+		},
+		{
+			name:    "negative carry is kept without a minimum",
+			horizon: 24 * time.Hour,
+			mark:    "98",
+			wantNet: []string{"-176"},
+		},
+		{
+			name:      "zero minimum drops negative carry",
+			horizon:   24 * time.Hour,
+			mark:      "98",
+			minNetBps: &zero,
+		},
+		{
+			name:    "partial intervals are not collected",
+			horizon: 150 * time.Minute,
+			mark:    "100",
+			wantNet: []string{"2"},
+		},
+		{
+			name:    "horizon shorter than the interval",
+			horizon: 30 * time.Minute,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockClient(ctrl)
+			if !tt.wantErr {
+				pc := mocks.NewMockPerpsClient(ctrl)
+				sc := mocks.NewMockSpotClient(ctrl)
+				c.EXPECT().PerpsClient().Return(pc).AnyTimes()
+				c.EXPECT().SpotClient().Return(sc).AnyTimes()
+				pc.EXPECT().GetMarkPrices().Return(map[string]*model.MarkPrice{
+					"AVAX-USD.P": {Pair: "AVAX-USD.P", Price: decimal.RequireFromString(tt.mark)},
+				}, nil)
+				c.EXPECT().GetMarkets().Return(&model.Market{Spot: &model.SpotMarkets{
+					TradingPairs: []*model.V1SpotMarketsResult{{Pair: &model.CurrencyPair{Base: "AVAX", Quote: "USDC"}}},
+				}}, nil)
+				sc.EXPECT().GetDepth(gomock.Any()).Return(&model.OrderBook{
+					Bids: [][]decimal.Decimal{{decimal.RequireFromString("99.5"), decimal.NewFromInt(1)}},
+					Asks: [][]decimal.Decimal{{decimal.RequireFromString("100.5"), decimal.NewFromInt(1)}},
+				}, nil)
+				pc.EXPECT().GetFundingRates(gomock.Any()).Return(&model.FundingRate{Rate: decimal.RequireFromString("0.0001")}, nil)
+			}
+
+			res, err := Scan(c, ScanConfig{Horizon: tt.horizon, Interval: time.Hour, MinNetBps: tt.minNetBps})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(res) != len(tt.wantNet) {
+				t.Fatalf("Scan() returned %d opportunities, want %d", len(res), len(tt.wantNet))
+			}
+			for i, o := range res {
+				if want := decimal.RequireFromString(tt.wantNet[i]); !o.NetBps.Equal(want) {
+					t.Errorf("NetBps = %s, want %s", o.NetBps, want)
+				}
+				if o.Perps != model.PositionDirectionShort || o.SpotMarket != "AVAX-USDC" {
+					t.Errorf("opportunity = %+v, want a short hedged on AVAX-USDC", o)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package ledger
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+type EntryKind string
+
+const (
+	EntryDeposit     EntryKind = "deposit"
+	EntryWithdrawal  EntryKind = "withdrawal"
+	EntryTransferIn  EntryKind = "transferIn"
+	EntryTransferOut EntryKind = "transferOut"
+	EntryTrade       EntryKind = "trade"
+	EntryFee         EntryKind = "fee"
+	EntryFunding     EntryKind = "funding"
+	EntryAdjustment  EntryKind = "adjustment"
+)
+
+// Key identifies a single journal: one asset held in one wallet.
+type Key struct {
+	Asset  string       `json:"asset"`  // Asset symbol, e.g., "USDC"
+	Wallet model.Wallet `json:"wallet"` // Wallet holding the asset, e.g., "main" or "margin"
+}
+
+// Entry is a single signed cash flow in a journal.
+type Entry struct {
+	Time    time.Time       `json:"time"`             // Time the cash flow happened
+	Kind    EntryKind       `json:"kind"`             // Source of the cash flow
+	Asset   string          `json:"asset"`            // Asset symbol, e.g., "USDC"
+	Wallet  model.Wallet    `json:"wallet"`           // Wallet the cash flow was booked to
+	Amount  decimal.Decimal `json:"amount"`           // Signed amount, positive for credits and negative for debits
+	Balance decimal.Decimal `json:"balance"`          // Running balance of the journal after this entry
+	Ref     string          `json:"ref"`              // Transaction, transfer or fill ID of the source record
+	Market  string          `json:"market,omitempty"` // Market of the fill or funding fee, if any
+	Note    string          `json:"note,omitempty"`   // Free-form note for adjustments
+}
+
+// Ledger merges deposits, withdrawals, transfers, spot fills and funding fees into time-ordered journals.
+type Ledger struct {
+	accountID string
+	entries   []*Entry
+	feeAsset  func(f *model.Fill, base, quote string) string
+}
+
+// New initializes an empty Ledger.
+// When accountID is set, transfer legs belonging to other accounts are ignored.
+func New(accountID string) *Ledger {
+	return &Ledger{accountID: accountID}
+}
+
+// SetFeeAsset sets the function returning the asset the fee of a spot fill was charged in. Fills do not report it,
+// so fees are booked in the quote asset by default; venues charging e.g. buys in the base asset need fn.
+// It applies to fills added afterwards.
+func (l *Ledger) SetFeeAsset(fn func(f *model.Fill, base, quote string) string) {
+	l.feeAsset = fn
+}
+
+// AddDeposits books confirmed deposits as credits to the main wallet.
+func (l *Ledger) AddDeposits(deposits []*model.Deposit) {
+	for _, d := range deposits {
+		if d.Status != model.DepositStatusConfirmed {
+			continue
+		}
+		l.add(&Entry{
+			Time:   d.Time,
+			Kind:   EntryDeposit,
+			Asset:  d.Coin,
+			Wallet: model.WalletMain,
+			Amount: d.Size,
+			Ref:    d.TxID,
+		})
+	}
+}
+
+// AddWithdrawals books withdrawals that did not fail as debits to the main wallet.
+func (l *Ledger) AddWithdrawals(withdrawals []*model.Withdrawal) {
+	for _, w := range withdrawals {
+		if w.Status == model.WithdrawalStatusFailed {
+			continue
+		}
+		l.add(&Entry{
+			Time:   w.Time,
+			Kind:   EntryWithdrawal,
+			Asset:  w.Coin,
+			Wallet: model.WalletMain,
+			Amount: w.Size.Neg(),
+			Ref:    w.WithdrawalID,
+		})
+	}
+}
+
+// AddTransfers books both legs of each transfer.
+func (l *Ledger) AddTransfers(transfers []*model.Transfer) {
+	for _, t := range transfers {
+		if t.From != nil && l.owns(t.From) {
+			l.add(&Entry{
+				Time:   t.Time,
+				Kind:   EntryTransferOut,
+				Asset:  t.Symbol,
+				Wallet: t.From.Wallet,
+				Amount: t.Amount.Abs().Neg(),
+				Ref:    t.ID,
+			})
+		}
+		if t.To != nil && l.owns(t.To) {
+			l.add(&Entry{
+				Time:   t.Time,
+				Kind:   EntryTransferIn,
+				Asset:  t.Symbol,
+				Wallet: t.To.Wallet,
+				Amount: t.Amount.Abs(),
+				Ref:    t.ID,
+			})
+		}
+	}
+}
+
+// AddFills books spot fills against the main wallet.
+// The base asset moves by the fill size, the quote asset by the filled cost, and the fee is charged in the quote asset
+// unless SetFeeAsset says otherwise.
+// Fills in perps markets are skipped since they settle through realized PnL rather than asset movements.
+func (l *Ledger) AddFills(fills []*model.Fill) {
+	for _, f := range fills {
+		base, quote, ok := util.SplitTradingPair(f.Market)
+		if !ok {
+			continue
+		}
+
+		baseAmount, quoteAmount := f.Size, f.FilledCost.Neg()
+		if f.Side == model.OrderSideSell {
+			baseAmount, quoteAmount = f.Size.Neg(), f.FilledCost
+		}
+
+		l.add(&Entry{
+			Time:   f.Time,
+			Kind:   EntryTrade,
+			Asset:  base,
+			Wallet: model.WalletMain,
+			Amount: baseAmount,
+			Ref:    f.ID,
+			Market: f.Market,
+		})
+		l.add(&Entry{
+			Time:   f.Time,
+			Kind:   EntryTrade,
+			Asset:  quote,
+			Wallet: model.WalletMain,
+			Amount: quoteAmount,
+			Ref:    f.ID,
+			Market: f.Market,
+		})
+		if !f.Fee.IsZero() {
+			l.add(&Entry{
+				Time:   f.Time,
+				Kind:   EntryFee,
+				Asset:  l.feeAssetOf(f, base, quote),
+				Wallet: model.WalletMain,
+				Amount: f.Fee.Neg(),
+				Ref:    f.ID,
+				Market: f.Market,
+			})
+		}
+	}
+}
+
+// AddFundingFees books perps funding payments against the USDC margin wallet.
+func (l *Ledger) AddFundingFees(fees []*model.FundingFee) {
+	for _, f := range fees {
+		l.add(&Entry{
+			Time:   f.Time,
+			Kind:   EntryFunding,
+			Asset:  "USDC",
+			Wallet: model.WalletMargin,
+			Amount: f.Amount,
+			Ref:    f.Market + "@" + f.Time.Format(time.RFC3339Nano),
+			Market: f.Market,
+		})
+	}
+}
+
+// AddAdjustment books a manual entry, e.g., an opening balance or realized perps PnL.
+func (l *Ledger) AddAdjustment(asset string, wallet model.Wallet, amount decimal.Decimal, at time.Time, note string) {
+	l.add(&Entry{
+		Time:   at,
+		Kind:   EntryAdjustment,
+		Asset:  asset,
+		Wallet: wallet,
+		Amount: amount,
+		Note:   note,
+	})
+}
+
+// Keys returns the asset and wallet pairs that have at least one entry, sorted by asset then wallet.
+func (l *Ledger) Keys() []Key {
+	seen := make(map[Key]struct{})
+	var keys []Key
+	for _, e := range l.entries {
+		k := Key{Asset: e.Asset, Wallet: e.Wallet}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Asset != keys[j].Asset {
+			return keys[i].Asset < keys[j].Asset
+		}
+		return keys[i].Wallet < keys[j].Wallet
+	})
+	return keys
+}
+
+// Journal returns the time-ordered entries for an asset and wallet with running balances filled in.
+func (l *Ledger) Journal(asset string, wallet model.Wallet) []*Entry {
+	asset = strings.ToUpper(asset)
+
+	var journal []*Entry
+	for _, e := range l.entries {
+		if e.Asset == asset && e.Wallet == wallet {
+			journal = append(journal, e)
+		}
+	}
+	sort.SliceStable(journal, func(i, j int) bool {
+		return journal[i].Time.Before(journal[j].Time)
+	})
+
+	balance := decimal.Zero
+	for _, e := range journal {
+		balance = balance.Add(e.Amount)
+		e.Balance = balance
+	}
+	return journal
+}
+
+// Balance returns the ending balance of an asset in a wallet.
+func (l *Ledger) Balance(asset string, wallet model.Wallet) decimal.Decimal {
+	asset = strings.ToUpper(asset)
+
+	balance := decimal.Zero
+	for _, e := range l.entries {
+		if e.Asset == asset && e.Wallet == wallet {
+			balance = balance.Add(e.Amount)
+		}
+	}
+	return balance
+}
+
+func (l *Ledger) add(e *Entry) {
+	e.Asset = strings.ToUpper(e.Asset)
+	l.entries = append(l.entries, e)
+}
+
+// feeAssetOf returns the asset the fee of f was charged in.
+func (l *Ledger) feeAssetOf(f *model.Fill, base, quote string) string {
+	if l.feeAsset == nil {
+		return quote
+	}
+	return l.feeAsset(f, base, quote)
+}
+
+func (l *Ledger) owns(key *model.AccountWalletKey) bool {
+	return l.accountID == "" || key.ID == "" || key.ID == l.accountID
+}
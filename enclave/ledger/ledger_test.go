@@ -0,0 +1,61 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestAddFillsFeeAsset(t *testing.T) {
+	fills := []*model.Fill{
+		{ID: "b", Market: "AVAX-USDC", Side: model.OrderSideBuy, Size: decimal.NewFromInt(2), FilledCost: decimal.NewFromInt(40), Fee: decimal.RequireFromString("0.002")},
+		{ID: "s", Market: "AVAX-USDC", Side: model.OrderSideSell, Size: decimal.NewFromInt(1), FilledCost: decimal.NewFromInt(21), Fee: decimal.RequireFromString("0.021")},
+		{ID: "p", Market: "AVAX-USD.P", Side: model.OrderSideBuy, Size: decimal.NewFromInt(5), FilledCost: decimal.NewFromInt(100), Fee: decimal.NewFromInt(1)},
+	}
+	// Buys pay their fee in the asset received.
+	received := func(f *model.Fill, base, quote string) string {
+		if f.Side == model.OrderSideBuy {
+			return base
+		}
+		return quote
+	}
+
+	tests := []struct {
+		name     string
+		feeAsset func(f *model.Fill, base, quote string) string
+		wantAVAX string
+		wantUSDC string
+		wantFees int // Number of fee entries
+	}{
+		{name: "quote asset by default", wantAVAX: "1", wantUSDC: "-19.023", wantFees: 2},
+		{name: "asset received", feeAsset: received, wantAVAX: "0.998", wantUSDC: "-19.021", wantFees: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New("")
+			l.SetFeeAsset(tt.feeAsset)
+			l.AddFills(fills)
+
+			if got := l.Balance("AVAX", model.WalletMain); !got.Equal(decimal.RequireFromString(tt.wantAVAX)) {
+				t.Errorf("AVAX balance = %s, want %s", got, tt.wantAVAX)
+			}
+			if got := l.Balance("USDC", model.WalletMain); !got.Equal(decimal.RequireFromString(tt.wantUSDC)) {
+				t.Errorf("USDC balance = %s, want %s", got, tt.wantUSDC)
+			}
+			fees := 0
+			for _, k := range l.Keys() {
+				for _, e := range l.Journal(k.Asset, k.Wallet) {
+					if e.Kind == EntryFee {
+						fees++
+					}
+				}
+			}
+			if fees != tt.wantFees {
+				t.Errorf("got %d fee entries, want %d; perps fills are skipped", fees, tt.wantFees)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/funding"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Discrepancy describes a journal whose ending balance differs from the balance reported by the exchange.
+type Discrepancy struct {
+	Asset      string          `json:"asset"`      // Asset symbol, e.g., "USDC"
+	Wallet     model.Wallet    `json:"wallet"`     // Wallet holding the asset
+	Ledger     decimal.Decimal `json:"ledger"`     // Ending balance reconstructed from cash flows
+	Reported   decimal.Decimal `json:"reported"`   // Balance reported by the exchange
+	Difference decimal.Decimal `json:"difference"` // Reported minus ledger balance
+}
+
+// Reconcile compares the ending balances against main wallet asset balances and, if given, the margin wallet balance.
+// The ledger does not journal perps realized PnL nor perps trading fees, which settle in the margin wallet, so margin
+// should only be given once they are booked with AddAdjustment; otherwise pass nil to leave the margin wallet out.
+// Differences whose absolute value does not exceed tolerance are not reported.
+func (l *Ledger) Reconcile(balances []*model.AssetBalance, margin *model.Balance, tolerance decimal.Decimal) ([]*Discrepancy, error) {
+	reported := make(map[Key]decimal.Decimal)
+	for _, b := range balances {
+		total, err := decimal.NewFromString(b.TotalBalance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s total balance: %w", b.Symbol, err)
+		}
+		reported[Key{Asset: strings.ToUpper(b.Symbol), Wallet: model.WalletMain}] = total
+	}
+	if margin != nil {
+		reported[Key{Asset: "USDC", Wallet: model.WalletMargin}] = margin.WalletBalance
+	}
+
+	keys := l.Keys()
+	known := make(map[Key]struct{}, len(keys))
+	for _, k := range keys {
+		known[k] = struct{}{}
+	}
+	for k := range reported {
+		if _, ok := known[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	var discrepancies []*Discrepancy
+	for _, k := range keys {
+		rep, ok := reported[k]
+		if !ok {
+			continue
+		}
+		bal := l.Balance(k.Asset, k.Wallet)
+		diff := rep.Sub(bal)
+		if diff.Abs().LessThanOrEqual(tolerance) {
+			continue
+		}
+		discrepancies = append(discrepancies, &Discrepancy{
+			Asset:      k.Asset,
+			Wallet:     k.Wallet,
+			Ledger:     bal,
+			Reported:   rep,
+			Difference: diff,
+		})
+	}
+	return discrepancies, nil
+}
+
+// Load builds a Ledger from every deposit, withdrawal, transfer, spot fill and perps funding fee available through the
+// client, following the pages of each source until the last one. Funding fees are fetched for every perps market with
+// a mark price.
+func Load(c client.Client, accountID string) (*Ledger, error) {
+	l := New(accountID)
+
+	deposits, err := fetchAll(func(cursor string) ([]*model.Deposit, api.PageInfo, error) {
+		req := &api.GetDepositsRequest{}
+		req.Cursor = cursor
+		resp, err := c.GetDepositsPage(req)
+		if err != nil {
+			return nil, api.PageInfo{}, err
+		}
+		return resp.Deposits, resp.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.AddDeposits(deposits)
+
+	withdrawals, err := fetchAll(func(cursor string) ([]*model.Withdrawal, api.PageInfo, error) {
+		req := &api.GetWithdrawalsRequest{}
+		req.Cursor = cursor
+		resp, err := c.GetWithdrawalsPage(req)
+		if err != nil {
+			return nil, api.PageInfo{}, err
+		}
+		return resp.Withdrawals, resp.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.AddWithdrawals(withdrawals)
+
+	// Without a limit, GetTransferHistory follows every page itself.
+	transfers, err := c.GetTransferHistory(&api.GetTransferHistoryRequest{})
+	if err != nil {
+		return nil, err
+	}
+	l.AddTransfers(transfers.Transfers)
+
+	fills, err := fetchAll(func(cursor string) ([]*model.Fill, api.PageInfo, error) {
+		req := &api.GetFillsRequest{}
+		req.Cursor = cursor
+		resp, err := c.SpotClient().GetFillsPage(req)
+		if err != nil {
+			return nil, api.PageInfo{}, err
+		}
+		return resp.Fills, resp.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.AddFills(fills)
+
+	marks, err := c.PerpsClient().GetMarkPrices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mark prices: %w", err)
+	}
+	markets := make([]string, 0, len(marks))
+	for m := range marks {
+		markets = append(markets, m)
+	}
+	sort.Strings(markets)
+	for _, market := range markets {
+		fees, err := funding.FetchFees(c.PerpsClient(), market, time.Time{}, time.Time{}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get funding fees of %s: %w", market, err)
+		}
+		for _, f := range fees {
+			if f.Market == "" {
+				f.Market = market
+			}
+		}
+		l.AddFundingFees(fees)
+	}
+
+	return l, nil
+}
+
+// fetchAll calls page with the cursor of each page until the last one and returns the items of every page.
+func fetchAll[T any](page func(cursor string) ([]T, api.PageInfo, error)) ([]T, error) {
+	var res []T
+	cursor := ""
+	for {
+		items, info, err := page(cursor)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, items...)
+		if info.NextCursor == "" || info.NextCursor == cursor || len(items) == 0 {
+			return res, nil
+		}
+		cursor = info.NextCursor
+	}
+}
+
+// ReconcileWithClient fetches the current asset balances and reconciles them against the main wallet journals.
+// The margin wallet is left out since Load does not journal realized PnL, see Reconcile.
+func (l *Ledger) ReconcileWithClient(c client.Client, tolerance decimal.Decimal) ([]*Discrepancy, error) {
+	balances, err := c.GetAssetBalances()
+	if err != nil {
+		return nil, err
+	}
+	return l.Reconcile(balances, nil, tolerance)
+}
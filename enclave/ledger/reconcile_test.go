@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestLoadFollowsPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockClient(ctrl)
+	spot := mocks.NewMockSpotClient(ctrl)
+	perps := mocks.NewMockPerpsClient(ctrl)
+	c.EXPECT().SpotClient().Return(spot).AnyTimes()
+	c.EXPECT().PerpsClient().Return(perps).AnyTimes()
+
+	deposit := func(txID string) *model.Deposit {
+		return &model.Deposit{Coin: "USDC", Size: decimal.NewFromInt(1), Status: model.DepositStatusConfirmed, TxID: txID}
+	}
+	gomock.InOrder(
+		c.EXPECT().GetDepositsPage(gomock.Any()).Return(&api.GetDepositsResponse{
+			PageInfo: api.PageInfo{NextCursor: "d2"},
+			Deposits: []*model.Deposit{deposit("a"), deposit("b")},
+		}, nil),
+		c.EXPECT().GetDepositsPage(&api.GetDepositsRequest{PagingAndTimeRange: api.PagingAndTimeRange{Paging: api.Paging{Cursor: "d2"}}}).
+			Return(&api.GetDepositsResponse{Deposits: []*model.Deposit{deposit("c")}}, nil),
+	)
+	c.EXPECT().GetWithdrawalsPage(gomock.Any()).Return(&api.GetWithdrawalsResponse{}, nil)
+	c.EXPECT().GetTransferHistory(gomock.Any()).Return(&api.GetTransferHistoryResponse{}, nil)
+	spot.EXPECT().GetFillsPage(gomock.Any()).Return(&api.GetFillsResponse{}, nil)
+	perps.EXPECT().GetMarkPrices().Return(map[string]*model.MarkPrice{"BTC-USD.P": {}}, nil)
+	gomock.InOrder(
+		perps.EXPECT().GetFundingFees(gomock.Any()).Return(&api.GetFundingFeesResponse{
+			PageInfo:    api.PageInfo{NextCursor: "f2"},
+			FundingFees: []*model.FundingFee{{Amount: decimal.RequireFromString("0.5")}},
+		}, nil),
+		perps.EXPECT().GetFundingFees(gomock.Any()).Return(&api.GetFundingFeesResponse{
+			FundingFees: []*model.FundingFee{{Amount: decimal.RequireFromString("-0.2")}},
+		}, nil),
+	)
+
+	l, err := Load(c, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Balance("USDC", model.WalletMain); !got.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("main balance = %s, want 3 from deposits across pages", got)
+	}
+	if got := l.Balance("USDC", model.WalletMargin); !got.Equal(decimal.RequireFromString("0.3")) {
+		t.Errorf("margin balance = %s, want 0.3 from funding fees across pages", got)
+	}
+}
+
+func testLedger() *Ledger {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New("")
+	l.AddDeposits([]*model.Deposit{{Coin: "USDC", Size: decimal.NewFromInt(1000), Status: model.DepositStatusConfirmed, TxID: "d1", Time: at}})
+	l.AddFills([]*model.Fill{{
+		ID:         "f1",
+		Market:     "AVAX-USDC",
+		Side:       model.OrderSideBuy,
+		Size:       decimal.NewFromInt(2),
+		FilledCost: decimal.NewFromInt(40),
+		Fee:        decimal.RequireFromString("0.04"),
+		Time:       at.Add(time.Hour),
+	}})
+	l.AddFundingFees([]*model.FundingFee{{Market: "AVAX-USD.P", Amount: decimal.RequireFromString("0.5"), Time: at.Add(2 * time.Hour)}})
+	return l
+}
+
+func TestReconcile(t *testing.T) {
+	balance := func(symbol, total string) *model.AssetBalance {
+		return &model.AssetBalance{Symbol: symbol, TotalBalance: total}
+	}
+	type want struct {
+		asset      string
+		wallet     model.Wallet
+		ledger     string
+		difference string
+	}
+
+	tests := []struct {
+		name      string
+		balances  []*model.AssetBalance
+		margin    *model.Balance
+		tolerance string
+		want      []want
+		wantErr   bool
+	}{
+		{
+			name:     "balanced",
+			balances: []*model.AssetBalance{balance("USDC", "959.96"), balance("AVAX", "2")},
+		},
+		{
+			name:     "lowercase symbols",
+			balances: []*model.AssetBalance{balance("usdc", "959.96"), balance("avax", "2")},
+		},
+		{
+			name:      "difference within tolerance",
+			balances:  []*model.AssetBalance{balance("USDC", "959.95"), balance("AVAX", "2")},
+			tolerance: "0.01",
+		},
+		{
+			name:      "difference beyond tolerance",
+			balances:  []*model.AssetBalance{balance("USDC", "959.9"), balance("AVAX", "2.5")},
+			tolerance: "0.01",
+			want: []want{
+				{"AVAX", model.WalletMain, "2", "0.5"},
+				{"USDC", model.WalletMain, "959.96", "-0.06"},
+			},
+		},
+		{
+			name:     "reported asset without entries",
+			balances: []*model.AssetBalance{balance("USDC", "959.96"), balance("AVAX", "2"), balance("ETH", "0.1")},
+			want:     []want{{"ETH", model.WalletMain, "0", "0.1"}},
+		},
+		{
+			name:     "journal without a reported balance",
+			balances: []*model.AssetBalance{balance("USDC", "959.96")},
+		},
+		{
+			name:     "margin wallet compared when given",
+			balances: []*model.AssetBalance{balance("USDC", "959.96"), balance("AVAX", "2")},
+			margin:   &model.Balance{WalletBalance: decimal.RequireFromString("12.5")},
+			want:     []want{{"USDC", model.WalletMargin, "0.5", "12"}},
+		},
+		{
+			name:     "invalid balance",
+			balances: []*model.AssetBalance{balance("USDC", "n/a")},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tolerance := decimal.Zero
+			if tt.tolerance != "" {
+				tolerance = decimal.RequireFromString(tt.tolerance)
+			}
+			got, err := testLedger().Reconcile(tt.balances, tt.margin, tolerance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d discrepancies, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Asset != w.asset || g.Wallet != w.wallet || !g.Ledger.Equal(decimal.RequireFromString(w.ledger)) ||
+					!g.Difference.Equal(decimal.RequireFromString(w.difference)) || !g.Reported.Equal(g.Ledger.Add(g.Difference)) {
+					t.Errorf("discrepancy %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileWithClientLeavesMarginOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockClient(ctrl)
+	c.EXPECT().GetAssetBalances().Return([]*model.AssetBalance{
+		{Symbol: "USDC", TotalBalance: "959.96"},
+		{Symbol: "AVAX", TotalBalance: "2"},
+	}, nil)
+
+	// The margin journal holds funding fees only; without realized PnL it cannot match the margin wallet.
+	got, err := testLedger().ReconcileWithClient(c, decimal.Zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got discrepancies %+v, want none", got)
+	}
+}
@@ -1,7 +1,5 @@
 package model
 
-import "encoding/json"
-
 type DisabledFunctionality struct {
 	DisableTransfers   bool `json:"disableTransfers"`
 	DisableKYC         bool `json:"disableKYC"`
@@ -27,5 +25,5 @@ type Account struct {
 	PrivacyVersion        int                    `json:"PrivacyVersion"`        // Version of privacy policy accepted
 	PrivacyUnixSecs       int                    `json:"PrivacyUnixSecs"`       // Timestamp of privacy policy acceptance
 	MarketingConsent      string                 `json:"MarketingConsent"`      // Marketing consent status (e.g., "accepted")
-	Subaccount            json.RawMessage        `json:"Subaccount,omitempty"`  // Optional subaccount information
+	Subaccount            *Subaccount            `json:"Subaccount,omitempty"`  // Set when the account is a subaccount
 }
@@ -6,6 +6,12 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	DepositStatusPending   = "DEPOSIT_PENDING"
+	DepositStatusConfirmed = "DEPOSIT_CONFIRMED"
+	DepositStatusFailed    = "DEPOSIT_FAILED"
+)
+
 type Deposit struct {
 	Coin                  string          `json:"coin"`                  // The coin which was deposited
 	CurrentConfirmations  int64           `json:"currentConfirmations"`  // Current number of confirmations if the deposit is pending
@@ -7,3 +7,29 @@ type OrderBook struct {
 	Asks [][]decimal.Decimal `json:"asks"`
 	Bids [][]decimal.Decimal `json:"bids"`
 }
+
+// BestBid returns the highest bid price, or false when there are no bids.
+func (b *OrderBook) BestBid() (decimal.Decimal, bool) {
+	if len(b.Bids) == 0 || len(b.Bids[0]) == 0 {
+		return decimal.Zero, false
+	}
+	return b.Bids[0][0], true
+}
+
+// BestAsk returns the lowest ask price, or false when there are no asks.
+func (b *OrderBook) BestAsk() (decimal.Decimal, bool) {
+	if len(b.Asks) == 0 || len(b.Asks[0]) == 0 {
+		return decimal.Zero, false
+	}
+	return b.Asks[0][0], true
+}
+
+// Mid returns the midpoint of the best bid and ask, or false when either side is empty.
+func (b *OrderBook) Mid() (decimal.Decimal, bool) {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return decimal.Zero, false
+	}
+	return bid.Add(ask).Div(decimal.NewFromInt(2)), true
+}
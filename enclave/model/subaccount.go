@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Subaccount represents a subaccount owned by a master account.
+type Subaccount struct {
+	ID        string    `json:"id"`        // Account ID of the subaccount
+	Name      string    `json:"name"`      // Display name of the subaccount, e.g., "market-making"
+	ParentID  string    `json:"parentId"`  // Account ID of the master account
+	CreatedAt time.Time `json:"createdAt"` // ISO8601 timestamp of the subaccount creation time
+}
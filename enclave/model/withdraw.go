@@ -6,6 +6,12 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	WithdrawalStatusPending   = "WITHDRAWAL_PENDING"
+	WithdrawalStatusConfirmed = "WITHDRAWAL_CONFIRMED"
+	WithdrawalStatusFailed    = "WITHDRAWAL_FAILED"
+)
+
 type WithdrawalRequest struct {
 	AccountID            string          `json:"account_id"`             // Internal ID associated with the account that made the request
 	Address              string          `json:"address"`                // Address to initiate withdrawal to
@@ -0,0 +1,254 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+type EventKind string
+
+const (
+	EventWarning          EventKind = "warning"          // Margin ratio crossed a warning threshold upwards
+	EventRecovered        EventKind = "recovered"        // Margin ratio fell back below every warning threshold
+	EventLiquidation      EventKind = "liquidation"      // Account went under liquidation
+	EventLiquidationEnded EventKind = "liquidationEnded" // Account is no longer under liquidation
+	EventTopUp            EventKind = "topUp"            // USDC was transferred from the main wallet to margin
+	EventTopUpSkipped     EventKind = "topUpSkipped"     // A top-up was needed but blocked by the cooldown or daily cap, once per cooldown or day
+	EventTopUpFailed      EventKind = "topUpFailed"      // The top-up transfer request failed
+)
+
+// Event is an alert or action taken by the Monitor, with the snapshot that triggered it.
+type Event struct {
+	Time      time.Time         `json:"time"`               // Time the event was emitted
+	Kind      EventKind         `json:"kind"`               // Kind of event
+	Message   string            `json:"message"`            // Human-readable description
+	Threshold decimal.Decimal   `json:"threshold"`          // Threshold that was crossed, if any
+	Balance   *model.Balance    `json:"balance"`            // Balance snapshot that triggered the event
+	Positions []*model.Position `json:"positions"`          // Positions snapshot that triggered the event
+	Transfer  *model.Transfer   `json:"transfer,omitempty"` // Transfer executed by a top-up
+	Error     string            `json:"error,omitempty"`    // Error of a failed action
+}
+
+// TopUp configures automatic transfers from the main wallet into margin.
+type TopUp struct {
+	Threshold decimal.Decimal // Margin ratio percentage at or above which a top-up is sent
+	Amount    decimal.Decimal // Amount of USDC sent per top-up
+	DailyCap  decimal.Decimal // Maximum USDC sent per UTC day, zero for no cap
+	Cooldown  time.Duration   // Minimum time between two top-ups
+}
+
+// Config configures a Monitor.
+type Config struct {
+	Interval   time.Duration     // Time between two polls, defaults to 30 seconds
+	Thresholds []decimal.Decimal // Margin ratio percentages that raise a warning when crossed upwards
+	Hysteresis decimal.Decimal   // Percentage points the margin ratio must fall below a crossed threshold before it can warn again
+	TopUp      *TopUp            // Optional automatic top-up, disabled when nil
+	Notifiers  []Notifier        // Destinations for every event
+	Recorder   Recorder          // Optional persistent record of every event
+}
+
+// Monitor polls the margin account and raises events when its health deteriorates.
+type Monitor struct {
+	perps client.PerpsClient
+	cfg   Config
+	now   func() time.Time
+
+	mu               sync.Mutex
+	level            int
+	underLiquidation bool
+	lastTopUp        time.Time
+	topUpDay         time.Time
+	topUpToday       decimal.Decimal
+	skippedCooldown  time.Time // lastTopUp of the cooldown a skip was emitted for
+	skippedCap       time.Time // Day a skip was emitted for because of the daily cap
+}
+
+// New initializes a Monitor for the margin account of perps.
+func New(perps client.PerpsClient, cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	thresholds := make([]decimal.Decimal, len(cfg.Thresholds))
+	copy(thresholds, cfg.Thresholds)
+	sort.Slice(thresholds, func(i, j int) bool {
+		return thresholds[i].LessThan(thresholds[j])
+	})
+	cfg.Thresholds = thresholds
+
+	return &Monitor{
+		perps: perps,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Run polls until ctx is done. Errors from individual polls are passed to onError, if non-nil, and do not stop the loop.
+func (m *Monitor) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check polls the balance and positions once and emits any resulting events.
+func (m *Monitor) Check(ctx context.Context) error {
+	balance, err := m.perps.GetBalance()
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+	positions, err := m.perps.GetPositions()
+	if err != nil {
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	emit := func(e *Event) {
+		e.Time = m.now()
+		e.Balance = balance
+		e.Positions = positions
+		if err := m.emit(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	level := 0
+	for i, t := range m.cfg.Thresholds {
+		// Crossed thresholds are only cleared once the ratio falls Hysteresis below them, so that a ratio
+		// hovering around a threshold does not warn on every poll.
+		if i < m.level {
+			t = t.Sub(m.cfg.Hysteresis)
+		}
+		if balance.MarginRatio.GreaterThanOrEqual(t) {
+			level++
+		}
+	}
+	switch {
+	case level > m.level:
+		threshold := m.cfg.Thresholds[level-1]
+		emit(&Event{
+			Kind:      EventWarning,
+			Message:   fmt.Sprintf("margin ratio %s%% crossed %s%%", balance.MarginRatio, threshold),
+			Threshold: threshold,
+		})
+	case level == 0 && m.level > 0:
+		emit(&Event{
+			Kind:    EventRecovered,
+			Message: fmt.Sprintf("margin ratio %s%% is below every threshold", balance.MarginRatio),
+		})
+	}
+	m.level = level
+
+	if balance.UnderLiquidation != m.underLiquidation {
+		e := &Event{Kind: EventLiquidation, Message: "account is under liquidation"}
+		if !balance.UnderLiquidation {
+			e = &Event{Kind: EventLiquidationEnded, Message: "account is no longer under liquidation"}
+		}
+		emit(e)
+		m.underLiquidation = balance.UnderLiquidation
+	}
+
+	if e := m.topUp(balance); e != nil {
+		emit(e)
+	}
+
+	return errors.Join(errs...)
+}
+
+// topUp sends a transfer if the configured threshold is reached and returns the resulting event, if any.
+// A blocked top-up is reported once per cooldown and once per day for the daily cap.
+func (m *Monitor) topUp(balance *model.Balance) *Event {
+	cfg := m.cfg.TopUp
+	if cfg == nil || balance.MarginRatio.LessThan(cfg.Threshold) {
+		return nil
+	}
+
+	now := m.now()
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(m.topUpDay) {
+		m.topUpDay = day
+		m.topUpToday = decimal.Zero
+	}
+
+	if !m.lastTopUp.IsZero() && now.Sub(m.lastTopUp) < cfg.Cooldown {
+		if m.skippedCooldown.Equal(m.lastTopUp) {
+			return nil
+		}
+		m.skippedCooldown = m.lastTopUp
+		return &Event{
+			Kind:      EventTopUpSkipped,
+			Message:   fmt.Sprintf("top-up skipped: cooldown until %s", m.lastTopUp.Add(cfg.Cooldown).Format(time.RFC3339)),
+			Threshold: cfg.Threshold,
+		}
+	}
+	if !cfg.DailyCap.IsZero() && m.topUpToday.Add(cfg.Amount).GreaterThan(cfg.DailyCap) {
+		if m.skippedCap.Equal(day) {
+			return nil
+		}
+		m.skippedCap = day
+		return &Event{
+			Kind:      EventTopUpSkipped,
+			Message:   fmt.Sprintf("top-up skipped: %s USDC already sent today, cap is %s", m.topUpToday, cfg.DailyCap),
+			Threshold: cfg.Threshold,
+		}
+	}
+
+	transfer, err := m.perps.Transfer(&api.TransferRequest{
+		Symbol: "USDC",
+		Amount: cfg.Amount,
+	})
+	if err != nil {
+		return &Event{
+			Kind:      EventTopUpFailed,
+			Message:   fmt.Sprintf("top-up of %s USDC failed", cfg.Amount),
+			Threshold: cfg.Threshold,
+			Error:     err.Error(),
+		}
+	}
+
+	m.lastTopUp = now
+	m.topUpToday = m.topUpToday.Add(cfg.Amount)
+	return &Event{
+		Kind:      EventTopUp,
+		Message:   fmt.Sprintf("transferred %s USDC from main to margin", cfg.Amount),
+		Threshold: cfg.Threshold,
+		Transfer:  transfer,
+	}
+}
+
+func (m *Monitor) emit(ctx context.Context, e *Event) error {
+	var errs []error
+	if m.cfg.Recorder != nil {
+		if err := m.cfg.Recorder.Record(e); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record %s event: %w", e.Kind, err))
+		}
+	}
+	for _, n := range m.cfg.Notifiers {
+		if err := n.Notify(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify %s event: %w", e.Kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
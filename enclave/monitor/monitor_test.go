@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+type memoryRecorder struct {
+	events []*Event
+}
+
+func (r *memoryRecorder) Record(event *Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// poll is one Check of a test sequence.
+type poll struct {
+	at               time.Duration // Time since the start of the sequence
+	ratio            string
+	underLiquidation bool
+	transfer         bool  // Whether a top-up transfer is expected
+	transferErr      error // Error returned by the transfer
+	want             []EventKind
+}
+
+func runPolls(t *testing.T, cfg Config, polls []poll) {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	pc := mocks.NewMockPerpsClient(ctrl)
+	rec := &memoryRecorder{}
+	cfg.Recorder = rec
+	m := New(pc, cfg)
+
+	for i, p := range polls {
+		now := start.Add(p.at)
+		m.now = func() time.Time { return now }
+		pc.EXPECT().GetBalance().Return(&model.Balance{MarginRatio: d(p.ratio), UnderLiquidation: p.underLiquidation}, nil)
+		pc.EXPECT().GetPositions().Return(nil, nil)
+		if p.transfer {
+			pc.EXPECT().Transfer(gomock.Any()).DoAndReturn(func(req *api.TransferRequest) (*model.Transfer, error) {
+				if req.Symbol != "USDC" || !req.Amount.Equal(cfg.TopUp.Amount) {
+					t.Errorf("Transfer() request = %+v, want %s USDC", req, cfg.TopUp.Amount)
+				}
+				if p.transferErr != nil {
+					return nil, p.transferErr
+				}
+				return &model.Transfer{}, nil
+			})
+		}
+
+		rec.events = nil
+		if err := m.Check(context.Background()); err != nil {
+			t.Fatalf("poll %d: Check() error = %v", i, err)
+		}
+		var got []EventKind
+		for _, e := range rec.events {
+			got = append(got, e.Kind)
+			if !e.Time.Equal(now) || e.Balance == nil {
+				t.Errorf("poll %d: event %+v, want the time and balance of the poll", i, e)
+			}
+		}
+		if !reflect.DeepEqual(got, p.want) {
+			t.Fatalf("poll %d at ratio %s: events = %v, want %v", i, p.ratio, got, p.want)
+		}
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	runPolls(t, Config{
+		Thresholds: []decimal.Decimal{d("80"), d("50")},
+		Hysteresis: d("5"),
+	}, []poll{
+		{ratio: "40"},
+		{ratio: "55", want: []EventKind{EventWarning}},
+		{ratio: "48"},
+		{ratio: "52"},
+		{ratio: "44", want: []EventKind{EventRecovered}},
+		{ratio: "51", want: []EventKind{EventWarning}},
+		{ratio: "85", want: []EventKind{EventWarning}},
+		{ratio: "78"},
+		{ratio: "81"},
+		{ratio: "74"},
+		{ratio: "80", want: []EventKind{EventWarning}},
+		{ratio: "90", underLiquidation: true, want: []EventKind{EventLiquidation}},
+		{ratio: "90", underLiquidation: true},
+		{ratio: "30", want: []EventKind{EventRecovered, EventLiquidationEnded}},
+	})
+}
+
+func TestCheckTopUp(t *testing.T) {
+	tomorrow := 4*time.Hour + 30*time.Minute
+	runPolls(t, Config{
+		TopUp: &TopUp{
+			Threshold: d("80"),
+			Amount:    d("100"),
+			DailyCap:  d("250"),
+			Cooldown:  time.Hour,
+		},
+	}, []poll{
+		{ratio: "70"},
+		{ratio: "85", transfer: true, transferErr: errors.New("insufficient balance"), want: []EventKind{EventTopUpFailed}},
+		{at: time.Minute, ratio: "85", transfer: true, want: []EventKind{EventTopUp}},
+		{at: 10 * time.Minute, ratio: "85", want: []EventKind{EventTopUpSkipped}},
+		{at: 20 * time.Minute, ratio: "85"},
+		{at: 30 * time.Minute, ratio: "85"},
+		{at: 61 * time.Minute, ratio: "85", transfer: true, want: []EventKind{EventTopUp}},
+		{at: 62 * time.Minute, ratio: "85", want: []EventKind{EventTopUpSkipped}},
+		{at: 130 * time.Minute, ratio: "85", want: []EventKind{EventTopUpSkipped}},
+		{at: 200 * time.Minute, ratio: "85"},
+		{at: 210 * time.Minute, ratio: "70"},
+		{at: tomorrow, ratio: "85", transfer: true, want: []EventKind{EventTopUp}},
+	})
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Notifier delivers an Event to an external system.
+type Notifier interface {
+	Notify(ctx context.Context, event *Event) error
+}
+
+// LogNotifier writes events to a logger.
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// NewLogNotifier initializes a LogNotifier; a nil logger uses the standard logger.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{Logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, event *Event) error {
+	n.Logger.Printf("[margin %s] %s (margin ratio %s%%)", event.Kind, event.Message, event.Balance.MarginRatio)
+	return nil
+}
+
+// WebhookNotifier posts events as JSON to a URL.
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewWebhookNotifier initializes a WebhookNotifier posting to url.
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:     url,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ExecNotifier runs a command for each event.
+// The event is written as JSON to the command's stdin, and ENCLAVE_EVENT_KIND and ENCLAVE_MARGIN_RATIO are set in its environment.
+type ExecNotifier struct {
+	Command string
+	Args    []string
+}
+
+// NewExecNotifier initializes an ExecNotifier running command with args.
+func NewExecNotifier(command string, args ...string) *ExecNotifier {
+	return &ExecNotifier{Command: command, Args: args}
+}
+
+func (n *ExecNotifier) Notify(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, n.Command, n.Args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"ENCLAVE_EVENT_KIND="+string(event.Kind),
+		"ENCLAVE_MARGIN_RATIO="+event.Balance.MarginRatio.String(),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run %s: %w: %s", n.Command, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// Recorder persists every Event emitted by the Monitor.
+type Recorder interface {
+	Record(event *Event) error
+}
+
+// FileRecorder appends events as JSON lines to a file.
+type FileRecorder struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileRecorder initializes a FileRecorder appending to path.
+func NewFileRecorder(path string) *FileRecorder {
+	return &FileRecorder{path: path}
+}
+
+func (r *FileRecorder) Record(event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
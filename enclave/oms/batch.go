@@ -0,0 +1,148 @@
+package oms
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// DefaultConcurrency is the number of requests a batch runs in parallel when BatchOptions does not set it.
+const DefaultConcurrency = 4
+
+// BatchOptions configures batch operations. Requests also wait on the client's rate limiter, see client.WithRateLimit,
+// so the concurrency only bounds how many requests are in flight at once.
+type BatchOptions struct {
+	Concurrency int // Maximum number of requests in flight, defaults to DefaultConcurrency
+}
+
+func (o *BatchOptions) concurrency() int {
+	if o == nil || o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
+
+// PlaceResult is the outcome of one order of PlaceOrders.
+type PlaceResult struct {
+	Request api.AddOrderRequest
+	Order   *model.Order // Order added, nil on error
+	Err     error
+}
+
+// CancelResult is the outcome of one cancellation.
+type CancelResult struct {
+	OrderID string
+	Order   *model.Order // Order canceled, nil on error
+	Err     error
+}
+
+// PlaceOrders adds every order of reqs and returns their results in the same order.
+// A failed order does not stop the others.
+func PlaceOrders(c client.OrderFillClient, reqs []api.AddOrderRequest, opts *BatchOptions) []PlaceResult {
+	res := make([]PlaceResult, len(reqs))
+	forEach(len(reqs), opts.concurrency(), func(i int) {
+		req := reqs[i]
+		o, err := c.AddOrder(&req)
+		res[i] = PlaceResult{Request: reqs[i], Order: o, Err: err}
+	})
+	return res
+}
+
+// CancelOrdersByID cancels every order of ids and returns their results in the same order.
+// A failed cancellation does not stop the others.
+func CancelOrdersByID(c client.OrderFillClient, ids []string, opts *BatchOptions) []CancelResult {
+	res := make([]CancelResult, len(ids))
+	forEach(len(ids), opts.concurrency(), func(i int) {
+		o, err := c.CancelOrder(&api.CancelOrderRequest{OrderID: ids[i]})
+		res[i] = CancelResult{OrderID: ids[i], Order: o, Err: err}
+	})
+	return res
+}
+
+// CancelFilter selects open orders. Unset fields match every order.
+type CancelFilter struct {
+	Market         string
+	Side           model.OrderSide
+	MinPrice       decimal.Decimal // Lowest price of the orders to cancel, inclusive
+	MaxPrice       decimal.Decimal // Highest price of the orders to cancel, inclusive
+	OlderThan      time.Duration   // Minimum age of the orders to cancel
+	ClientIDPrefix string          // Prefix of the client order IDs of the orders to cancel
+}
+
+// Match reports whether o is selected by f at time now.
+func (f *CancelFilter) Match(o *model.Order, now time.Time) bool {
+	switch {
+	case f.Market != "" && o.Market != f.Market:
+		return false
+	case f.Side != "" && o.Side != f.Side:
+		return false
+	case f.MinPrice.IsPositive() && o.Price.LessThan(f.MinPrice):
+		return false
+	case f.MaxPrice.IsPositive() && o.Price.GreaterThan(f.MaxPrice):
+		return false
+	case f.OlderThan > 0 && now.Sub(o.CreatedAt) < f.OlderThan:
+		return false
+	case f.ClientIDPrefix != "" && !strings.HasPrefix(o.ClientOrderID, f.ClientIDPrefix):
+		return false
+	}
+	return true
+}
+
+// CancelMatching lists the open orders, then cancels those selected by f and returns their results in listing order.
+// Only an error listing the orders is returned; failed cancellations are reported in the results.
+func CancelMatching(c client.OrderFillClient, f *CancelFilter, opts *BatchOptions) ([]CancelResult, error) {
+	orders, err := OpenOrders(c, f.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var ids []string
+	for i := range orders {
+		if f.Match(&orders[i], now) {
+			ids = append(ids, orders[i].OrderID)
+		}
+	}
+	return CancelOrdersByID(c, ids, opts), nil
+}
+
+// OpenOrders lists every open order of market, or of every market when market is empty, following pagination.
+func OpenOrders(c client.OrderFillClient, market string) ([]model.Order, error) {
+	var res []model.Order
+	req := &api.GetOrdersRequest{Market: market, Status: model.OrderStatusOpen}
+	for {
+		resp, err := c.GetOrders(req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.Orders...)
+		if resp.PageInfo.NextCursor == "" || resp.PageInfo.NextCursor == req.Cursor || len(resp.Orders) == 0 {
+			return res, nil
+		}
+		req.Cursor = resp.PageInfo.NextCursor
+	}
+}
+
+// forEach calls fn for every index below n with at most concurrency calls running at once.
+func forEach(n, concurrency int, fn func(i int)) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,230 @@
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// inFlight tracks the number of concurrent calls and the most seen at once.
+type inFlight struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (f *inFlight) enter() {
+	f.mu.Lock()
+	f.current++
+	if f.current > f.max {
+		f.max = f.current
+	}
+	f.mu.Unlock()
+	// Give other calls the chance to overlap with this one.
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (f *inFlight) leave() {
+	f.mu.Lock()
+	f.current--
+	f.mu.Unlock()
+}
+
+func TestPlaceOrders(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *BatchOptions
+		wantMaxBusy int
+	}{
+		{name: "default concurrency", wantMaxBusy: DefaultConcurrency},
+		{name: "concurrency of 2", opts: &BatchOptions{Concurrency: 2}, wantMaxBusy: 2},
+		{name: "sequential", opts: &BatchOptions{Concurrency: 1}, wantMaxBusy: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockOrderFillClient(ctrl)
+			rejected := errors.New("insufficient balance")
+			busy := &inFlight{}
+			c.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+				busy.enter()
+				defer busy.leave()
+				if req.ClientOrderID == "c2" {
+					return nil, rejected
+				}
+				return &model.Order{OrderID: "o-" + req.ClientOrderID, ClientOrderID: req.ClientOrderID}, nil
+			}).Times(8)
+
+			var reqs []api.AddOrderRequest
+			for i := 0; i < 8; i++ {
+				reqs = append(reqs, api.AddOrderRequest{ClientOrderID: fmt.Sprintf("c%d", i), Market: "AVAX-USDC", Side: model.OrderSideBuy, Size: d("1"), Price: d("20")})
+			}
+			res := PlaceOrders(c, reqs, tt.opts)
+
+			if len(res) != len(reqs) {
+				t.Fatalf("got %d results, want %d", len(res), len(reqs))
+			}
+			for i, r := range res {
+				if r.Request.ClientOrderID != reqs[i].ClientOrderID {
+					t.Fatalf("result %d is for %s, want %s", i, r.Request.ClientOrderID, reqs[i].ClientOrderID)
+				}
+				if i == 2 {
+					if !errors.Is(r.Err, rejected) || r.Order != nil {
+						t.Errorf("result %d = %+v, want rejected", i, r)
+					}
+					continue
+				}
+				if r.Err != nil || r.Order == nil || r.Order.OrderID != "o-"+reqs[i].ClientOrderID {
+					t.Errorf("result %d = %+v, want order o-%s", i, r, reqs[i].ClientOrderID)
+				}
+			}
+			if busy.max > tt.wantMaxBusy {
+				t.Fatalf("%d requests in flight at once, want at most %d", busy.max, tt.wantMaxBusy)
+			}
+		})
+	}
+}
+
+func TestCancelOrdersByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockOrderFillClient(ctrl)
+	notOpen := errors.New("order not open")
+	busy := &inFlight{}
+	c.EXPECT().CancelOrder(gomock.Any()).DoAndReturn(func(req *api.CancelOrderRequest) (*model.Order, error) {
+		busy.enter()
+		defer busy.leave()
+		if req.OrderID == "o1" {
+			return nil, notOpen
+		}
+		return &model.Order{OrderID: req.OrderID, Status: model.OrderStatusCanceled}, nil
+	}).Times(5)
+
+	ids := []string{"o0", "o1", "o2", "o3", "o4"}
+	res := CancelOrdersByID(c, ids, &BatchOptions{Concurrency: 3})
+
+	if len(res) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(res), len(ids))
+	}
+	for i, r := range res {
+		switch {
+		case r.OrderID != ids[i]:
+			t.Fatalf("result %d is for %s, want %s", i, r.OrderID, ids[i])
+		case i == 1 && (!errors.Is(r.Err, notOpen) || r.Order != nil):
+			t.Errorf("result %d = %+v, want not open", i, r)
+		case i != 1 && (r.Err != nil || r.Order == nil || r.Order.OrderID != ids[i]):
+			t.Errorf("result %d = %+v, want %s canceled", i, r, ids[i])
+		}
+	}
+	if busy.max > 3 {
+		t.Fatalf("%d cancellations in flight at once, want at most 3", busy.max)
+	}
+}
+
+func TestCancelFilterMatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	o := &model.Order{
+		Market:        "AVAX-USDC",
+		Side:          model.OrderSideBuy,
+		Price:         d("20"),
+		ClientOrderID: "mm-42",
+		CreatedAt:     now.Add(-10 * time.Minute),
+	}
+
+	tests := []struct {
+		name   string
+		filter CancelFilter
+		want   bool
+	}{
+		{name: "empty filter", want: true},
+		{name: "same market", filter: CancelFilter{Market: "AVAX-USDC"}, want: true},
+		{name: "other market", filter: CancelFilter{Market: "ETH-USDC"}},
+		{name: "same side", filter: CancelFilter{Side: model.OrderSideBuy}, want: true},
+		{name: "other side", filter: CancelFilter{Side: model.OrderSideSell}},
+		{name: "at the minimum price", filter: CancelFilter{MinPrice: d("20")}, want: true},
+		{name: "below the minimum price", filter: CancelFilter{MinPrice: d("20.01")}},
+		{name: "at the maximum price", filter: CancelFilter{MaxPrice: d("20")}, want: true},
+		{name: "above the maximum price", filter: CancelFilter{MaxPrice: d("19.99")}},
+		{name: "old enough", filter: CancelFilter{OlderThan: 10 * time.Minute}, want: true},
+		{name: "too recent", filter: CancelFilter{OlderThan: 11 * time.Minute}},
+		{name: "client ID prefix", filter: CancelFilter{ClientIDPrefix: "mm-"}, want: true},
+		{name: "other client ID prefix", filter: CancelFilter{ClientIDPrefix: "arb-"}},
+		{
+			name:   "every field matching",
+			filter: CancelFilter{Market: "AVAX-USDC", Side: model.OrderSideBuy, MinPrice: d("19"), MaxPrice: d("21"), OlderThan: time.Minute, ClientIDPrefix: "mm"},
+			want:   true,
+		},
+		{
+			name:   "one field not matching",
+			filter: CancelFilter{Market: "AVAX-USDC", Side: model.OrderSideBuy, MinPrice: d("19"), MaxPrice: d("21"), OlderThan: time.Hour, ClientIDPrefix: "mm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(o, now); got != tt.want {
+				t.Fatalf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelMatching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockOrderFillClient(ctrl)
+	order := func(id string, side model.OrderSide) model.Order {
+		return model.Order{OrderID: id, Market: "AVAX-USDC", Side: side, Price: d("20")}
+	}
+	gomock.InOrder(
+		c.EXPECT().GetOrders(gomock.Any()).DoAndReturn(func(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error) {
+			if req.Market != "AVAX-USDC" || req.Status != model.OrderStatusOpen || req.Cursor != "" {
+				t.Errorf("GetOrders() request = %+v, want the first page of open AVAX-USDC orders", req)
+			}
+			return &api.GetOrdersResponse{
+				Orders:   []model.Order{order("o1", model.OrderSideBuy), order("o2", model.OrderSideSell)},
+				PageInfo: api.PageInfo{NextCursor: "p2"},
+			}, nil
+		}),
+		c.EXPECT().GetOrders(gomock.Any()).DoAndReturn(func(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error) {
+			if req.Cursor != "p2" {
+				t.Errorf("GetOrders() cursor = %q, want p2", req.Cursor)
+			}
+			return &api.GetOrdersResponse{Orders: []model.Order{order("o3", model.OrderSideBuy), order("o4", model.OrderSideBuy)}}, nil
+		}),
+	)
+	notOpen := errors.New("order not open")
+	c.EXPECT().CancelOrder(gomock.Any()).DoAndReturn(func(req *api.CancelOrderRequest) (*model.Order, error) {
+		if req.OrderID == "o3" {
+			return nil, notOpen
+		}
+		return &model.Order{OrderID: req.OrderID, Status: model.OrderStatusCanceled}, nil
+	}).Times(3)
+
+	res, err := CancelMatching(c, &CancelFilter{Market: "AVAX-USDC", Side: model.OrderSideBuy}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"o1", "o3", "o4"}
+	if len(res) != len(want) {
+		t.Fatalf("got %d results, want %d", len(res), len(want))
+	}
+	for i, r := range res {
+		if r.OrderID != want[i] || (r.Err != nil) != (r.OrderID == "o3") {
+			t.Errorf("result %d = %+v, want %s failing only for o3", i, r, want[i])
+		}
+	}
+
+	listErr := errors.New("503 service unavailable")
+	c.EXPECT().GetOrders(gomock.Any()).Return(nil, listErr)
+	if _, err := CancelMatching(c, &CancelFilter{}, nil); !errors.Is(err, listErr) {
+		t.Fatalf("CancelMatching() error = %v, want %v", err, listErr)
+	}
+}
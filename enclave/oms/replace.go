@@ -0,0 +1,127 @@
+// Package oms provides order management operations built from several API calls, such as cancel-replace.
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// ErrStillOpen is returned by Replace when the original order could not be canceled and is still open.
+var ErrStillOpen = errors.New("original order is still open")
+
+// ReplaceRequest identifies an order and its new terms.
+type ReplaceRequest struct {
+	OrderID       string // Order to replace, by ID
+	ClientOrderID string // Order to replace, by client order ID when OrderID is empty
+
+	Price            decimal.Decimal // New limit price
+	Size             decimal.Decimal // New total size, including what the original filled; zero keeps the original size
+	NewClientOrderID string          // Optional client order ID of the new order
+	PostOnly         bool            // Submit the new order as post-only
+
+	SettleTimeout time.Duration // Time to wait for the canceled order's final fills, defaults to 2 seconds
+}
+
+// ReplaceResult links the new order to the original one.
+type ReplaceResult struct {
+	OriginalOrderID string          `json:"originalOrderId"`
+	Original        *model.Order    `json:"original"`  // Final state of the original order
+	Order           *model.Order    `json:"order"`     // New order, nil when nothing remained to fill
+	Filled          decimal.Decimal `json:"filled"`    // Size the original filled before it was canceled
+	Remaining       decimal.Decimal `json:"remaining"` // Size of the new order
+	FullyFilled     bool            `json:"fullyFilled"`
+}
+
+// Replace changes the price of an order by canceling it and submitting a new one for the size that remains after
+// the original's final fills, so a fill racing the cancel never leads to an overfill. When the original filled
+// before the cancel landed, no new order is submitted. If the cancel succeeds but the new order fails, the result
+// is returned along with the error so that the caller knows the original is gone.
+func Replace(c client.OrderFillClient, req *ReplaceRequest) (*ReplaceResult, error) {
+	if req.OrderID == "" && req.ClientOrderID == "" {
+		return nil, errors.New("order ID or client order ID is required")
+	}
+	if !req.Price.IsPositive() {
+		return nil, errors.New("price must be positive")
+	}
+	if req.SettleTimeout <= 0 {
+		req.SettleTimeout = 2 * time.Second
+	}
+
+	ref := &api.GetOrderRequest{OrderID: req.OrderID, ClientOrderID: req.ClientOrderID}
+	if _, cancelErr := c.CancelOrder(&api.CancelOrderRequest{OrderID: req.OrderID, ClientOrderID: req.ClientOrderID}); cancelErr != nil {
+		// The cancel fails when the order already filled or was canceled; its state decides.
+		o, err := c.GetOrder(ref)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to cancel order: %w", cancelErr), fmt.Errorf("failed to get order: %w", err))
+		}
+		if o.Status == model.OrderStatusOpen {
+			return nil, fmt.Errorf("%w: %v", ErrStillOpen, cancelErr)
+		}
+	}
+
+	original, err := settle(c, ref, req.SettleTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	size := req.Size
+	if !size.IsPositive() {
+		size = original.Size
+	}
+	res := &ReplaceResult{
+		OriginalOrderID: original.OrderID,
+		Original:        original,
+		Filled:          original.FilledSize,
+		Remaining:       decimal.Max(size.Sub(original.FilledSize), decimal.Zero),
+	}
+	if original.Status == model.OrderStatusFullyFilled || !res.Remaining.IsPositive() {
+		res.FullyFilled = original.Status == model.OrderStatusFullyFilled
+		res.Remaining = decimal.Zero
+		return res, nil
+	}
+
+	tif := original.TimeInForce
+	if tif == "" {
+		tif = model.TimeInForceGTC
+	}
+	o, err := c.AddOrder(&api.AddOrderRequest{
+		ClientOrderID: req.NewClientOrderID,
+		Market:        original.Market,
+		Side:          original.Side,
+		Price:         req.Price,
+		Size:          res.Remaining,
+		Type:          model.OrderTypeLimit,
+		TimeInForce:   tif,
+		PostOnly:      req.PostOnly,
+	})
+	if err != nil {
+		return res, fmt.Errorf("original order %s canceled but failed to add replacement: %w", original.OrderID, err)
+	}
+	res.Order = o
+	return res, nil
+}
+
+// settle polls an order until it is no longer open, so that its FilledSize is final.
+func settle(c client.OrderFillClient, ref *api.GetOrderRequest, timeout time.Duration) (*model.Order, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		o, err := c.GetOrder(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get canceled order: %w", err)
+		}
+		if o.Status != model.OrderStatusOpen {
+			return o, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w after cancel: order %s", ErrStillOpen, o.OrderID)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,133 @@
+package oms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          string // New total size of the request
+		status        model.OrderStatus
+		filled        string
+		cancelErr     error
+		addErr        error
+		wantAddSize   string // Size of the replacement, empty when none is added
+		wantRemaining string
+		wantFilled    bool
+		wantErr       error
+	}{
+		{
+			name:          "zero size keeps the original size",
+			size:          "0",
+			status:        model.OrderStatusCanceled,
+			filled:        "4",
+			wantAddSize:   "6",
+			wantRemaining: "6",
+		},
+		{
+			name:          "larger size nets out the fills",
+			size:          "15",
+			status:        model.OrderStatusCanceled,
+			filled:        "4",
+			wantAddSize:   "11",
+			wantRemaining: "11",
+		},
+		{
+			name:          "size below the fills adds nothing",
+			size:          "3",
+			status:        model.OrderStatusCanceled,
+			filled:        "4",
+			wantRemaining: "0",
+		},
+		{
+			name:          "filled before the cancel landed",
+			size:          "0",
+			status:        model.OrderStatusFullyFilled,
+			filled:        "10",
+			cancelErr:     errors.New("order not open"),
+			wantRemaining: "0",
+			wantFilled:    true,
+		},
+		{
+			name:      "cancel failed and the order is still open",
+			size:      "0",
+			status:    model.OrderStatusOpen,
+			filled:    "0",
+			cancelErr: errors.New("503 service unavailable"),
+			wantErr:   ErrStillOpen,
+		},
+		{
+			name:          "replacement rejected after the cancel",
+			size:          "0",
+			status:        model.OrderStatusCanceled,
+			filled:        "4",
+			addErr:        errors.New("insufficient balance"),
+			wantAddSize:   "6",
+			wantRemaining: "6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockOrderFillClient(ctrl)
+			original := &model.Order{
+				OrderID:    "o1",
+				Market:     "AVAX-USDC",
+				Side:       model.OrderSideBuy,
+				Size:       d("10"),
+				FilledSize: d(tt.filled),
+				Status:     tt.status,
+			}
+			c.EXPECT().CancelOrder(gomock.Any()).Return(nil, tt.cancelErr)
+			c.EXPECT().GetOrder(gomock.Any()).Return(original, nil).AnyTimes()
+			if tt.wantAddSize != "" {
+				c.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+					if !req.Size.Equal(d(tt.wantAddSize)) || !req.Price.Equal(d("20")) || req.TimeInForce != model.TimeInForceGTC {
+						t.Errorf("AddOrder() request = %+v, want size %s at 20 GTC", req, tt.wantAddSize)
+					}
+					if tt.addErr != nil {
+						return nil, tt.addErr
+					}
+					return &model.Order{OrderID: "o2"}, nil
+				})
+			}
+
+			res, err := Replace(c, &ReplaceRequest{OrderID: "o1", Price: d("20"), Size: d(tt.size)})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Replace() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.addErr != nil:
+				if !errors.Is(err, tt.addErr) {
+					t.Fatalf("Replace() error = %v, want %v", err, tt.addErr)
+				}
+			case err != nil:
+				t.Fatalf("Replace() error = %v", err)
+			}
+
+			if res == nil {
+				t.Fatal("Replace() returned no result")
+			}
+			if !res.Remaining.Equal(d(tt.wantRemaining)) || res.FullyFilled != tt.wantFilled || !res.Filled.Equal(d(tt.filled)) {
+				t.Fatalf("Replace() = %+v, want remaining %s fully filled %v", res, tt.wantRemaining, tt.wantFilled)
+			}
+			if wantOrder := tt.wantAddSize != "" && tt.addErr == nil; (res.Order != nil) != wantOrder {
+				t.Fatalf("Replace() order = %+v, want a replacement %v", res.Order, wantOrder)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package pnl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+// RateFunc returns the price of one unit of asset in the reporting currency at the given time.
+type RateFunc func(asset string, at time.Time) (decimal.Decimal, error)
+
+// Engine matches disposals against tax lots built from spot fills.
+// Every fill acquires one coin and disposes of the other, so markets sharing an asset, e.g., AVAX-USDC and AVAX-ETH,
+// draw from the same lots. Values are expressed in the reporting currency; quotes in other coins are converted with the RateFunc.
+type Engine struct {
+	method    Method
+	currency  string
+	rate      RateFunc
+	lots      map[string][]*Lot
+	disposals []*Disposal
+}
+
+// NewEngine initializes an Engine reporting in currency, e.g., "USDC".
+// rate may be nil if every processed market is quoted in the reporting currency.
+func NewEngine(method Method, currency string, rate RateFunc) *Engine {
+	return &Engine{
+		method:   method,
+		currency: strings.ToUpper(currency),
+		rate:     rate,
+		lots:     make(map[string][]*Lot),
+	}
+}
+
+// Process consumes spot fills in time order. Fills in perps markets are skipped.
+func (e *Engine) Process(fills []*model.Fill) error {
+	sorted := make([]*model.Fill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	for _, f := range sorted {
+		if err := e.processFill(f); err != nil {
+			return fmt.Errorf("failed to process fill %s: %w", f.ID, err)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) processFill(f *model.Fill) error {
+	base, quote, ok := util.SplitTradingPair(f.Market)
+	if !ok {
+		return nil
+	}
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	quoteRate, err := e.rateOf(quote, f.Time)
+	if err != nil {
+		return err
+	}
+	fee := f.Fee.Mul(quoteRate)
+
+	if f.Side == model.OrderSideBuy {
+		// The base coin is acquired for the filled cost plus fee, which is paid in the quote coin.
+		cost := f.FilledCost.Mul(quoteRate).Add(fee)
+		if quote != e.currency {
+			e.dispose(quote, f, f.FilledCost.Add(f.Fee), cost, decimal.Zero)
+		}
+		e.acquire(base, f, f.Size, cost)
+		return nil
+	}
+
+	// The base coin is disposed of for the filled cost less fee, which is received in the quote coin.
+	proceeds := f.FilledCost.Mul(quoteRate).Sub(fee)
+	e.dispose(base, f, f.Size, proceeds, fee)
+	if quote != e.currency {
+		e.acquire(quote, f, f.FilledCost.Sub(f.Fee), proceeds)
+	}
+	return nil
+}
+
+func (e *Engine) rateOf(asset string, at time.Time) (decimal.Decimal, error) {
+	if asset == e.currency {
+		return decimal.NewFromInt(1), nil
+	}
+	if e.rate == nil {
+		return decimal.Zero, fmt.Errorf("no rate available to convert %s to %s", asset, e.currency)
+	}
+	return e.rate(asset, at)
+}
+
+func (e *Engine) acquire(asset string, f *model.Fill, size, cost decimal.Decimal) {
+	if size.LessThanOrEqual(decimal.Zero) {
+		return
+	}
+
+	if e.method == MethodAverage && len(e.lots[asset]) > 0 {
+		pool := e.lots[asset][0]
+		pool.Size = pool.Size.Add(size)
+		pool.Remaining = pool.Remaining.Add(size)
+		pool.CostBasis = pool.CostBasis.Add(cost)
+		return
+	}
+
+	e.lots[asset] = append(e.lots[asset], &Lot{
+		Asset:     asset,
+		Market:    f.Market,
+		FillID:    f.ID,
+		Acquired:  f.Time,
+		Size:      size,
+		Remaining: size,
+		CostBasis: cost,
+	})
+}
+
+// dispose matches size against open lots and records one Disposal per lot touched.
+// proceeds and fee are allocated to each Disposal in proportion to the size matched.
+func (e *Engine) dispose(asset string, f *model.Fill, size, proceeds, fee decimal.Decimal) {
+	if size.LessThanOrEqual(decimal.Zero) {
+		return
+	}
+
+	left := size
+	for _, lot := range e.order(asset) {
+		if left.IsZero() {
+			break
+		}
+		matched := decimal.Min(left, lot.Remaining)
+		acquired := lot.Acquired
+		cost := lot.take(matched)
+		e.record(asset, f, acquired, matched, size, proceeds, fee, cost, false)
+		left = left.Sub(matched)
+	}
+	e.prune(asset)
+
+	if left.GreaterThan(decimal.Zero) {
+		e.record(asset, f, time.Time{}, left, size, proceeds, fee, decimal.Zero, true)
+	}
+}
+
+func (e *Engine) record(asset string, f *model.Fill, acquired time.Time, matched, total, proceeds, fee, cost decimal.Decimal, unmatched bool) {
+	share := proceeds.Mul(matched).Div(total)
+	e.disposals = append(e.disposals, &Disposal{
+		Asset:     asset,
+		Market:    f.Market,
+		FillID:    f.ID,
+		Acquired:  acquired,
+		Disposed:  f.Time,
+		Size:      matched,
+		Proceeds:  share,
+		CostBasis: cost,
+		Fee:       fee.Mul(matched).Div(total),
+		Gain:      share.Sub(cost),
+		Unmatched: unmatched,
+	})
+}
+
+// order returns the open lots of asset in the order the method consumes them.
+func (e *Engine) order(asset string) []*Lot {
+	lots := make([]*Lot, len(e.lots[asset]))
+	copy(lots, e.lots[asset])
+
+	switch e.method {
+	case MethodLIFO:
+		sort.SliceStable(lots, func(i, j int) bool {
+			return lots[i].Acquired.After(lots[j].Acquired)
+		})
+	case MethodHIFO:
+		sort.SliceStable(lots, func(i, j int) bool {
+			return lots[i].UnitCost().GreaterThan(lots[j].UnitCost())
+		})
+	default:
+		sort.SliceStable(lots, func(i, j int) bool {
+			return lots[i].Acquired.Before(lots[j].Acquired)
+		})
+	}
+	return lots
+}
+
+func (e *Engine) prune(asset string) {
+	open := e.lots[asset][:0]
+	for _, lot := range e.lots[asset] {
+		if lot.Remaining.GreaterThan(decimal.Zero) {
+			open = append(open, lot)
+		}
+	}
+	if len(open) == 0 {
+		delete(e.lots, asset)
+		return
+	}
+	e.lots[asset] = open
+}
+
+// Lots returns the open lots of asset.
+func (e *Engine) Lots(asset string) []*Lot {
+	return e.order(strings.ToUpper(asset))
+}
+
+// Disposals returns every disposal recorded so far in processing order.
+func (e *Engine) Disposals() []*Disposal {
+	return e.disposals
+}
+
+// RealizedPnl returns the total realized gain per asset.
+func (e *Engine) RealizedPnl() map[string]decimal.Decimal {
+	res := make(map[string]decimal.Decimal)
+	for _, d := range e.disposals {
+		res[d.Asset] = res[d.Asset].Add(d.Gain)
+	}
+	return res
+}
@@ -0,0 +1,199 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fill(id string, side model.OrderSide, size, price, fee string, hour int) *model.Fill {
+	return &model.Fill{
+		ID:         id,
+		Market:     "AVAX-USDC",
+		Side:       side,
+		Size:       d(size),
+		Price:      d(price),
+		FilledCost: d(size).Mul(d(price)),
+		Fee:        d(fee),
+		Time:       start.Add(time.Duration(hour) * time.Hour),
+	}
+}
+
+func TestLotMatching(t *testing.T) {
+	// Three lots at unit costs of 10, 30 and 20, then one sale splitting a lot.
+	fills := []*model.Fill{
+		fill("sell", model.OrderSideSell, "1.5", "25", "0", 3),
+		fill("b1", model.OrderSideBuy, "1", "10", "0", 0),
+		fill("b2", model.OrderSideBuy, "1", "30", "0", 1),
+		fill("b3", model.OrderSideBuy, "1", "20", "0", 2),
+	}
+
+	tests := []struct {
+		method        Method
+		wantSizes     []string // Size of each disposal
+		wantGains     []string // Gain of each disposal
+		wantRemaining []string // Remaining size of each open lot, in matching order
+		wantCosts     []string // Cost basis of each open lot
+	}{
+		{
+			method:        MethodFIFO,
+			wantSizes:     []string{"1", "0.5"},
+			wantGains:     []string{"15", "-2.5"},
+			wantRemaining: []string{"0.5", "1"},
+			wantCosts:     []string{"15", "20"},
+		},
+		{
+			method:        MethodLIFO,
+			wantSizes:     []string{"1", "0.5"},
+			wantGains:     []string{"5", "-2.5"},
+			wantRemaining: []string{"0.5", "1"},
+			wantCosts:     []string{"15", "10"},
+		},
+		{
+			method:        MethodHIFO,
+			wantSizes:     []string{"1", "0.5"},
+			wantGains:     []string{"-5", "2.5"},
+			wantRemaining: []string{"0.5", "1"},
+			wantCosts:     []string{"10", "10"},
+		},
+		{
+			method:        MethodAverage,
+			wantSizes:     []string{"1.5"},
+			wantGains:     []string{"7.5"},
+			wantRemaining: []string{"1.5"},
+			wantCosts:     []string{"30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			e := NewEngine(tt.method, "usdc", nil)
+			if err := e.Process(fills); err != nil {
+				t.Fatal(err)
+			}
+
+			disposals := e.Disposals()
+			if len(disposals) != len(tt.wantGains) {
+				t.Fatalf("got %d disposals, want %d", len(disposals), len(tt.wantGains))
+			}
+			for i, dp := range disposals {
+				if !dp.Size.Equal(d(tt.wantSizes[i])) || !dp.Gain.Equal(d(tt.wantGains[i])) {
+					t.Errorf("disposal %d: size %s gain %s, want size %s gain %s", i, dp.Size, dp.Gain, tt.wantSizes[i], tt.wantGains[i])
+				}
+			}
+
+			lots := e.Lots("avax")
+			if len(lots) != len(tt.wantRemaining) {
+				t.Fatalf("got %d open lots, want %d", len(lots), len(tt.wantRemaining))
+			}
+			for i, lot := range lots {
+				if !lot.Remaining.Equal(d(tt.wantRemaining[i])) || !lot.CostBasis.Equal(d(tt.wantCosts[i])) {
+					t.Errorf("lot %d: remaining %s cost %s, want remaining %s cost %s", i, lot.Remaining, lot.CostBasis, tt.wantRemaining[i], tt.wantCosts[i])
+				}
+			}
+
+			total := decimal.Zero
+			for _, g := range tt.wantGains {
+				total = total.Add(d(g))
+			}
+			if got := e.RealizedPnl()["AVAX"]; !got.Equal(total) {
+				t.Errorf("RealizedPnl() = %s, want %s", got, total)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name          string
+		fills         []*model.Fill
+		wantGains     []string
+		wantFees      []string
+		wantUnmatched []bool
+	}{
+		{
+			name: "fees raise cost and reduce proceeds",
+			fills: []*model.Fill{
+				fill("b1", model.OrderSideBuy, "1", "10", "0.1", 0),
+				fill("s1", model.OrderSideSell, "1", "20", "0.2", 1),
+			},
+			wantGains:     []string{"9.7"},
+			wantFees:      []string{"0.2"},
+			wantUnmatched: []bool{false},
+		},
+		{
+			name: "fee is allocated across split lots",
+			fills: []*model.Fill{
+				fill("b1", model.OrderSideBuy, "1", "10", "0", 0),
+				fill("b2", model.OrderSideBuy, "1", "10", "0", 1),
+				fill("s1", model.OrderSideSell, "2", "15", "1", 2),
+			},
+			wantGains:     []string{"4.5", "4.5"},
+			wantFees:      []string{"0.5", "0.5"},
+			wantUnmatched: []bool{false, false},
+		},
+		{
+			name: "sale beyond open lots is unmatched",
+			fills: []*model.Fill{
+				fill("b1", model.OrderSideBuy, "1", "10", "0", 0),
+				fill("s1", model.OrderSideSell, "3", "20", "0", 1),
+			},
+			wantGains:     []string{"10", "40"},
+			wantFees:      []string{"0", "0"},
+			wantUnmatched: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(MethodFIFO, "USDC", nil)
+			if err := e.Process(tt.fills); err != nil {
+				t.Fatal(err)
+			}
+			disposals := e.Disposals()
+			if len(disposals) != len(tt.wantGains) {
+				t.Fatalf("got %d disposals, want %d", len(disposals), len(tt.wantGains))
+			}
+			for i, dp := range disposals {
+				if !dp.Gain.Equal(d(tt.wantGains[i])) || !dp.Fee.Equal(d(tt.wantFees[i])) || dp.Unmatched != tt.wantUnmatched[i] {
+					t.Errorf("disposal %d = %+v, want gain %s fee %s unmatched %v", i, dp, tt.wantGains[i], tt.wantFees[i], tt.wantUnmatched[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProcessCrossQuote(t *testing.T) {
+	rate := func(asset string, at time.Time) (decimal.Decimal, error) {
+		return d("2000"), nil
+	}
+	buyETH := fill("b1", model.OrderSideBuy, "0.01", "2000", "0", 0)
+	buyETH.Market = "ETH-USDC"
+	buyAVAX := fill("b2", model.OrderSideBuy, "1", "0.015", "0", 1)
+	buyAVAX.Market = "AVAX-ETH"
+
+	e := NewEngine(MethodFIFO, "USDC", rate)
+	if err := e.Process([]*model.Fill{buyETH, buyAVAX}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paying 0.015 ETH worth 30 USDC disposes of the 0.01 ETH lot bought for 20 and 0.005 ETH without a lot.
+	disposals := e.Disposals()
+	if len(disposals) != 2 || disposals[0].Asset != "ETH" || !disposals[0].Gain.Equal(d("0")) || !disposals[1].Unmatched {
+		t.Fatalf("disposals = %+v, want a matched and an unmatched ETH disposal", disposals)
+	}
+	lots := e.Lots("AVAX")
+	if len(lots) != 1 || !lots[0].CostBasis.Equal(d("30")) {
+		t.Fatalf("AVAX lots = %+v, want one lot costing 30", lots)
+	}
+	if err := NewEngine(MethodFIFO, "USDC", nil).Process([]*model.Fill{buyAVAX}); err == nil {
+		t.Fatal("processing an ETH-quoted fill without a rate succeeded")
+	}
+}
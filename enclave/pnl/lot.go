@@ -0,0 +1,75 @@
+package pnl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Method string
+
+const (
+	MethodFIFO    Method = "fifo"    // First in, first out
+	MethodLIFO    Method = "lifo"    // Last in, first out
+	MethodHIFO    Method = "hifo"    // Highest unit cost first
+	MethodAverage Method = "average" // Pooled average cost
+)
+
+// ParseMethod returns the Method matching s.
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case MethodFIFO, MethodLIFO, MethodHIFO, MethodAverage:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown cost basis method %q", s)
+	}
+}
+
+// Lot is an open tax lot of a single asset.
+type Lot struct {
+	Asset     string          `json:"asset"`     // Asset held in the lot, e.g., "AVAX"
+	Market    string          `json:"market"`    // Market the lot was acquired in, e.g., "AVAX-USDC"
+	FillID    string          `json:"fillId"`    // ID of the fill that opened the lot
+	Acquired  time.Time       `json:"acquired"`  // Time the lot was acquired
+	Size      decimal.Decimal `json:"size"`      // Size originally acquired
+	Remaining decimal.Decimal `json:"remaining"` // Size not yet disposed of
+	CostBasis decimal.Decimal `json:"costBasis"` // Cost basis of the remaining size, fees included
+}
+
+// UnitCost returns the cost basis per unit of the remaining size.
+func (l *Lot) UnitCost() decimal.Decimal {
+	if l.Remaining.IsZero() {
+		return decimal.Zero
+	}
+	return l.CostBasis.Div(l.Remaining)
+}
+
+// take removes size from the lot and returns the cost basis attributed to it.
+func (l *Lot) take(size decimal.Decimal) decimal.Decimal {
+	if size.GreaterThanOrEqual(l.Remaining) {
+		cost := l.CostBasis
+		l.Remaining = decimal.Zero
+		l.CostBasis = decimal.Zero
+		return cost
+	}
+	cost := l.CostBasis.Mul(size).Div(l.Remaining)
+	l.Remaining = l.Remaining.Sub(size)
+	l.CostBasis = l.CostBasis.Sub(cost)
+	return cost
+}
+
+// Disposal is a realized gain or loss from disposing of part or all of a lot.
+type Disposal struct {
+	Asset     string          `json:"asset"`     // Asset disposed of, e.g., "AVAX"
+	Market    string          `json:"market"`    // Market the disposal happened in
+	FillID    string          `json:"fillId"`    // ID of the fill that disposed of the asset
+	Acquired  time.Time       `json:"acquired"`  // Time the matched lot was acquired, zero if unmatched
+	Disposed  time.Time       `json:"disposed"`  // Time of the disposal
+	Size      decimal.Decimal `json:"size"`      // Size disposed of
+	Proceeds  decimal.Decimal `json:"proceeds"`  // Proceeds net of the allocated fee
+	CostBasis decimal.Decimal `json:"costBasis"` // Cost basis of the matched lot portion
+	Fee       decimal.Decimal `json:"fee"`       // Fee allocated to this disposal
+	Gain      decimal.Decimal `json:"gain"`      // Proceeds minus cost basis
+	Unmatched bool            `json:"unmatched"` // Whether no open lot covered the size, e.g., due to missing history
+}
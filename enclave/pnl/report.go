@@ -0,0 +1,45 @@
+package pnl
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+var disposalHeader = []string{
+	"asset", "market", "fill_id", "size", "acquired", "disposed", "proceeds", "cost_basis", "fee", "gain",
+}
+
+// WriteDisposalsCSV writes a disposal report with one row per Disposal.
+// The acquired date is left empty for unmatched disposals.
+func WriteDisposalsCSV(w io.Writer, disposals []*Disposal) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(disposalHeader); err != nil {
+		return err
+	}
+
+	for _, d := range disposals {
+		acquired := ""
+		if !d.Unmatched {
+			acquired = d.Acquired.UTC().Format(time.RFC3339)
+		}
+		row := []string{
+			d.Asset,
+			d.Market,
+			d.FillID,
+			d.Size.String(),
+			acquired,
+			d.Disposed.UTC().Format(time.RFC3339),
+			d.Proceeds.StringFixed(8),
+			d.CostBasis.StringFixed(8),
+			d.Fee.StringFixed(8),
+			d.Gain.StringFixed(8),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
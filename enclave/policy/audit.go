@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// AuditRecord describes one order checked by an Engine, allowed or not.
+type AuditRecord struct {
+	Time      time.Time       `json:"time"`             // Time of the check
+	Policy    string          `json:"policy"`           // Name of the policy in effect
+	Market    string          `json:"market"`           // Market of the order
+	Side      model.OrderSide `json:"side"`             // Side of the order
+	Type      model.OrderType `json:"type,omitempty"`   // Type of the order
+	Size      decimal.Decimal `json:"size"`             // Base size of the order, zero for quote-sized market orders
+	QuoteSize decimal.Decimal `json:"quoteSize"`        // Quote size of market orders
+	Price     decimal.Decimal `json:"price"`            // Limit price, zero for market orders
+	Allowed   bool            `json:"allowed"`          // Whether the order was sent
+	Rule      Rule            `json:"rule,omitempty"`   // Broken rule of a rejected order
+	Detail    string          `json:"detail,omitempty"` // Description of the violation
+	Error     string          `json:"error,omitempty"`  // Error that prevented the check, the order is then rejected
+}
+
+// Auditor persists every AuditRecord produced by an Engine.
+type Auditor interface {
+	Record(rec *AuditRecord) error
+}
+
+// FileAuditor appends audit records as JSON lines to a file.
+type FileAuditor struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAuditor initializes a FileAuditor appending to path.
+func NewFileAuditor(path string) *FileAuditor {
+	return &FileAuditor{path: path}
+}
+
+func (a *FileAuditor) Record(rec *AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
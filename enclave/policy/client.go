@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// NewSpotClient wraps sc so that AddOrder is checked by engine before the request is sent.
+// Limit prices are compared to the order book mid.
+func NewSpotClient(sc client.SpotClient, engine *Engine) client.SpotClient {
+	return &spotClient{SpotClient: sc, engine: engine}
+}
+
+// NewPerpsClient wraps pc so that AddOrder is checked by engine before the request is sent.
+// Limit prices are compared to the mark price, or the order book mid when the market has none.
+func NewPerpsClient(pc client.PerpsClient, engine *Engine) client.PerpsClient {
+	return &perpsClient{PerpsClient: pc, engine: engine}
+}
+
+// NewClient wraps the spot and perps clients of c with NewSpotClient and NewPerpsClient. Clients returned by its
+// ForSubaccount are wrapped the same way, with the same engine.
+func NewClient(c client.Client, engine *Engine) client.Client {
+	return &policyClient{
+		Client: c,
+		engine: engine,
+		sc:     NewSpotClient(c.SpotClient(), engine),
+		pc:     NewPerpsClient(c.PerpsClient(), engine),
+	}
+}
+
+type spotClient struct {
+	client.SpotClient
+	engine *Engine
+}
+
+func (c *spotClient) AddOrder(req *api.AddOrderRequest) (*model.Order, error) {
+	release, err := c.engine.Reserve(req, &source{ofc: c.SpotClient})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.SpotClient.AddOrder(req)
+}
+
+type perpsClient struct {
+	client.PerpsClient
+	engine *Engine
+}
+
+func (c *perpsClient) AddOrder(req *api.AddOrderRequest) (*model.Order, error) {
+	release, err := c.engine.Reserve(req, &source{ofc: c.PerpsClient, pc: c.PerpsClient})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.PerpsClient.AddOrder(req)
+}
+
+type policyClient struct {
+	client.Client
+	engine *Engine
+	sc     client.SpotClient
+	pc     client.PerpsClient
+}
+
+func (c *policyClient) SpotClient() client.SpotClient {
+	return c.sc
+}
+
+func (c *policyClient) PerpsClient() client.PerpsClient {
+	return c.pc
+}
+
+func (c *policyClient) ForSubaccount(subaccountID string) client.Client {
+	return NewClient(c.Client.ForSubaccount(subaccountID), c.engine)
+}
+
+// source implements Source with a spot or perps client. pc is nil for spot.
+type source struct {
+	ofc client.OrderFillClient
+	pc  client.PerpsClient
+}
+
+func (s *source) ReferencePrice(market string) (decimal.Decimal, error) {
+	if s.pc != nil {
+		marks, err := s.pc.GetMarkPrices()
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("failed to get mark prices: %w", err)
+		}
+		if mark, ok := marks[market]; ok && mark.Price.IsPositive() {
+			return mark.Price, nil
+		}
+	}
+
+	book, err := s.ofc.GetDepth(&api.GetDepthRequest{Market: market, Depth: 1})
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get depth: %w", err)
+	}
+	var bid, ask decimal.Decimal
+	if len(book.Bids) > 0 && len(book.Bids[0]) > 0 {
+		bid = book.Bids[0][0]
+	}
+	if len(book.Asks) > 0 && len(book.Asks[0]) > 0 {
+		ask = book.Asks[0][0]
+	}
+	switch {
+	case bid.IsPositive() && ask.IsPositive():
+		return bid.Add(ask).Div(decimal.NewFromInt(2)), nil
+	case bid.IsPositive():
+		return bid, nil
+	default:
+		return ask, nil
+	}
+}
+
+func (s *source) OpenOrders(market string, limit int) (int, error) {
+	req := &api.GetOrdersRequest{Market: market, Status: model.OrderStatusOpen}
+	req.Limit = limit + 1
+	resp, err := s.ofc.GetOrders(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open orders: %w", err)
+	}
+	return len(resp.Orders), nil
+}
+
+func (s *source) Position(market string) (decimal.Decimal, bool, error) {
+	if s.pc == nil {
+		return decimal.Zero, false, nil
+	}
+	positions, err := s.pc.GetPositions()
+	if err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to get positions: %w", err)
+	}
+	for _, p := range positions {
+		if p.Market != market {
+			continue
+		}
+		size := p.NetQuantity.Abs()
+		if p.Direction == model.PositionDirectionShort {
+			size = size.Neg()
+		}
+		return size, true, nil
+	}
+	return decimal.Zero, true, nil
+}
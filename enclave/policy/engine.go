@@ -0,0 +1,288 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Source provides the account and market state that some rules depend on. It is only queried when a rule needs it.
+type Source interface {
+	// ReferencePrice returns the price limit prices are compared to, zero if there is none.
+	ReferencePrice(market string) (decimal.Decimal, error)
+	// OpenOrders returns the number of open orders on market; counting may stop once it exceeds limit.
+	OpenOrders(market string, limit int) (int, error)
+	// Position returns the signed position on market, positive for long. ok is false for markets without positions.
+	Position(market string) (size decimal.Decimal, ok bool, err error)
+}
+
+// Engine checks orders against the current Policy, which may be replaced at any time.
+type Engine struct {
+	mu      sync.RWMutex
+	policy  *Policy
+	auditor Auditor
+	now     func() time.Time
+
+	// slotsMu serializes counting and reserving open order slots; placing holds the reserved slots by market.
+	slotsMu sync.Mutex
+	placing map[string]int
+}
+
+// NewEngine initializes an Engine enforcing p. auditor may be nil.
+func NewEngine(p *Policy, auditor Auditor) *Engine {
+	return &Engine{policy: p, auditor: auditor, now: time.Now, placing: make(map[string]int)}
+}
+
+// Policy returns the policy in effect.
+func (e *Engine) Policy() *Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// SetPolicy replaces the policy in effect. Checks already running finish with the previous policy.
+func (e *Engine) SetPolicy(p *Policy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policy = p
+}
+
+// Watch reloads the policy from path whenever the file's modification time changes, until ctx is done.
+// A file that fails to load is reported to onError and the previous policy stays in effect.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if onError != nil {
+				onError(fmt.Errorf("failed to stat policy: %w", err))
+			}
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+
+		p, err := LoadFile(path)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+		e.SetPolicy(p)
+	}
+}
+
+// Check returns a *Violation if req breaks the policy in effect. Orders are also rejected when the state a rule
+// needs cannot be fetched, or when the check cannot be audited.
+func (e *Engine) Check(req *api.AddOrderRequest, src Source) error {
+	release, err := e.Reserve(req, src)
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// Reserve checks req like Check and, when it is allowed, holds one of the market's MaxOpenOrders slots until release
+// is called, so that orders placed concurrently cannot together exceed the limit. Call release once the order has
+// been sent, whether or not it was accepted.
+func (e *Engine) Reserve(req *api.AddOrderRequest, src Source) (release func(), err error) {
+	p := e.Policy()
+
+	// Counting open orders and reserving a slot happen under slotsMu, so concurrent checks see each other's slots.
+	limited := p.Allows(req.Market) && p.LimitsFor(req.Market).MaxOpenOrders > 0
+	if limited {
+		e.slotsMu.Lock()
+		defer e.slotsMu.Unlock()
+	}
+	violation, err := check(p, req, src, e.placing[req.Market])
+
+	rec := &AuditRecord{
+		Time:      e.now(),
+		Policy:    p.Name,
+		Market:    req.Market,
+		Side:      req.Side,
+		Type:      req.Type,
+		Size:      req.Size,
+		QuoteSize: req.QuoteSize,
+		Price:     req.Price,
+		Allowed:   violation == nil && err == nil,
+	}
+	if violation != nil {
+		rec.Rule, rec.Detail = violation.Rule, violation.Detail
+	}
+	if err != nil {
+		err = fmt.Errorf("policy %s could not check order on %s: %w", p.Name, req.Market, err)
+		rec.Error = err.Error()
+	}
+	if e.auditor != nil {
+		if auditErr := e.auditor.Record(rec); auditErr != nil {
+			return nil, fmt.Errorf("failed to audit order on %s: %w", req.Market, auditErr)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if violation != nil {
+		return nil, violation
+	}
+	if !limited {
+		return func() {}, nil
+	}
+
+	e.placing[req.Market]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			e.slotsMu.Lock()
+			defer e.slotsMu.Unlock()
+			if e.placing[req.Market]--; e.placing[req.Market] <= 0 {
+				delete(e.placing, req.Market)
+			}
+		})
+	}, nil
+}
+
+// check applies the rules of p to req. placing is the number of orders on the market that passed a check and are
+// still being sent; they count against MaxOpenOrders.
+func check(p *Policy, req *api.AddOrderRequest, src Source, placing int) (*Violation, error) {
+	violation := func(rule Rule, limit, value decimal.Decimal, format string, args ...any) *Violation {
+		return &Violation{
+			Policy: p.Name,
+			Rule:   rule,
+			Market: req.Market,
+			Limit:  limit,
+			Value:  value,
+			Detail: fmt.Sprintf(format, args...),
+		}
+	}
+
+	if !p.Allows(req.Market) {
+		return violation(RuleMarketNotAllowed, decimal.Zero, decimal.Zero, "market is not allowed"), nil
+	}
+	limits := p.LimitsFor(req.Market)
+
+	var (
+		ref    decimal.Decimal
+		refErr error
+		refSet bool
+	)
+	reference := func() (decimal.Decimal, error) {
+		if !refSet {
+			ref, refErr = src.ReferencePrice(req.Market)
+			refSet = true
+		}
+		return ref, refErr
+	}
+
+	// Market orders sized in the quote asset are converted at the reference price.
+	size := req.Size
+	if size.IsZero() && req.QuoteSize.IsPositive() && (limits.MaxOrderSize != nil || limits.MaxPosition != nil) {
+		price, err := reference()
+		if err != nil {
+			return nil, err
+		}
+		if !price.IsPositive() {
+			return violation(RulePriceBand, decimal.Zero, decimal.Zero, "no reference price to size the order"), nil
+		}
+		size = req.QuoteSize.Div(price)
+	}
+
+	if limits.MaxOrderSize != nil && size.GreaterThan(*limits.MaxOrderSize) {
+		return violation(RuleMaxOrderSize, *limits.MaxOrderSize, size,
+			"size %s exceeds %s", size, limits.MaxOrderSize), nil
+	}
+
+	if limits.MaxNotional != nil {
+		notional := req.QuoteSize
+		if !req.Size.IsZero() {
+			price := req.Price
+			if req.Type == model.OrderTypeMarket || price.IsZero() {
+				var err error
+				if price, err = reference(); err != nil {
+					return nil, err
+				}
+			}
+			notional = req.Size.Mul(price)
+		}
+		if notional.GreaterThan(*limits.MaxNotional) {
+			return violation(RuleMaxNotional, *limits.MaxNotional, notional,
+				"notional %s exceeds %s", notional, limits.MaxNotional), nil
+		}
+	}
+
+	if limits.PriceBand != nil && req.Type != model.OrderTypeMarket && !req.Price.IsZero() {
+		price, err := reference()
+		if err != nil {
+			return nil, err
+		}
+		if !price.IsPositive() {
+			return violation(RulePriceBand, *limits.PriceBand, decimal.Zero, "no reference price to check %s against", req.Price), nil
+		}
+		deviation := req.Price.Sub(price).Abs().Div(price)
+		if deviation.GreaterThan(*limits.PriceBand) {
+			return violation(RulePriceBand, *limits.PriceBand, deviation,
+				"price %s is %s%% away from reference %s, more than %s%%",
+				req.Price, deviation.Shift(2).StringFixed(2), price, limits.PriceBand.Shift(2)), nil
+		}
+	}
+
+	if limits.MaxOpenOrders > 0 {
+		n, err := src.OpenOrders(req.Market, limits.MaxOpenOrders)
+		if err != nil {
+			return nil, err
+		}
+		n += placing
+		if n >= limits.MaxOpenOrders {
+			return violation(RuleMaxOpenOrders, decimal.NewFromInt(int64(limits.MaxOpenOrders)), decimal.NewFromInt(int64(n)),
+				"%d orders are already open or being placed, the limit is %d", n, limits.MaxOpenOrders), nil
+		}
+	}
+
+	if limits.MaxPosition != nil {
+		current, ok, err := src.Position(req.Market)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			next := current.Add(size)
+			if req.Side == model.OrderSideSell {
+				next = current.Sub(size)
+			}
+			// Orders reducing the position are always allowed, even when it is already over the limit.
+			if next.Abs().GreaterThan(*limits.MaxPosition) && next.Abs().GreaterThan(current.Abs()) {
+				return violation(RuleMaxPosition, *limits.MaxPosition, next.Abs(),
+					"position would grow from %s to %s, more than %s", current, next, limits.MaxPosition), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,292 @@
+package policy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+func limit(s string) *decimal.Decimal {
+	v := d(s)
+	return &v
+}
+
+// fakeSource serves fixed state. open is guarded by mu so that tests can land orders concurrently.
+type fakeSource struct {
+	ref      decimal.Decimal
+	position *decimal.Decimal // nil for markets without positions
+	err      error
+
+	mu   sync.Mutex
+	open int
+}
+
+func (s *fakeSource) ReferencePrice(string) (decimal.Decimal, error) {
+	return s.ref, s.err
+}
+
+func (s *fakeSource) OpenOrders(string, int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open, s.err
+}
+
+func (s *fakeSource) Position(string) (decimal.Decimal, bool, error) {
+	if s.position == nil {
+		return decimal.Zero, false, s.err
+	}
+	return *s.position, true, s.err
+}
+
+type recordingAuditor struct {
+	records []*AuditRecord
+	err     error
+}
+
+func (a *recordingAuditor) Record(rec *AuditRecord) error {
+	a.records = append(a.records, rec)
+	return a.err
+}
+
+func testPolicy() *Policy {
+	return &Policy{
+		Name:           "test",
+		AllowedMarkets: []string{"AVAX-USDC", "AVAX-USD.P"},
+		Default: Limits{
+			MaxOrderSize:  limit("20"),
+			MaxNotional:   limit("500"),
+			MaxOpenOrders: 3,
+			PriceBand:     limit("0.05"),
+		},
+		Markets: map[string]*Limits{
+			"AVAX-USD.P": {MaxPosition: limit("100")},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	limitOrder := func(market string, side model.OrderSide, size, price string) *api.AddOrderRequest {
+		return &api.AddOrderRequest{Market: market, Side: side, Type: model.OrderTypeLimit, Size: d(size), Price: d(price)}
+	}
+
+	tests := []struct {
+		name     string
+		req      *api.AddOrderRequest
+		src      *fakeSource
+		wantRule Rule // Empty when the order is allowed
+		wantErr  bool
+	}{
+		{
+			name: "allowed",
+			req:  limitOrder("AVAX-USDC", model.OrderSideBuy, "10", "20"),
+			src:  &fakeSource{ref: d("20"), open: 2},
+		},
+		{
+			name:     "market not allowed",
+			req:      limitOrder("ETH-USDC", model.OrderSideBuy, "1", "2000"),
+			src:      &fakeSource{ref: d("2000")},
+			wantRule: RuleMarketNotAllowed,
+		},
+		{
+			name:     "order size above the limit",
+			req:      limitOrder("AVAX-USDC", model.OrderSideBuy, "21", "20"),
+			src:      &fakeSource{ref: d("20")},
+			wantRule: RuleMaxOrderSize,
+		},
+		{
+			name:     "quote size converted above the size limit",
+			req:      &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Type: model.OrderTypeMarket, QuoteSize: d("450")},
+			src:      &fakeSource{ref: d("10")},
+			wantRule: RuleMaxOrderSize,
+		},
+		{
+			name: "quote size converted within the size limit",
+			req:  &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Type: model.OrderTypeMarket, QuoteSize: d("300")},
+			src:  &fakeSource{ref: d("20")},
+		},
+		{
+			name:     "quote size without a reference price",
+			req:      &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Type: model.OrderTypeMarket, QuoteSize: d("300")},
+			src:      &fakeSource{},
+			wantRule: RulePriceBand,
+		},
+		{
+			name:     "notional above the limit",
+			req:      limitOrder("AVAX-USDC", model.OrderSideBuy, "20", "26"),
+			src:      &fakeSource{ref: d("26")},
+			wantRule: RuleMaxNotional,
+		},
+		{
+			name:     "market order notional at the reference price",
+			req:      &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideSell, Type: model.OrderTypeMarket, Size: d("20")},
+			src:      &fakeSource{ref: d("26")},
+			wantRule: RuleMaxNotional,
+		},
+		{
+			name:     "price outside the band",
+			req:      limitOrder("AVAX-USDC", model.OrderSideBuy, "10", "21.5"),
+			src:      &fakeSource{ref: d("20")},
+			wantRule: RulePriceBand,
+		},
+		{
+			name:     "limit price without a reference price",
+			req:      limitOrder("AVAX-USDC", model.OrderSideBuy, "10", "20"),
+			src:      &fakeSource{},
+			wantRule: RulePriceBand,
+		},
+		{
+			name:     "open orders at the limit",
+			req:      limitOrder("AVAX-USDC", model.OrderSideBuy, "10", "20"),
+			src:      &fakeSource{ref: d("20"), open: 3},
+			wantRule: RuleMaxOpenOrders,
+		},
+		{
+			name:     "position would grow above the limit",
+			req:      limitOrder("AVAX-USD.P", model.OrderSideBuy, "15", "20"),
+			src:      &fakeSource{ref: d("20"), position: limit("90")},
+			wantRule: RuleMaxPosition,
+		},
+		{
+			name:     "short position would grow above the limit",
+			req:      limitOrder("AVAX-USD.P", model.OrderSideSell, "15", "20"),
+			src:      &fakeSource{ref: d("20"), position: limit("-90")},
+			wantRule: RuleMaxPosition,
+		},
+		{
+			name: "reducing a position above the limit",
+			req:  limitOrder("AVAX-USD.P", model.OrderSideSell, "15", "20"),
+			src:  &fakeSource{ref: d("20"), position: limit("150")},
+		},
+		{
+			name: "flipping a position within the limit",
+			req:  limitOrder("AVAX-USD.P", model.OrderSideSell, "20", "20"),
+			src:  &fakeSource{ref: d("20"), position: limit("90")},
+		},
+		{
+			name:    "state cannot be fetched",
+			req:     limitOrder("AVAX-USDC", model.OrderSideBuy, "10", "20"),
+			src:     &fakeSource{err: errors.New("503 service unavailable")},
+			wantErr: true,
+		},
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditor := &recordingAuditor{}
+			e := NewEngine(testPolicy(), auditor)
+			e.now = func() time.Time { return now }
+
+			err := e.Check(tt.req, tt.src)
+			var violation *Violation
+			switch {
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrViolation) {
+					t.Fatalf("Check() error = %v, want a non-violation error", err)
+				}
+			case tt.wantRule != "":
+				if !errors.As(err, &violation) || violation.Rule != tt.wantRule {
+					t.Fatalf("Check() error = %v, want a %s violation", err, tt.wantRule)
+				}
+				if violation.Policy != "test" || violation.Market != tt.req.Market {
+					t.Errorf("violation = %+v, want policy test on %s", violation, tt.req.Market)
+				}
+			case err != nil:
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			if len(auditor.records) != 1 {
+				t.Fatalf("got %d audit records, want 1", len(auditor.records))
+			}
+			rec := auditor.records[0]
+			allowed := !tt.wantErr && tt.wantRule == ""
+			if rec.Allowed != allowed || rec.Rule != tt.wantRule || rec.Policy != "test" || rec.Market != tt.req.Market || !rec.Time.Equal(now) {
+				t.Errorf("audit record = %+v, want allowed %v rule %q", rec, allowed, tt.wantRule)
+			}
+			if (rec.Error != "") != tt.wantErr {
+				t.Errorf("audit record error = %q, want an error %v", rec.Error, tt.wantErr)
+			}
+			if violation != nil && rec.Detail != violation.Detail {
+				t.Errorf("audit record detail = %q, want %q", rec.Detail, violation.Detail)
+			}
+		})
+	}
+}
+
+func TestCheckAuditFailure(t *testing.T) {
+	auditErr := errors.New("disk full")
+	e := NewEngine(testPolicy(), &recordingAuditor{err: auditErr})
+
+	req := &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Type: model.OrderTypeLimit, Size: d("10"), Price: d("20")}
+	if err := e.Check(req, &fakeSource{ref: d("20")}); !errors.Is(err, auditErr) {
+		t.Fatalf("Check() error = %v, want %v", err, auditErr)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	e := NewEngine(testPolicy(), nil)
+	src := &fakeSource{ref: d("20")}
+	req := &api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Type: model.OrderTypeLimit, Size: d("1"), Price: d("20")}
+
+	// Orders being placed hold their slots until released.
+	var releases []func()
+	for i := 0; i < 3; i++ {
+		release, err := e.Reserve(req, src)
+		if err != nil {
+			t.Fatalf("Reserve() %d error = %v", i, err)
+		}
+		releases = append(releases, release)
+	}
+	if _, err := e.Reserve(req, src); !errors.Is(err, ErrViolation) {
+		t.Fatalf("Reserve() beyond the limit error = %v, want a violation", err)
+	}
+	releases[0]()
+	releases[0]()
+	if _, err := e.Reserve(req, src); err != nil {
+		t.Fatalf("Reserve() after a release error = %v", err)
+	}
+
+	// Orders placed concurrently never exceed the limit, each landing before its slot is released.
+	e = NewEngine(testPolicy(), nil)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		placed  int
+		maxOpen int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := e.Reserve(req, src)
+			if err != nil {
+				return
+			}
+			defer release()
+
+			src.mu.Lock()
+			src.open++
+			open := src.open
+			src.mu.Unlock()
+
+			mu.Lock()
+			placed++
+			if open > maxOpen {
+				maxOpen = open
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if placed != 3 || maxOpen != 3 {
+		t.Fatalf("placed %d orders with up to %d open, want 3 with up to 3", placed, maxOpen)
+	}
+}
@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Limits bound individual orders. Unset limits are not enforced.
+type Limits struct {
+	MaxOrderSize  *decimal.Decimal `yaml:"max_order_size"`  // Maximum size of one order in the base asset
+	MaxNotional   *decimal.Decimal `yaml:"max_notional"`    // Maximum size × price of one order in the quote asset
+	MaxOpenOrders int              `yaml:"max_open_orders"` // Maximum number of open orders per market, 0 for no limit
+	MaxPosition   *decimal.Decimal `yaml:"max_position"`    // Maximum absolute perps position once the order fills
+	PriceBand     *decimal.Decimal `yaml:"price_band"`      // Maximum relative distance of a limit price from the reference price, e.g., 0.05 for 5%
+}
+
+// merge returns l with every limit set in override replaced.
+func (l Limits) merge(override *Limits) Limits {
+	if override == nil {
+		return l
+	}
+	if override.MaxOrderSize != nil {
+		l.MaxOrderSize = override.MaxOrderSize
+	}
+	if override.MaxNotional != nil {
+		l.MaxNotional = override.MaxNotional
+	}
+	if override.MaxOpenOrders != 0 {
+		l.MaxOpenOrders = override.MaxOpenOrders
+	}
+	if override.MaxPosition != nil {
+		l.MaxPosition = override.MaxPosition
+	}
+	if override.PriceBand != nil {
+		l.PriceBand = override.PriceBand
+	}
+	return l
+}
+
+// Policy declares the trading limits of one strategy, e.g.:
+//
+//	name: avax-mm
+//	allowed_markets: [AVAX-USDC, AVAX-USD.P]
+//	default:
+//	  max_order_size: 100
+//	  max_notional: 5000
+//	  max_open_orders: 10
+//	  price_band: 0.05
+//	markets:
+//	  AVAX-USD.P:
+//	    max_position: 500
+type Policy struct {
+	Name           string             `yaml:"name"`            // Name recorded in audit records
+	AllowedMarkets []string           `yaml:"allowed_markets"` // Markets orders may be placed on, empty to allow all
+	Default        Limits             `yaml:"default"`         // Limits applied to every market
+	Markets        map[string]*Limits `yaml:"markets"`         // Per-market limits overriding Default
+}
+
+// Parse decodes a YAML policy. Unknown keys are rejected so that typos do not silently disable a limit.
+func Parse(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var p Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadFile reads a YAML policy from path.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+func (p *Policy) validate() error {
+	check := func(name string, l *Limits) error {
+		for _, d := range []*decimal.Decimal{l.MaxOrderSize, l.MaxNotional, l.MaxPosition, l.PriceBand} {
+			if d != nil && d.IsNegative() {
+				return fmt.Errorf("policy %s: negative limit in %s", p.Name, name)
+			}
+		}
+		if l.MaxOpenOrders < 0 {
+			return fmt.Errorf("policy %s: negative max_open_orders in %s", p.Name, name)
+		}
+		return nil
+	}
+
+	if err := check("default", &p.Default); err != nil {
+		return err
+	}
+	for market, l := range p.Markets {
+		if l == nil {
+			continue
+		}
+		if err := check(market, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allows reports whether orders may be placed on market.
+func (p *Policy) Allows(market string) bool {
+	if len(p.AllowedMarkets) == 0 {
+		return true
+	}
+	for _, m := range p.AllowedMarkets {
+		if strings.EqualFold(m, market) {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitsFor returns the limits that apply to market.
+func (p *Policy) LimitsFor(market string) Limits {
+	if l, ok := p.Markets[market]; ok {
+		return p.Default.merge(l)
+	}
+	for m, l := range p.Markets {
+		if strings.EqualFold(m, market) {
+			return p.Default.merge(l)
+		}
+	}
+	return p.Default
+}
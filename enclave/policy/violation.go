@@ -0,0 +1,40 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrViolation matches every *Violation with errors.Is.
+var ErrViolation = errors.New("policy violation")
+
+type Rule string
+
+const (
+	RuleMarketNotAllowed Rule = "marketNotAllowed" // The market is not in AllowedMarkets
+	RuleMaxOrderSize     Rule = "maxOrderSize"     // The order size exceeds MaxOrderSize
+	RuleMaxNotional      Rule = "maxNotional"      // The order notional exceeds MaxNotional
+	RuleMaxOpenOrders    Rule = "maxOpenOrders"    // The market already has MaxOpenOrders open orders
+	RuleMaxPosition      Rule = "maxPosition"      // The position would grow beyond MaxPosition
+	RulePriceBand        Rule = "priceBand"        // The limit price is too far from the reference price, or there is none
+)
+
+// Violation is returned instead of sending an order that breaks a policy.
+type Violation struct {
+	Policy string          // Name of the violated policy
+	Rule   Rule            // Rule that was broken
+	Market string          // Market of the rejected order
+	Limit  decimal.Decimal // Configured limit
+	Value  decimal.Decimal // Value that broke the limit
+	Detail string          // Human-readable description
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s: policy %s rejected order on %s: %s", ErrViolation, v.Policy, v.Market, v.Detail)
+}
+
+func (v *Violation) Is(target error) bool {
+	return target == ErrViolation
+}
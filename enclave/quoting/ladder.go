@@ -0,0 +1,204 @@
+// Package quoting builds market-making quote ladders skewed by inventory and keeps them live with minimal re-quoting.
+package quoting
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+var (
+	one       = decimal.NewFromInt(1)
+	bpsFactor = decimal.NewFromInt(10000)
+)
+
+// MarketSpec holds the increments orders of a market are rounded to. GetMarkets only describes spot markets, so
+// SpotSpec is the only source; the spec of a perps market must be filled in from the exchange's market documentation.
+type MarketSpec struct {
+	Market        string
+	TickSize      decimal.Decimal // Price increment
+	SizeIncrement decimal.Decimal // Size increment
+	MinSize       decimal.Decimal // Smallest order size, zero for none
+}
+
+// SpotSpec returns the spec of a spot market from the result of GetMarkets.
+func SpotSpec(markets *model.Market, market string) (MarketSpec, error) {
+	base, quote, ok := util.SplitTradingPair(market)
+	if !ok {
+		return MarketSpec{}, fmt.Errorf("invalid market %q", market)
+	}
+	if markets.Spot == nil {
+		return MarketSpec{}, errors.New("no spot markets")
+	}
+
+	for _, m := range markets.Spot.TradingPairs {
+		if m.Pair == nil || m.Pair.Base != base || m.Pair.Quote != quote {
+			continue
+		}
+		if m.Disabled {
+			return MarketSpec{}, fmt.Errorf("market %s is disabled", market)
+		}
+		spec := MarketSpec{Market: market, TickSize: m.QuoteIncrement, SizeIncrement: m.BaseIncrement}
+		for _, t := range markets.TokenConfig {
+			if t.Id == base {
+				spec.MinSize = t.MinOrderSize
+			}
+		}
+		return spec, nil
+	}
+	return MarketSpec{}, fmt.Errorf("unknown spot market %s", market)
+}
+
+// RoundPrice rounds price to the tick size, down for bids and up for asks, so rounding never tightens a quote.
+func (s MarketSpec) RoundPrice(price decimal.Decimal, side model.OrderSide) decimal.Decimal {
+	if !s.TickSize.IsPositive() {
+		return price
+	}
+	ticks := price.Div(s.TickSize)
+	if side == model.OrderSideBuy {
+		ticks = ticks.Floor()
+	} else {
+		ticks = ticks.Ceil()
+	}
+	return ticks.Mul(s.TickSize)
+}
+
+// RoundSize rounds size down to the size increment.
+func (s MarketSpec) RoundSize(size decimal.Decimal) decimal.Decimal {
+	if !s.SizeIncrement.IsPositive() {
+		return size
+	}
+	return size.Div(s.SizeIncrement).Floor().Mul(s.SizeIncrement)
+}
+
+// LadderConfig describes a ladder around a fair price. Spreads are in basis points of the fair price.
+type LadderConfig struct {
+	Levels       int                             // Number of levels per side
+	Spread       decimal.Decimal                 // Distance of the first level from the fair price
+	LevelSpacing decimal.Decimal                 // Additional distance of each following level
+	BaseSize     decimal.Decimal                 // Size of the first level
+	SizeCurve    func(level int) decimal.Decimal // Multiplier of BaseSize per level, starting at 0; nil for flat sizes
+
+	TargetInventory decimal.Decimal // Inventory the ladder steers towards
+	MaxInventory    decimal.Decimal // Deviation from the target at which quoting the side that adds to it stops; zero for no limit
+	MaxSkew         decimal.Decimal // Shift of the ladder at MaxInventory, proportional below it
+}
+
+func (c *LadderConfig) validate() error {
+	switch {
+	case c.Levels <= 0:
+		return errors.New("levels must be positive")
+	case !c.BaseSize.IsPositive():
+		return errors.New("base size must be positive")
+	case c.Spread.IsNegative() || c.LevelSpacing.IsNegative():
+		return errors.New("spreads must not be negative")
+	case c.MaxSkew.IsPositive() && !c.MaxInventory.IsPositive():
+		return errors.New("max skew requires max inventory")
+	}
+	return nil
+}
+
+// Level is one quote of a ladder.
+type Level struct {
+	Side  model.OrderSide `json:"side"`
+	Level int             `json:"level"` // Distance from the fair price, 0 being the closest
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+// Build returns the bids and asks of the ladder around fair for the given inventory, rounded to spec.
+// A long inventory shifts the ladder down so asks fill first, a short one shifts it up.
+// Levels smaller than the minimum size after rounding are dropped.
+func Build(cfg *LadderConfig, spec MarketSpec, fair, inventory decimal.Decimal) ([]Level, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if !fair.IsPositive() {
+		return nil, errors.New("fair price must be positive")
+	}
+
+	deviation := inventory.Sub(cfg.TargetInventory)
+	var skew decimal.Decimal
+	if cfg.MaxInventory.IsPositive() {
+		ratio := decimal.Min(decimal.Max(deviation.Div(cfg.MaxInventory), one.Neg()), one)
+		skew = ratio.Mul(cfg.MaxSkew)
+	}
+	center := fair.Mul(one.Sub(skew.Div(bpsFactor)))
+
+	quoteBids := !cfg.MaxInventory.IsPositive() || deviation.LessThan(cfg.MaxInventory)
+	quoteAsks := !cfg.MaxInventory.IsPositive() || deviation.GreaterThan(cfg.MaxInventory.Neg())
+
+	var levels []Level
+	for i := 0; i < cfg.Levels; i++ {
+		offset := cfg.Spread.Add(cfg.LevelSpacing.Mul(decimal.NewFromInt(int64(i)))).Div(bpsFactor)
+		size := cfg.BaseSize
+		if cfg.SizeCurve != nil {
+			size = size.Mul(cfg.SizeCurve(i))
+		}
+		size = spec.RoundSize(size)
+		if !size.IsPositive() || spec.MinSize.IsPositive() && size.LessThan(spec.MinSize) {
+			continue
+		}
+
+		if quoteBids {
+			price := spec.RoundPrice(center.Mul(one.Sub(offset)), model.OrderSideBuy)
+			if price.IsPositive() {
+				levels = append(levels, Level{Side: model.OrderSideBuy, Level: i, Price: price, Size: size})
+			}
+		}
+		if quoteAsks {
+			price := spec.RoundPrice(center.Mul(one.Add(offset)), model.OrderSideSell)
+			levels = append(levels, Level{Side: model.OrderSideSell, Level: i, Price: price, Size: size})
+		}
+	}
+	return levels, nil
+}
+
+// LinearSizeCurve returns a size curve growing by step times the base size per level.
+func LinearSizeCurve(step decimal.Decimal) func(int) decimal.Decimal {
+	return func(level int) decimal.Decimal {
+		return one.Add(step.Mul(decimal.NewFromInt(int64(level))))
+	}
+}
+
+// GeometricSizeCurve returns a size curve multiplying the size by factor per level.
+func GeometricSizeCurve(factor decimal.Decimal) func(int) decimal.Decimal {
+	return func(level int) decimal.Decimal {
+		return factor.Pow(decimal.NewFromInt(int64(level)))
+	}
+}
+
+// Diff matches live orders to desired levels. A live order matches a level of the same side and price whose size
+// its remaining size is within sizeTolerance, a fraction, of. Unmatched orders are to be canceled and unmatched
+// levels to be placed.
+func Diff(desired []Level, live []model.Order, sizeTolerance decimal.Decimal) (cancel []model.Order, place []Level) {
+	matched := make([]bool, len(live))
+	for _, l := range desired {
+		found := false
+		for i := range live {
+			o := &live[i]
+			if matched[i] || o.Side != l.Side || !o.Price.Equal(l.Price) {
+				continue
+			}
+			remaining := o.Size.Sub(o.FilledSize)
+			if remaining.Sub(l.Size).Abs().GreaterThan(l.Size.Mul(sizeTolerance)) {
+				continue
+			}
+			matched[i], found = true, true
+			break
+		}
+		if !found {
+			place = append(place, l)
+		}
+	}
+	for i, o := range live {
+		if !matched[i] {
+			cancel = append(cancel, o)
+		}
+	}
+	return cancel, place
+}
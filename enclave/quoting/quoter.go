@@ -0,0 +1,235 @@
+package quoting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+// Inventory returns the signed base-asset inventory held in a market.
+type Inventory interface {
+	Inventory(market string) (decimal.Decimal, error)
+}
+
+type spotInventory struct {
+	c client.Client
+}
+
+// SpotInventory returns an Inventory reading the total balance of the base asset of spot markets.
+func SpotInventory(c client.Client) Inventory {
+	return &spotInventory{c: c}
+}
+
+func (s *spotInventory) Inventory(market string) (decimal.Decimal, error) {
+	base, _, ok := util.SplitTradingPair(market)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("invalid market %q", market)
+	}
+	balances, err := s.c.GetAssetBalances()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get balances: %w", err)
+	}
+	for _, b := range balances {
+		if b.Symbol == base {
+			return decimal.NewFromString(b.TotalBalance)
+		}
+	}
+	return decimal.Zero, nil
+}
+
+type perpsInventory struct {
+	pc client.PerpsClient
+}
+
+// PerpsInventory returns an Inventory reading the net quantity of perps positions, negative for shorts.
+func PerpsInventory(pc client.PerpsClient) Inventory {
+	return &perpsInventory{pc: pc}
+}
+
+func (p *perpsInventory) Inventory(market string) (decimal.Decimal, error) {
+	positions, err := p.pc.GetPositions()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get positions: %w", err)
+	}
+	for _, pos := range positions {
+		if pos.Market != market {
+			continue
+		}
+		if pos.Direction == model.PositionDirectionShort {
+			return pos.NetQuantity.Abs().Neg(), nil
+		}
+		return pos.NetQuantity.Abs(), nil
+	}
+	return decimal.Zero, nil
+}
+
+// Config configures a Quoter.
+type Config struct {
+	Spec          MarketSpec
+	Ladder        LadderConfig
+	PostOnly      bool            // Submit quotes as post-only
+	SizeTolerance decimal.Decimal // Fraction of a level's size a live order's remaining size may differ by and still match it
+	ClientPrefix  string          // Prefix of the client order IDs of quotes; orders without it are never touched. Defaults to "mm-"
+	Batch         *oms.BatchOptions
+}
+
+// Update is the outcome of one Requote.
+type Update struct {
+	Inventory decimal.Decimal    `json:"inventory"`
+	Desired   []Level            `json:"desired"`
+	Kept      int                `json:"kept"`     // Live quotes left in place
+	Canceled  []oms.CancelResult `json:"canceled"` // Live quotes canceled
+	Placed    []oms.PlaceResult  `json:"placed"`   // Levels placed
+	Skipped   []Level            `json:"skipped"`  // Levels not placed because a quote they would duplicate or cross is still live
+}
+
+// Err returns an error joining every failed cancellation and placement, or nil.
+func (u *Update) Err() error {
+	var errs []error
+	for _, r := range u.Canceled {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel %s: %w", r.OrderID, r.Err))
+		}
+	}
+	for _, r := range u.Placed {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to place %s %s at %s: %w", r.Request.Side, r.Request.Size, r.Request.Price, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Quoter keeps a ladder of quotes live in one market, re-quoting only the levels that changed.
+type Quoter struct {
+	c   client.OrderFillClient
+	inv Inventory
+	cfg Config
+}
+
+// New initializes a Quoter placing orders through c, spot or perps, and skewing by inv.
+func New(c client.OrderFillClient, inv Inventory, cfg Config) (*Quoter, error) {
+	if cfg.Spec.Market == "" {
+		return nil, errors.New("market is required")
+	}
+	if !cfg.Spec.TickSize.IsPositive() || !cfg.Spec.SizeIncrement.IsPositive() {
+		return nil, errors.New("tick size and size increment are required")
+	}
+	if err := cfg.Ladder.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.ClientPrefix == "" {
+		cfg.ClientPrefix = "mm-"
+	}
+	return &Quoter{c: c, inv: inv, cfg: cfg}, nil
+}
+
+// Requote builds the ladder around fair, cancels live quotes that no longer match a level and places the missing
+// levels. Cancellations are sent before placements so that the ladder never crosses itself; a level is skipped when a
+// quote that failed to cancel rests at its side and price or would cross it, and is retried on the next Requote.
+// Failed items are reported in the Update, see Update.Err; the returned error is for failures to read state.
+func (q *Quoter) Requote(fair decimal.Decimal) (*Update, error) {
+	inventory, err := q.inv.Inventory(q.cfg.Spec.Market)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := Build(&q.cfg.Ladder, q.cfg.Spec, fair, inventory)
+	if err != nil {
+		return nil, err
+	}
+	live, err := q.Live()
+	if err != nil {
+		return nil, err
+	}
+
+	cancel, place := Diff(desired, live, q.cfg.SizeTolerance)
+	u := &Update{Inventory: inventory, Desired: desired, Kept: len(live) - len(cancel)}
+
+	ids := make([]string, len(cancel))
+	for i, o := range cancel {
+		ids[i] = o.OrderID
+	}
+	u.Canceled = oms.CancelOrdersByID(q.c, ids, q.cfg.Batch)
+	var stuck []model.Order
+	for i, r := range u.Canceled {
+		if r.Err != nil {
+			stuck = append(stuck, cancel[i])
+		}
+	}
+
+	reqs := make([]api.AddOrderRequest, 0, len(place))
+	for _, l := range place {
+		if blocked(l, stuck) {
+			u.Skipped = append(u.Skipped, l)
+			continue
+		}
+		id, err := q.clientOrderID()
+		if err != nil {
+			return u, err
+		}
+		reqs = append(reqs, api.AddOrderRequest{
+			ClientOrderID: id,
+			Market:        q.cfg.Spec.Market,
+			Price:         l.Price,
+			Side:          l.Side,
+			Size:          l.Size,
+			Type:          model.OrderTypeLimit,
+			TimeInForce:   model.TimeInForceGTC,
+			PostOnly:      q.cfg.PostOnly,
+		})
+	}
+	u.Placed = oms.PlaceOrders(q.c, reqs, q.cfg.Batch)
+	return u, nil
+}
+
+// blocked reports whether l would duplicate or cross one of the live orders.
+func blocked(l Level, live []model.Order) bool {
+	for _, o := range live {
+		switch {
+		case o.Side == l.Side && o.Price.Equal(l.Price):
+			return true
+		case o.Side == model.OrderSideBuy && l.Side == model.OrderSideSell && l.Price.LessThanOrEqual(o.Price):
+			return true
+		case o.Side == model.OrderSideSell && l.Side == model.OrderSideBuy && l.Price.GreaterThanOrEqual(o.Price):
+			return true
+		}
+	}
+	return false
+}
+
+// Live returns the open quotes of the Quoter, recognized by their client order ID prefix.
+func (q *Quoter) Live() ([]model.Order, error) {
+	orders, err := oms.OpenOrders(q.c, q.cfg.Spec.Market)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+	live := orders[:0]
+	for _, o := range orders {
+		if strings.HasPrefix(o.ClientOrderID, q.cfg.ClientPrefix) {
+			live = append(live, o)
+		}
+	}
+	return live, nil
+}
+
+// CancelAll cancels every live quote.
+func (q *Quoter) CancelAll() ([]oms.CancelResult, error) {
+	return oms.CancelMatching(q.c, &oms.CancelFilter{Market: q.cfg.Spec.Market, ClientIDPrefix: q.cfg.ClientPrefix}, q.cfg.Batch)
+}
+
+func (q *Quoter) clientOrderID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate client order ID: %w", err)
+	}
+	return q.cfg.ClientPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,111 @@
+package quoting
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+)
+
+type fixedInventory decimal.Decimal
+
+func (f fixedInventory) Inventory(string) (decimal.Decimal, error) {
+	return decimal.Decimal(f), nil
+}
+
+func TestRequoteSkipsLevelsBlockedByFailedCancels(t *testing.T) {
+	d := decimal.RequireFromString
+	quote := func(id string, side model.OrderSide, price, size string) model.Order {
+		return model.Order{OrderID: id, ClientOrderID: "mm-" + id, Market: "AVAX-USDC", Side: side, Price: d(price), Size: d(size), Status: model.OrderStatusOpen}
+	}
+	// The ladder around 100 is a bid at 99 and an ask at 101, both of size 1.
+	tests := []struct {
+		name       string
+		live       []model.Order
+		failing    map[string]bool
+		wantPlaced []model.OrderSide
+		wantSkip   int
+	}{
+		{
+			name:       "cancels succeed",
+			live:       []model.Order{quote("b", model.OrderSideBuy, "98", "1"), quote("a", model.OrderSideSell, "102", "1")},
+			wantPlaced: []model.OrderSide{model.OrderSideBuy, model.OrderSideSell},
+		},
+		{
+			name:       "stale quote away from the ladder",
+			live:       []model.Order{quote("b", model.OrderSideBuy, "98", "1")},
+			failing:    map[string]bool{"b": true},
+			wantPlaced: []model.OrderSide{model.OrderSideBuy, model.OrderSideSell},
+		},
+		{
+			name:       "stale quote at the level's price",
+			live:       []model.Order{quote("b", model.OrderSideBuy, "99", "5")},
+			failing:    map[string]bool{"b": true},
+			wantPlaced: []model.OrderSide{model.OrderSideSell},
+			wantSkip:   1,
+		},
+		{
+			name:       "stale bid crossing the new ask",
+			live:       []model.Order{quote("b", model.OrderSideBuy, "101.5", "1")},
+			failing:    map[string]bool{"b": true},
+			wantPlaced: []model.OrderSide{model.OrderSideBuy},
+			wantSkip:   1,
+		},
+		{
+			name:       "stale ask crossing the new bid",
+			live:       []model.Order{quote("a", model.OrderSideSell, "99", "1")},
+			failing:    map[string]bool{"a": true},
+			wantPlaced: []model.OrderSide{model.OrderSideSell},
+			wantSkip:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockSpotClient(ctrl)
+			c.EXPECT().GetOrders(gomock.Any()).Return(&api.GetOrdersResponse{Orders: tt.live}, nil)
+			c.EXPECT().CancelOrder(gomock.Any()).DoAndReturn(func(req *api.CancelOrderRequest) (*model.Order, error) {
+				if tt.failing[req.OrderID] {
+					return nil, errors.New("503 service unavailable")
+				}
+				return &model.Order{OrderID: req.OrderID, Status: model.OrderStatusCanceled}, nil
+			}).Times(len(tt.live))
+			var placed []model.OrderSide
+			c.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+				placed = append(placed, req.Side)
+				return &model.Order{OrderID: "new"}, nil
+			}).Times(len(tt.wantPlaced))
+
+			q, err := New(c, fixedInventory(decimal.Zero), Config{
+				Spec:   MarketSpec{Market: "AVAX-USDC", TickSize: d("0.01"), SizeIncrement: d("0.01")},
+				Ladder: LadderConfig{Levels: 1, Spread: d("100"), BaseSize: d("1")},
+				Batch:  &oms.BatchOptions{Concurrency: 1},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := q.Requote(d("100"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(u.Skipped) != tt.wantSkip {
+				t.Errorf("skipped %v, want %d levels", u.Skipped, tt.wantSkip)
+			}
+			if len(placed) != len(tt.wantPlaced) {
+				t.Fatalf("placed %v, want %v", placed, tt.wantPlaced)
+			}
+			for i := range placed {
+				if placed[i] != tt.wantPlaced[i] {
+					t.Fatalf("placed %v, want %v", placed, tt.wantPlaced)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Position is the simulated state of a single perps position.
+type Position struct {
+	Market            string                  // Perps market, e.g., "BTC-USD.P"
+	Direction         model.PositionDirection // Position direction, either "long" or "short"
+	Quantity          decimal.Decimal         // Unsigned base size of the position
+	AverageEntryPrice decimal.Decimal         // Average entry price
+}
+
+// sign returns 1 for long positions and -1 for short positions.
+func (p *Position) sign() decimal.Decimal {
+	if p.Direction == model.PositionDirectionShort {
+		return decimal.NewFromInt(-1)
+	}
+	return decimal.NewFromInt(1)
+}
+
+// Account is a cross-margin account snapshot that can be modified to answer "what if" questions.
+type Account struct {
+	WalletBalance decimal.Decimal            // Margin wallet balance in USDC, realized PnL included
+	Positions     map[string]*Position       // Open positions keyed by market
+	MarkPrices    map[string]decimal.Decimal // Mark prices keyed by market
+
+	// MaintenanceRates holds the maintenance margin as a fraction of notional, keyed by market.
+	// NewAccount derives it from the exchange's position data; DefaultMaintenanceRate covers other markets.
+	MaintenanceRates       map[string]decimal.Decimal
+	DefaultMaintenanceRate decimal.Decimal
+}
+
+// NewAccount builds an Account from the exchange's balance, positions and mark prices.
+// Mark prices missing from marks fall back to each position's own MarkPrice.
+func NewAccount(balance *model.Balance, positions []*model.Position, marks map[string]*model.MarkPrice) *Account {
+	a := &Account{
+		Positions:        make(map[string]*Position),
+		MarkPrices:       make(map[string]decimal.Decimal),
+		MaintenanceRates: make(map[string]decimal.Decimal),
+	}
+	if balance != nil {
+		a.WalletBalance = balance.WalletBalance
+	}
+	for market, mark := range marks {
+		a.MarkPrices[market] = mark.Price
+	}
+
+	for _, p := range positions {
+		qty := p.NetQuantity.Abs()
+		if qty.IsZero() {
+			continue
+		}
+
+		direction := p.Direction
+		if direction == "" {
+			direction = model.PositionDirectionLong
+			if p.NetQuantity.IsNegative() {
+				direction = model.PositionDirectionShort
+			}
+		}
+
+		a.Positions[p.Market] = &Position{
+			Market:            p.Market,
+			Direction:         direction,
+			Quantity:          qty,
+			AverageEntryPrice: p.AverageEntryPrice,
+		}
+		if _, ok := a.MarkPrices[p.Market]; !ok {
+			a.MarkPrices[p.Market] = p.MarkPrice
+		}
+		if notional := qty.Mul(p.MarkPrice); notional.IsPositive() {
+			a.MaintenanceRates[p.Market] = p.MaintenanceMargin.Div(notional)
+		}
+	}
+	return a
+}
+
+// Clone returns a deep copy of the account.
+func (a *Account) Clone() *Account {
+	c := &Account{
+		WalletBalance:          a.WalletBalance,
+		Positions:              make(map[string]*Position, len(a.Positions)),
+		MarkPrices:             make(map[string]decimal.Decimal, len(a.MarkPrices)),
+		MaintenanceRates:       make(map[string]decimal.Decimal, len(a.MaintenanceRates)),
+		DefaultMaintenanceRate: a.DefaultMaintenanceRate,
+	}
+	for k, v := range a.Positions {
+		p := *v
+		c.Positions[k] = &p
+	}
+	for k, v := range a.MarkPrices {
+		c.MarkPrices[k] = v
+	}
+	for k, v := range a.MaintenanceRates {
+		c.MaintenanceRates[k] = v
+	}
+	return c
+}
+
+// ApplyOrder fills a hypothetical order at price, or at the mark price if price is zero.
+// Reducing or flipping a position realizes PnL into the wallet balance; fee is charged to the wallet balance.
+func (a *Account) ApplyOrder(market string, side model.OrderSide, size, price, fee decimal.Decimal) error {
+	if !size.IsPositive() {
+		return fmt.Errorf("size must be positive")
+	}
+	if price.IsZero() {
+		mark, ok := a.MarkPrices[market]
+		if !ok {
+			return fmt.Errorf("no mark price for market %s", market)
+		}
+		price = mark
+	}
+	if _, ok := a.MarkPrices[market]; !ok {
+		a.MarkPrices[market] = price
+	}
+	a.WalletBalance = a.WalletBalance.Sub(fee)
+
+	direction := model.PositionDirectionLong
+	if side == model.OrderSideSell {
+		direction = model.PositionDirectionShort
+	}
+
+	p, ok := a.Positions[market]
+	if !ok {
+		a.Positions[market] = &Position{
+			Market:            market,
+			Direction:         direction,
+			Quantity:          size,
+			AverageEntryPrice: price,
+		}
+		return nil
+	}
+
+	if p.Direction == direction {
+		total := p.Quantity.Add(size)
+		p.AverageEntryPrice = p.AverageEntryPrice.Mul(p.Quantity).Add(price.Mul(size)).Div(total)
+		p.Quantity = total
+		return nil
+	}
+
+	closed := decimal.Min(size, p.Quantity)
+	a.WalletBalance = a.WalletBalance.Add(price.Sub(p.AverageEntryPrice).Mul(closed).Mul(p.sign()))
+
+	switch rest := size.Sub(p.Quantity); {
+	case rest.IsNegative():
+		p.Quantity = p.Quantity.Sub(size)
+	case rest.IsZero():
+		delete(a.Positions, market)
+	default:
+		p.Direction = direction
+		p.Quantity = rest
+		p.AverageEntryPrice = price
+	}
+	return nil
+}
+
+// ApplyPrice sets the mark price of a market.
+func (a *Account) ApplyPrice(market string, price decimal.Decimal) {
+	a.MarkPrices[market] = price
+}
+
+// ApplyShock moves every mark price by pct, e.g., -0.1 for a 10% drop.
+func (a *Account) ApplyShock(pct decimal.Decimal) {
+	factor := decimal.NewFromInt(1).Add(pct)
+	for market, price := range a.MarkPrices {
+		a.MarkPrices[market] = price.Mul(factor)
+	}
+}
+
+// ApplyTransfer adds amount to the wallet balance, positive for a deposit into margin and negative for a withdrawal.
+func (a *Account) ApplyTransfer(amount decimal.Decimal) {
+	a.WalletBalance = a.WalletBalance.Add(amount)
+}
+
+// WhatIf applies change to a copy of the account and returns the resulting metrics, leaving the account untouched.
+func (a *Account) WhatIf(change func(*Account) error) (*Metrics, error) {
+	c := a.Clone()
+	if err := change(c); err != nil {
+		return nil, err
+	}
+	return c.Metrics(), nil
+}
+
+func (a *Account) maintenanceRate(market string) decimal.Decimal {
+	if rate, ok := a.MaintenanceRates[market]; ok {
+		return rate
+	}
+	return a.DefaultMaintenanceRate
+}
@@ -0,0 +1,105 @@
+package risk
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// PositionMetrics holds the recomputed figures for a single position.
+type PositionMetrics struct {
+	Market            string                  `json:"market"`            // Perps market, e.g., "BTC-USD.P"
+	Direction         model.PositionDirection `json:"direction"`         // Position direction, either "long" or "short"
+	Quantity          decimal.Decimal         `json:"quantity"`          // Unsigned base size of the position
+	AverageEntryPrice decimal.Decimal         `json:"averageEntryPrice"` // Average entry price
+	MarkPrice         decimal.Decimal         `json:"markPrice"`         // Mark price used for the computation
+	Notional          decimal.Decimal         `json:"notional"`          // Quantity times mark price
+	UnrealizedPnl     decimal.Decimal         `json:"unrealizedPnl"`     // Unrealized PnL at mark price
+	MaintenanceMargin decimal.Decimal         `json:"maintenanceMargin"` // Maintenance margin at mark price
+	LiquidationPrice  decimal.Decimal         `json:"liquidationPrice"`  // Mark price of this market that liquidates the account, zero if none
+}
+
+// Metrics holds the recomputed figures for the whole account.
+type Metrics struct {
+	WalletBalance     decimal.Decimal    `json:"walletBalance"`     // Wallet balance in USDC
+	UnrealizedPnl     decimal.Decimal    `json:"unrealizedPnl"`     // Total unrealized PnL in USDC
+	MarginBalance     decimal.Decimal    `json:"marginBalance"`     // Wallet balance plus unrealized PnL
+	MaintenanceMargin decimal.Decimal    `json:"maintenanceMargin"` // Total maintenance margin
+	MarginRatio       decimal.Decimal    `json:"marginRatio"`       // Maintenance margin over margin balance as a percentage
+	Leverage          decimal.Decimal    `json:"leverage"`          // Total notional over margin balance
+	Liquidatable      bool               `json:"liquidatable"`      // Whether the margin balance no longer covers maintenance margin
+	Positions         []*PositionMetrics `json:"positions"`         // Per-position figures sorted by market
+}
+
+// Metrics recomputes unrealized PnL, margin ratio and liquidation prices at the current mark prices.
+func (a *Account) Metrics() *Metrics {
+	m := &Metrics{WalletBalance: a.WalletBalance}
+
+	notional := decimal.Zero
+	for _, p := range a.Positions {
+		mark := a.MarkPrices[p.Market]
+		pm := &PositionMetrics{
+			Market:            p.Market,
+			Direction:         p.Direction,
+			Quantity:          p.Quantity,
+			AverageEntryPrice: p.AverageEntryPrice,
+			MarkPrice:         mark,
+			Notional:          p.Quantity.Mul(mark),
+			UnrealizedPnl:     mark.Sub(p.AverageEntryPrice).Mul(p.Quantity).Mul(p.sign()),
+		}
+		pm.MaintenanceMargin = pm.Notional.Mul(a.maintenanceRate(p.Market))
+
+		m.UnrealizedPnl = m.UnrealizedPnl.Add(pm.UnrealizedPnl)
+		m.MaintenanceMargin = m.MaintenanceMargin.Add(pm.MaintenanceMargin)
+		notional = notional.Add(pm.Notional)
+		m.Positions = append(m.Positions, pm)
+	}
+	sort.Slice(m.Positions, func(i, j int) bool {
+		return m.Positions[i].Market < m.Positions[j].Market
+	})
+
+	m.MarginBalance = m.WalletBalance.Add(m.UnrealizedPnl)
+	if m.MarginBalance.IsPositive() {
+		m.MarginRatio = m.MaintenanceMargin.Div(m.MarginBalance).Mul(hundred)
+		m.Leverage = notional.Div(m.MarginBalance)
+	}
+	m.Liquidatable = len(m.Positions) > 0 && m.MarginBalance.LessThanOrEqual(m.MaintenanceMargin)
+
+	for _, pm := range m.Positions {
+		pm.LiquidationPrice = a.liquidationPrice(pm, m)
+	}
+	return m
+}
+
+// liquidationPrice solves for the mark price of pm's market at which the margin balance equals maintenance margin,
+// holding every other market at its current mark price.
+func (a *Account) liquidationPrice(pm *PositionMetrics, m *Metrics) decimal.Decimal {
+	rate := a.maintenanceRate(pm.Market)
+	otherBalance := m.MarginBalance.Sub(pm.UnrealizedPnl)
+	otherMaintenance := m.MaintenanceMargin.Sub(pm.MaintenanceMargin)
+	qty, entry := pm.Quantity, pm.AverageEntryPrice
+	one := decimal.NewFromInt(1)
+
+	var price decimal.Decimal
+	if pm.Direction == model.PositionDirectionShort {
+		// otherBalance + qty*(entry-P) = otherMaintenance + rate*qty*P
+		denominator := qty.Mul(one.Add(rate))
+		price = otherBalance.Add(qty.Mul(entry)).Sub(otherMaintenance).Div(denominator)
+	} else {
+		// otherBalance + qty*(P-entry) = otherMaintenance + rate*qty*P
+		denominator := qty.Mul(one.Sub(rate))
+		if !denominator.IsPositive() {
+			return decimal.Zero
+		}
+		price = otherMaintenance.Sub(otherBalance).Add(qty.Mul(entry)).Div(denominator)
+	}
+
+	if !price.IsPositive() {
+		return decimal.Zero
+	}
+	return price
+}
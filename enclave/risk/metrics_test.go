@@ -0,0 +1,90 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+// position returns an exchange position marked at mark with a maintenance margin of 5% of its notional.
+func position(market string, qty, entry, mark string) *model.Position {
+	return &model.Position{
+		Market:            market,
+		NetQuantity:       d(qty),
+		AverageEntryPrice: d(entry),
+		MarkPrice:         d(mark),
+		MaintenanceMargin: d(qty).Abs().Mul(d(mark)).Mul(d("0.05")),
+	}
+}
+
+func TestLiquidationPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		wallet    string
+		positions []*model.Position
+		want      map[string]string // Liquidation price by market, rounded to cents
+	}{
+		{
+			name:      "long",
+			wallet:    "1000",
+			positions: []*model.Position{position("BTC-USD.P", "1", "10000", "10000")},
+			want:      map[string]string{"BTC-USD.P": "9473.68"},
+		},
+		{
+			name:      "short",
+			wallet:    "1000",
+			positions: []*model.Position{position("BTC-USD.P", "-1", "10000", "10000")},
+			want:      map[string]string{"BTC-USD.P": "10476.19"},
+		},
+		{
+			name:      "long backed beyond its notional",
+			wallet:    "20000",
+			positions: []*model.Position{position("BTC-USD.P", "1", "10000", "10000")},
+			want:      map[string]string{"BTC-USD.P": "0"},
+		},
+		{
+			name:   "cross margin with a losing short",
+			wallet: "3000",
+			positions: []*model.Position{
+				position("BTC-USD.P", "1", "10000", "10000"),
+				position("ETH-USD.P", "-10", "1000", "1100"),
+			},
+			want: map[string]string{"BTC-USD.P": "9000", "ETH-USD.P": "1190.48"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccount(&model.Balance{WalletBalance: d(tt.wallet)}, tt.positions, nil)
+			m := a.Metrics()
+			if m.Liquidatable {
+				t.Fatalf("account is liquidatable at its marks: %+v", m)
+			}
+			if len(m.Positions) != len(tt.want) {
+				t.Fatalf("got %d positions, want %d", len(m.Positions), len(tt.want))
+			}
+
+			for _, pm := range m.Positions {
+				want := d(tt.want[pm.Market])
+				if got := pm.LiquidationPrice.Round(2); !got.Equal(want) {
+					t.Errorf("%s liquidation price = %s, want %s", pm.Market, got, want)
+				}
+				if want.IsZero() {
+					continue
+				}
+
+				// At the liquidation price the margin balance meets maintenance margin exactly.
+				c := a.Clone()
+				c.ApplyPrice(pm.Market, pm.LiquidationPrice)
+				at := c.Metrics()
+				if gap := at.MarginBalance.Sub(at.MaintenanceMargin).Round(6); !gap.IsZero() {
+					t.Errorf("%s at its liquidation price: margin balance exceeds maintenance margin by %s", pm.Market, gap)
+				}
+			}
+		})
+	}
+}
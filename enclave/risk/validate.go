@@ -0,0 +1,61 @@
+package risk
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Deviation describes a figure where the calculator disagrees with the exchange beyond the allowed tolerance.
+type Deviation struct {
+	Market   string          `json:"market,omitempty"` // Perps market, empty for account-wide figures
+	Field    string          `json:"field"`            // Name of the compared figure, e.g., "liquidationPrice"
+	Computed decimal.Decimal `json:"computed"`         // Value recomputed by the calculator
+	Reported decimal.Decimal `json:"reported"`         // Value reported by the exchange
+}
+
+// Validate recomputes the account from the exchange's own data and reports every figure whose relative difference
+// from Balance.MarginRatio, Balance.UnrealizedPnl, Position.UnrealizedPnl or Position.LiquidationPrice exceeds tolerance,
+// e.g., 0.01 for 1%.
+func Validate(balance *model.Balance, positions []*model.Position, marks map[string]*model.MarkPrice, tolerance decimal.Decimal) []*Deviation {
+	m := NewAccount(balance, positions, marks).Metrics()
+
+	var deviations []*Deviation
+	check := func(market, field string, computed, reported decimal.Decimal) {
+		if !withinTolerance(computed, reported, tolerance) {
+			deviations = append(deviations, &Deviation{
+				Market:   market,
+				Field:    field,
+				Computed: computed,
+				Reported: reported,
+			})
+		}
+	}
+
+	if balance != nil {
+		check("", "marginRatio", m.MarginRatio, balance.MarginRatio)
+		check("", "unrealizedPnl", m.UnrealizedPnl, balance.UnrealizedPnl)
+	}
+
+	computed := make(map[string]*PositionMetrics, len(m.Positions))
+	for _, pm := range m.Positions {
+		computed[pm.Market] = pm
+	}
+	for _, p := range positions {
+		pm, ok := computed[p.Market]
+		if !ok {
+			continue
+		}
+		check(p.Market, "unrealizedPnl", pm.UnrealizedPnl, p.UnrealizedPnl)
+		check(p.Market, "liquidationPrice", pm.LiquidationPrice, p.LiquidationPrice)
+	}
+	return deviations
+}
+
+func withinTolerance(computed, reported, tolerance decimal.Decimal) bool {
+	diff := computed.Sub(reported).Abs()
+	if reported.IsZero() {
+		return diff.LessThanOrEqual(tolerance)
+	}
+	return diff.Div(reported.Abs()).LessThanOrEqual(tolerance)
+}
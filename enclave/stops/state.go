@@ -0,0 +1,77 @@
+package stops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+// Intent is the stop order the Manager wants for the position of a market.
+type Intent struct {
+	Market     string                  `json:"market"`
+	Direction  model.PositionDirection `json:"direction"`
+	Entry      decimal.Decimal         `json:"entry"`      // Average entry price when the bracket was attached
+	Watermark  decimal.Decimal         `json:"watermark"`  // Best mark price seen since, highest for longs and lowest for shorts
+	StopLoss   decimal.Decimal         `json:"stopLoss"`   // Intended stop-loss trigger price, zero for none
+	TakeProfit decimal.Decimal         `json:"takeProfit"` // Intended take-profit trigger price, zero for none
+}
+
+type EventKind string
+
+const (
+	EventAttached EventKind = "attached" // A bracket was computed for a new position
+	EventAdopted  EventKind = "adopted"  // Stop orders found on the exchange were taken over
+	EventTrailed  EventKind = "trailed"  // The stop loss moved with the mark price
+	EventSet      EventKind = "set"      // A stop order was set on the exchange
+	EventRemoved  EventKind = "removed"  // A stop order was removed from the exchange
+)
+
+// Event describes a change made by the Manager.
+type Event struct {
+	Time       time.Time               `json:"time"`
+	Kind       EventKind               `json:"kind"`
+	Market     string                  `json:"market"`
+	Direction  model.PositionDirection `json:"direction,omitempty"`
+	StopLoss   decimal.Decimal         `json:"stopLoss"`
+	TakeProfit decimal.Decimal         `json:"takeProfit"`
+	Mark       decimal.Decimal         `json:"mark"`
+	Message    string                  `json:"message"`
+}
+
+// loadState reads the intents persisted at path. A missing file is an empty state.
+func loadState(path string) (map[string]*Intent, error) {
+	state := map[string]*Intent{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stop state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode stop state: %w", err)
+	}
+	return state, nil
+}
+
+// saveState atomically replaces the state persisted at path.
+func saveState(path string, state map[string]*Intent) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write stop state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write stop state: %w", err)
+	}
+	return nil
+}
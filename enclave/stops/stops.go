@@ -0,0 +1,373 @@
+// Package stops manages trailing stops and take-profit/stop-loss brackets of perps positions.
+package stops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Offset is a price distance, either absolute or as a percentage of a reference price.
+type Offset struct {
+	Distance decimal.Decimal `json:"distance"` // Absolute price distance
+	Percent  decimal.Decimal `json:"percent"`  // Percentage of the reference price, used when Distance is zero
+}
+
+// IsZero reports whether the offset is unset.
+func (o Offset) IsZero() bool {
+	return !o.Distance.IsPositive() && !o.Percent.IsPositive()
+}
+
+func (o Offset) of(price decimal.Decimal) decimal.Decimal {
+	if o.Distance.IsPositive() {
+		return o.Distance
+	}
+	return price.Mul(o.Percent).Div(hundred)
+}
+
+// Rule configures the stops of one market. Markets without a rule are never touched.
+type Rule struct {
+	Market     string
+	StopLoss   Offset          // Stop-loss distance from the entry price, or from the best mark when Trailing; zero for none
+	TakeProfit Offset          // Take-profit distance from the entry price; zero for none
+	Trailing   bool            // Move the stop loss as the mark price moves in favor of the position
+	MinStep    decimal.Decimal // Minimum move of a trailing stop before it is updated on the exchange
+	TickSize   decimal.Decimal // Optional tick trigger prices are rounded to
+}
+
+// Config configures a Manager.
+type Config struct {
+	Interval  time.Duration // Time between two passes, defaults to 10 seconds
+	Rules     []Rule
+	StatePath string      // Optional file the intended stops are persisted to, so trailing survives restarts
+	OnEvent   func(Event) // Called for every change made to the stops
+}
+
+// Manager attaches brackets to new positions, trails stop losses with the mark price, removes stops of closed
+// positions and keeps the exchange's stop orders in line with its intended state.
+type Manager struct {
+	perps client.PerpsClient
+	cfg   Config
+	rules map[string]*Rule
+	now   func() time.Time
+
+	mu    sync.Mutex
+	state map[string]*Intent
+}
+
+// New initializes a Manager and loads its persisted state, if any.
+func New(perps client.PerpsClient, cfg Config) (*Manager, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	rules := make(map[string]*Rule, len(cfg.Rules))
+	for i := range cfg.Rules {
+		r := &cfg.Rules[i]
+		if r.Market == "" {
+			return nil, errors.New("rule market is required")
+		}
+		if r.Trailing && r.StopLoss.IsZero() {
+			return nil, fmt.Errorf("trailing rule for %s requires a stop-loss offset", r.Market)
+		}
+		if _, ok := rules[r.Market]; ok {
+			return nil, fmt.Errorf("duplicate rule for %s", r.Market)
+		}
+		rules[r.Market] = r
+	}
+
+	state := map[string]*Intent{}
+	if cfg.StatePath != "" {
+		var err error
+		if state, err = loadState(cfg.StatePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Manager{perps: perps, cfg: cfg, rules: rules, now: time.Now, state: state}, nil
+}
+
+// State returns a copy of the intended stops by market.
+func (m *Manager) State() map[string]Intent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[string]Intent, len(m.state))
+	for market, intent := range m.state {
+		res[market] = *intent
+	}
+	return res
+}
+
+// Run reconciles the stops immediately and then at every interval until ctx is done.
+// Errors of a pass are reported to onError, which may be nil, and the next pass retries.
+func (m *Manager) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Reconcile(); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile runs one pass: it updates the intended stops from positions and mark prices, then sets or removes stop
+// orders wherever GetStopOrders differs from them.
+func (m *Manager) Reconcile() error {
+	positions, err := m.perps.GetPositions()
+	if err != nil {
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+	marks, err := m.perps.GetMarkPrices()
+	if err != nil {
+		return fmt.Errorf("failed to get mark prices: %w", err)
+	}
+	live, err := m.perps.GetStopOrders()
+	if err != nil {
+		return fmt.Errorf("failed to get stop orders: %w", err)
+	}
+
+	open := make(map[string]*model.Position, len(positions))
+	for _, p := range positions {
+		if !p.NetQuantity.IsZero() {
+			open[p.Market] = p
+		}
+	}
+	stops := make(map[string][]*model.StopOrder, len(live))
+	for _, s := range live {
+		stops[s.Market] = append(stops[s.Market], s)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for market, rule := range m.rules {
+		var mark decimal.Decimal
+		if mp, ok := marks[market]; ok {
+			mark = mp.Price
+		}
+		if err := m.reconcileMarket(rule, open[market], mark, stops[market]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", market, err))
+		}
+	}
+
+	if m.cfg.StatePath != "" {
+		if err := saveState(m.cfg.StatePath, m.state); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reconcileMarket brings one market in line. It must be called with mu held.
+func (m *Manager) reconcileMarket(rule *Rule, pos *model.Position, mark decimal.Decimal, live []*model.StopOrder) error {
+	intent := m.state[rule.Market]
+
+	if pos == nil {
+		if intent != nil {
+			delete(m.state, rule.Market)
+		}
+		if len(live) == 0 {
+			return nil
+		}
+		if _, err := m.perps.RemoveStopOrder(&api.RemoveStopOrderRequest{Market: rule.Market}); err != nil {
+			return fmt.Errorf("failed to remove stop orders: %w", err)
+		}
+		m.emit(Event{Kind: EventRemoved, Market: rule.Market, Message: "position closed, stop orders removed"})
+		return nil
+	}
+
+	// Stops of the other direction were left by a position that has since flipped. The exchange removes stop orders by
+	// market and type only, so all of them go and the bracket of the new position is set from scratch.
+	if stale := findStale(live, pos.Direction); stale != nil {
+		if _, err := m.perps.RemoveStopOrder(&api.RemoveStopOrderRequest{Market: rule.Market}); err != nil {
+			return fmt.Errorf("failed to remove stop orders: %w", err)
+		}
+		m.emit(Event{Kind: EventRemoved, Market: rule.Market, Direction: stale.PositionDirection,
+			Message: fmt.Sprintf("position flipped to %s, stop orders of the %s position removed", pos.Direction, stale.PositionDirection)})
+		live = nil
+	}
+
+	current := findStop(live, pos.Direction)
+	// A position that was flipped between two passes is a new position. Adding to a position keeps its intent.
+	if intent == nil || intent.Direction != pos.Direction {
+		if intent == nil && current != nil {
+			// Stops already on the exchange without a known intent, e.g., after a restart without state: keep them.
+			intent = m.adopt(rule, pos, mark, current)
+		} else {
+			intent = m.attach(rule, pos, mark)
+			m.emit(Event{Kind: EventAttached, Market: rule.Market, Direction: pos.Direction, StopLoss: intent.StopLoss, TakeProfit: intent.TakeProfit, Mark: mark,
+				Message: fmt.Sprintf("bracket attached to %s position entered at %s", pos.Direction, intent.Entry)})
+		}
+		m.state[rule.Market] = intent
+	}
+	if rule.Trailing && mark.IsPositive() {
+		m.trail(rule, intent, mark)
+	}
+
+	return m.sync(intent, current)
+}
+
+// attach computes a new bracket around the entry price of pos.
+func (m *Manager) attach(rule *Rule, pos *model.Position, mark decimal.Decimal) *Intent {
+	entry := pos.AverageEntryPrice
+	intent := &Intent{
+		Market:    rule.Market,
+		Direction: pos.Direction,
+		Entry:     entry,
+		Watermark: mark,
+	}
+	if !intent.Watermark.IsPositive() {
+		intent.Watermark = entry
+	}
+	if !rule.StopLoss.IsZero() {
+		intent.StopLoss = rule.round(away(pos.Direction, entry, rule.StopLoss.of(entry), false))
+	}
+	if !rule.TakeProfit.IsZero() {
+		intent.TakeProfit = rule.round(away(pos.Direction, entry, rule.TakeProfit.of(entry), true))
+	}
+	return intent
+}
+
+// adopt takes the stops already set on the exchange as the intended ones, completing missing legs from the rule.
+func (m *Manager) adopt(rule *Rule, pos *model.Position, mark decimal.Decimal, current *model.StopOrder) *Intent {
+	intent := m.attach(rule, pos, mark)
+	if current.StopLoss.Valid {
+		intent.StopLoss = current.StopLoss.Decimal
+	}
+	if current.TakeProfit.Valid {
+		intent.TakeProfit = current.TakeProfit.Decimal
+	}
+	m.emit(Event{Kind: EventAdopted, Market: rule.Market, Direction: pos.Direction, StopLoss: intent.StopLoss, TakeProfit: intent.TakeProfit, Mark: mark,
+		Message: "existing stop orders adopted"})
+	return intent
+}
+
+// trail moves the watermark and the stop loss of intent in favor of the position.
+func (m *Manager) trail(rule *Rule, intent *Intent, mark decimal.Decimal) {
+	long := intent.Direction == model.PositionDirectionLong
+	if long && mark.GreaterThan(intent.Watermark) || !long && mark.LessThan(intent.Watermark) {
+		intent.Watermark = mark
+	}
+
+	stop := rule.round(away(intent.Direction, intent.Watermark, rule.StopLoss.of(intent.Watermark), false))
+	var step decimal.Decimal
+	if long {
+		step = stop.Sub(intent.StopLoss)
+	} else {
+		step = intent.StopLoss.Sub(stop)
+	}
+	if intent.StopLoss.IsPositive() && (!step.IsPositive() || step.LessThan(rule.MinStep)) {
+		return
+	}
+
+	intent.StopLoss = stop
+	m.emit(Event{Kind: EventTrailed, Market: rule.Market, Direction: intent.Direction, StopLoss: stop, TakeProfit: intent.TakeProfit, Mark: mark,
+		Message: fmt.Sprintf("stop loss trailed to %s", stop)})
+}
+
+// sync sets the legs of intent that differ from the live stop order.
+func (m *Manager) sync(intent *Intent, current *model.StopOrder) error {
+	var errs []error
+	legs := []struct {
+		typ   model.StopOrderType
+		price decimal.Decimal
+		live  decimal.NullDecimal
+	}{
+		{model.StopOrderTypeStopLoss, intent.StopLoss, nullStop(current, true)},
+		{model.StopOrderTypeTakeProfit, intent.TakeProfit, nullStop(current, false)},
+	}
+	for _, leg := range legs {
+		switch {
+		case leg.price.IsPositive() && (!leg.live.Valid || !leg.live.Decimal.Equal(leg.price)):
+			_, err := m.perps.SetStopOrder(&api.SetStopOrderRequest{
+				Market:            intent.Market,
+				PositionDirection: string(intent.Direction),
+				Type:              leg.typ,
+				TriggerPrice:      leg.price,
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to set %s: %w", leg.typ, err))
+				continue
+			}
+			m.emit(Event{Kind: EventSet, Market: intent.Market, Direction: intent.Direction, StopLoss: intent.StopLoss, TakeProfit: intent.TakeProfit,
+				Message: fmt.Sprintf("%s set to %s", leg.typ, leg.price)})
+		case !leg.price.IsPositive() && leg.live.Valid:
+			if _, err := m.perps.RemoveStopOrder(&api.RemoveStopOrderRequest{Market: intent.Market, Type: string(leg.typ)}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", leg.typ, err))
+				continue
+			}
+			m.emit(Event{Kind: EventRemoved, Market: intent.Market, Direction: intent.Direction, Message: fmt.Sprintf("%s removed", leg.typ)})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) emit(e Event) {
+	if m.cfg.OnEvent == nil {
+		return
+	}
+	e.Time = m.now()
+	m.cfg.OnEvent(e)
+}
+
+// round rounds price to the rule's tick size, if any.
+func (r *Rule) round(price decimal.Decimal) decimal.Decimal {
+	if !r.TickSize.IsPositive() {
+		return price
+	}
+	return price.Div(r.TickSize).Round(0).Mul(r.TickSize)
+}
+
+// away returns price moved by distance in favor of a position in direction when profit is set, against it otherwise.
+func away(direction model.PositionDirection, price, distance decimal.Decimal, profit bool) decimal.Decimal {
+	if (direction == model.PositionDirectionLong) == profit {
+		return price.Add(distance)
+	}
+	return price.Sub(distance)
+}
+
+func findStop(live []*model.StopOrder, direction model.PositionDirection) *model.StopOrder {
+	for _, s := range live {
+		if s.PositionDirection == direction {
+			return s
+		}
+	}
+	return nil
+}
+
+// findStale returns a stop order of live set for a position in another direction than direction, if any.
+func findStale(live []*model.StopOrder, direction model.PositionDirection) *model.StopOrder {
+	for _, s := range live {
+		if s.PositionDirection != direction {
+			return s
+		}
+	}
+	return nil
+}
+
+func nullStop(s *model.StopOrder, stopLoss bool) decimal.NullDecimal {
+	switch {
+	case s == nil:
+		return decimal.NullDecimal{}
+	case stopLoss:
+		return s.StopLoss
+	default:
+		return s.TakeProfit
+	}
+}
@@ -0,0 +1,295 @@
+package stops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+const market = "AVAX-USD.P"
+
+// exchange holds the position, mark price and stop orders served to a Manager through a mock perps client.
+type exchange struct {
+	position *model.Position
+	mark     decimal.Decimal
+	stops    map[model.PositionDirection]*model.StopOrder
+	sets     int // Number of SetStopOrder calls
+}
+
+func newExchange(t *testing.T) (*exchange, *mocks.MockPerpsClient) {
+	ctrl := gomock.NewController(t)
+	pc := mocks.NewMockPerpsClient(ctrl)
+	x := &exchange{stops: map[model.PositionDirection]*model.StopOrder{}}
+
+	pc.EXPECT().GetPositions().DoAndReturn(func() ([]*model.Position, error) {
+		if x.position == nil {
+			return nil, nil
+		}
+		return []*model.Position{x.position}, nil
+	}).AnyTimes()
+	pc.EXPECT().GetMarkPrices().DoAndReturn(func() (map[string]*model.MarkPrice, error) {
+		return map[string]*model.MarkPrice{market: {Pair: market, Price: x.mark}}, nil
+	}).AnyTimes()
+	pc.EXPECT().GetStopOrders().DoAndReturn(func() ([]*model.StopOrder, error) {
+		var res []*model.StopOrder
+		for _, s := range x.stops {
+			c := *s
+			res = append(res, &c)
+		}
+		return res, nil
+	}).AnyTimes()
+	pc.EXPECT().SetStopOrder(gomock.Any()).DoAndReturn(func(req *api.SetStopOrderRequest) ([]*model.StopOrder, error) {
+		x.sets++
+		dir := model.PositionDirection(req.PositionDirection)
+		s, ok := x.stops[dir]
+		if !ok {
+			s = &model.StopOrder{Market: req.Market, PositionDirection: dir}
+			x.stops[dir] = s
+		}
+		leg := decimal.NewNullDecimal(req.TriggerPrice)
+		if req.Type == model.StopOrderTypeStopLoss {
+			s.StopLoss = leg
+		} else {
+			s.TakeProfit = leg
+		}
+		return nil, nil
+	}).AnyTimes()
+	pc.EXPECT().RemoveStopOrder(gomock.Any()).DoAndReturn(func(req *api.RemoveStopOrderRequest) ([]*model.StopOrder, error) {
+		for dir, s := range x.stops {
+			switch model.StopOrderType(req.Type) {
+			case model.StopOrderTypeStopLoss:
+				s.StopLoss = decimal.NullDecimal{}
+			case model.StopOrderTypeTakeProfit:
+				s.TakeProfit = decimal.NullDecimal{}
+			default:
+				s.StopLoss, s.TakeProfit = decimal.NullDecimal{}, decimal.NullDecimal{}
+			}
+			if !s.StopLoss.Valid && !s.TakeProfit.Valid {
+				delete(x.stops, dir)
+			}
+		}
+		return nil, nil
+	}).AnyTimes()
+	return x, pc
+}
+
+func (x *exchange) open(direction model.PositionDirection, qty, entry, mark string) {
+	x.position = &model.Position{Market: market, Direction: direction, NetQuantity: d(qty), AverageEntryPrice: d(entry)}
+	x.mark = d(mark)
+}
+
+// leg returns the live stop-loss or take-profit trigger price of direction, empty when it is not set.
+func (x *exchange) leg(direction model.PositionDirection, stopLoss bool) string {
+	s, ok := x.stops[direction]
+	if !ok {
+		return ""
+	}
+	leg := s.TakeProfit
+	if stopLoss {
+		leg = s.StopLoss
+	}
+	if !leg.Valid {
+		return ""
+	}
+	return leg.Decimal.String()
+}
+
+func newManager(t *testing.T, pc *mocks.MockPerpsClient, rule Rule, events *[]Event) *Manager {
+	t.Helper()
+	rule.Market = market
+	m, err := New(pc, Config{Rules: []Rule{rule}, OnEvent: func(e Event) { *events = append(*events, e) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func reconcile(t *testing.T, m *Manager) {
+	t.Helper()
+	if err := m.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name           string
+		rule           Rule
+		direction      model.PositionDirection
+		qty            string
+		wantStopLoss   string
+		wantTakeProfit string
+	}{
+		{
+			name:           "long with distances",
+			rule:           Rule{StopLoss: Offset{Distance: d("2")}, TakeProfit: Offset{Distance: d("3")}},
+			direction:      model.PositionDirectionLong,
+			qty:            "10",
+			wantStopLoss:   "18",
+			wantTakeProfit: "23",
+		},
+		{
+			name:           "short with distances",
+			rule:           Rule{StopLoss: Offset{Distance: d("2")}, TakeProfit: Offset{Distance: d("3")}},
+			direction:      model.PositionDirectionShort,
+			qty:            "-10",
+			wantStopLoss:   "22",
+			wantTakeProfit: "17",
+		},
+		{
+			name:           "long with percentages rounded to ticks",
+			rule:           Rule{StopLoss: Offset{Percent: d("3.3")}, TakeProfit: Offset{Percent: d("10")}, TickSize: d("0.05")},
+			direction:      model.PositionDirectionLong,
+			qty:            "10",
+			wantStopLoss:   "19.35",
+			wantTakeProfit: "22",
+		},
+		{
+			name:         "stop loss only",
+			rule:         Rule{StopLoss: Offset{Percent: d("5")}},
+			direction:    model.PositionDirectionShort,
+			qty:          "-10",
+			wantStopLoss: "21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, pc := newExchange(t)
+			var events []Event
+			m := newManager(t, pc, tt.rule, &events)
+
+			x.open(tt.direction, tt.qty, "20", "20")
+			reconcile(t, m)
+
+			if sl, tp := x.leg(tt.direction, true), x.leg(tt.direction, false); sl != tt.wantStopLoss || tp != tt.wantTakeProfit {
+				t.Fatalf("live stops = %q/%q, want %q/%q", sl, tp, tt.wantStopLoss, tt.wantTakeProfit)
+			}
+			if len(events) == 0 || events[0].Kind != EventAttached {
+				t.Fatalf("events = %+v, want attached first", events)
+			}
+			intent := m.State()[market]
+			if intent.Direction != tt.direction || !intent.Entry.Equal(d("20")) {
+				t.Fatalf("intent = %+v, want %s entered at 20", intent, tt.direction)
+			}
+
+			// A pass without changes leaves the exchange alone.
+			sets := x.sets
+			reconcile(t, m)
+			if x.sets != sets {
+				t.Fatalf("second pass set %d stop orders, want none", x.sets-sets)
+			}
+		})
+	}
+}
+
+func TestTrail(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      Rule
+		direction model.PositionDirection
+		qty       string
+		marks     []string
+		wantStops []string // Live stop loss after each pass
+	}{
+		{
+			name:      "long with a distance",
+			rule:      Rule{StopLoss: Offset{Distance: d("2")}, Trailing: true, MinStep: d("0.5")},
+			direction: model.PositionDirectionLong,
+			qty:       "10",
+			marks:     []string{"20", "20.4", "21", "20.5", "21.4", "21.5"},
+			wantStops: []string{"18", "18", "19", "19", "19", "19.5"},
+		},
+		{
+			name:      "short with a percentage",
+			rule:      Rule{StopLoss: Offset{Percent: d("5")}, Trailing: true, MinStep: d("0.5"), TickSize: d("0.01")},
+			direction: model.PositionDirectionShort,
+			qty:       "-10",
+			marks:     []string{"20", "19.8", "19", "19.5", "18"},
+			wantStops: []string{"21", "21", "19.95", "19.95", "18.9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, pc := newExchange(t)
+			var events []Event
+			m := newManager(t, pc, tt.rule, &events)
+
+			for i, mark := range tt.marks {
+				x.open(tt.direction, tt.qty, "20", mark)
+				reconcile(t, m)
+				if got := x.leg(tt.direction, true); got != tt.wantStops[i] {
+					t.Fatalf("pass %d at mark %s: stop loss = %s, want %s", i, mark, got, tt.wantStops[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	x, pc := newExchange(t)
+	var events []Event
+	m := newManager(t, pc, Rule{StopLoss: Offset{Distance: d("2")}, TakeProfit: Offset{Distance: d("3")}}, &events)
+
+	x.open(model.PositionDirectionLong, "10", "20", "20")
+	x.stops[model.PositionDirectionLong] = &model.StopOrder{
+		Market:            market,
+		PositionDirection: model.PositionDirectionLong,
+		StopLoss:          decimal.NewNullDecimal(d("17.5")),
+	}
+	reconcile(t, m)
+
+	// The existing stop loss is kept and the missing take profit is completed from the rule.
+	if sl, tp := x.leg(model.PositionDirectionLong, true), x.leg(model.PositionDirectionLong, false); sl != "17.5" || tp != "23" {
+		t.Fatalf("live stops = %s/%s, want 17.5/23", sl, tp)
+	}
+	if x.sets != 1 {
+		t.Fatalf("set %d stop orders, want only the take profit", x.sets)
+	}
+	if len(events) == 0 || events[0].Kind != EventAdopted {
+		t.Fatalf("events = %+v, want adopted first", events)
+	}
+}
+
+func TestPositionChanges(t *testing.T) {
+	x, pc := newExchange(t)
+	var events []Event
+	m := newManager(t, pc, Rule{StopLoss: Offset{Distance: d("2")}, TakeProfit: Offset{Distance: d("3")}}, &events)
+
+	x.open(model.PositionDirectionLong, "10", "20", "20")
+	reconcile(t, m)
+
+	// Flipped to short between two passes: the long stops go and a short bracket is set around the new entry.
+	x.open(model.PositionDirectionShort, "-5", "25", "25")
+	reconcile(t, m)
+	if _, ok := x.stops[model.PositionDirectionLong]; ok {
+		t.Fatalf("long stops %+v left after the flip", x.stops[model.PositionDirectionLong])
+	}
+	if sl, tp := x.leg(model.PositionDirectionShort, true), x.leg(model.PositionDirectionShort, false); sl != "27" || tp != "22" {
+		t.Fatalf("short stops = %s/%s, want 27/22", sl, tp)
+	}
+
+	// Closed: every stop goes and the intent is dropped.
+	x.position = nil
+	reconcile(t, m)
+	if len(x.stops) != 0 {
+		t.Fatalf("stops %+v left after the close", x.stops)
+	}
+	if len(m.State()) != 0 {
+		t.Fatalf("state = %+v, want empty", m.State())
+	}
+	if last := events[len(events)-1]; last.Kind != EventRemoved {
+		t.Fatalf("last event = %+v, want removed", last)
+	}
+}
@@ -0,0 +1,127 @@
+package strategy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/client"
+)
+
+// BacktestConfig configures a Backtest.
+type BacktestConfig struct {
+	Sim           SimConfig
+	TimerInterval time.Duration // Simulated time between two timer events, none if zero
+}
+
+// Backtest is a Backend replaying recorded book and funding events, as written by Run with Config.Record, against
+// a Sim. Its clock is the time of the event being replayed, so runs are deterministic.
+type Backtest struct {
+	cfg     BacktestConfig
+	scanner *bufio.Scanner
+	line    int
+	now     time.Time
+	sim     *Sim
+	pending []Event
+	next    *Event    // Recorded event read ahead while timer events due before it are returned
+	timer   time.Time // Time of the next timer event
+}
+
+var _ Backend = (*Backtest)(nil)
+
+// NewBacktest initializes a Backtest replaying the JSON lines of r.
+func NewBacktest(r io.Reader, cfg BacktestConfig) *Backtest {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	b := &Backtest{cfg: cfg, scanner: scanner}
+	b.sim = NewSim(cfg.Sim, b.Now)
+	return b
+}
+
+// Sim returns the simulated exchange of the backend, to inspect its orders and fills.
+func (b *Backtest) Sim() *Sim {
+	return b.sim
+}
+
+func (b *Backtest) Start(context.Context, func(error)) error {
+	return nil
+}
+
+// Next returns the simulated fills and order updates first, then the timer events due before the next recorded
+// event, then that event.
+func (b *Backtest) Next(ctx context.Context) (Event, error) {
+	if err := ctx.Err(); err != nil {
+		return Event{}, err
+	}
+	b.pending = append(b.pending, b.sim.drain()...)
+	if len(b.pending) > 0 {
+		ev := b.pending[0]
+		b.pending = b.pending[1:]
+		return ev, nil
+	}
+
+	if b.next == nil {
+		ev, err := b.read()
+		if err != nil {
+			return Event{}, err
+		}
+		b.next = &ev
+	}
+	if b.cfg.TimerInterval > 0 {
+		if b.timer.IsZero() {
+			b.timer = b.next.Time.Truncate(b.cfg.TimerInterval).Add(b.cfg.TimerInterval)
+		}
+		if !b.timer.After(b.next.Time) {
+			b.now = b.timer
+			b.timer = b.timer.Add(b.cfg.TimerInterval)
+			return Event{Kind: EventTimer, Time: b.now}, nil
+		}
+	}
+
+	ev := *b.next
+	b.next = nil
+	if ev.Time.After(b.now) {
+		b.now = ev.Time
+	}
+	if ev.Kind == EventBook {
+		b.sim.OnBook(ev.Market, ev.Book)
+	}
+	return ev, nil
+}
+
+// read decodes the next replayable event, skipping blank lines.
+func (b *Backtest) read() (Event, error) {
+	for b.scanner.Scan() {
+		b.line++
+		if len(b.scanner.Bytes()) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(b.scanner.Bytes(), &ev); err != nil {
+			return Event{}, fmt.Errorf("failed to decode event on line %d: %w", b.line, err)
+		}
+		switch {
+		case ev.Kind == EventBook && ev.Book != nil, ev.Kind == EventFunding && ev.Funding != nil:
+			return ev, nil
+		default:
+			return Event{}, fmt.Errorf("line %d is not a book or funding event", b.line)
+		}
+	}
+	if err := b.scanner.Err(); err != nil {
+		return Event{}, fmt.Errorf("failed to read events: %w", err)
+	}
+	return Event{}, io.EOF
+}
+
+// Client returns the Sim for every market.
+func (b *Backtest) Client(string) client.OrderFillClient {
+	return b.sim
+}
+
+// Now returns the time of the event being replayed.
+func (b *Backtest) Now() time.Time {
+	return b.now
+}
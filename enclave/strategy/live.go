@@ -0,0 +1,263 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+	"github.com/yangnei/enclave-go/enclave/util"
+)
+
+// Feed is an additional source of events, such as a streaming connection, merged with the polled ones.
+type Feed interface {
+	// Run sends events to out until ctx is done.
+	Run(ctx context.Context, out chan<- Event) error
+}
+
+// LiveConfig configures the event sources of live and paper backends. Zero intervals use the defaults;
+// negative intervals disable the source.
+type LiveConfig struct {
+	Markets         []string      // Markets to poll books, fills and orders of; funding is polled for the perps ones
+	BookInterval    time.Duration // Time between two polls of each book, defaults to 1 second
+	BookDepth       int           // Number of levels of polled books, defaults to 10
+	AccountInterval time.Duration // Time between two polls of fills and open orders, defaults to 2 seconds
+	FundingInterval time.Duration // Time between two polls of funding rates, defaults to 1 minute
+	TimerInterval   time.Duration // Time between two timer events, none by default
+	Feeds           []Feed
+}
+
+func (c *LiveConfig) setDefaults() {
+	if c.BookInterval == 0 {
+		c.BookInterval = time.Second
+	}
+	if c.BookDepth <= 0 {
+		c.BookDepth = 10
+	}
+	if c.AccountInterval == 0 {
+		c.AccountInterval = 2 * time.Second
+	}
+	if c.FundingInterval == 0 {
+		c.FundingInterval = time.Minute
+	}
+}
+
+// Live is a Backend trading through the API and polling it for events.
+type Live struct {
+	c       client.Client
+	cfg     LiveConfig
+	account bool // Poll fills and orders; paper trading simulates them instead
+	events  chan Event
+}
+
+var _ Backend = (*Live)(nil)
+
+// NewLive initializes a Live backend on c.
+func NewLive(c client.Client, cfg LiveConfig) *Live {
+	cfg.setDefaults()
+	return &Live{c: c, cfg: cfg, account: true, events: make(chan Event, 256)}
+}
+
+func (l *Live) Start(ctx context.Context, onError func(error)) error {
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+
+	for _, market := range l.cfg.Markets {
+		market := market
+		ofc := l.Client(market)
+		if l.cfg.BookInterval > 0 {
+			go every(ctx, l.cfg.BookInterval, func() {
+				book, err := ofc.GetDepth(&api.GetDepthRequest{Market: market, Depth: l.cfg.BookDepth})
+				if err != nil {
+					report(fmt.Errorf("failed to get depth of %s: %w", market, err))
+					return
+				}
+				l.send(ctx, Event{Kind: EventBook, Time: time.Now(), Market: market, Book: book})
+			})
+		}
+		if l.account && l.cfg.AccountInterval > 0 {
+			p := &accountPoller{l: l, c: ofc, market: market, since: time.Now(), seen: map[string]time.Time{}}
+			go every(ctx, l.cfg.AccountInterval, func() {
+				if err := p.poll(ctx); err != nil {
+					report(err)
+				}
+			})
+		}
+		if util.IsPerpsMarket(market) && l.cfg.FundingInterval > 0 {
+			var last *model.FundingRate
+			go every(ctx, l.cfg.FundingInterval, func() {
+				rate, err := l.c.PerpsClient().GetFundingRates(&api.GetFundingRatesRequest{Market: market})
+				if err != nil {
+					report(fmt.Errorf("failed to get funding rate of %s: %w", market, err))
+					return
+				}
+				if rate.Market == "" {
+					rate.Market = market
+				}
+				// Only changes are events, the rate is polled more often than it moves.
+				if last != nil && last.Rate.Equal(rate.Rate) && last.IntervalEnds.Equal(rate.IntervalEnds) {
+					return
+				}
+				last = rate
+				l.send(ctx, Event{Kind: EventFunding, Time: time.Now(), Market: market, Funding: rate})
+			})
+		}
+	}
+	if l.cfg.TimerInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(l.cfg.TimerInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case t := <-ticker.C:
+					l.send(ctx, Event{Kind: EventTimer, Time: t})
+				}
+			}
+		}()
+	}
+	for _, f := range l.cfg.Feeds {
+		f := f
+		go func() {
+			if err := f.Run(ctx, l.events); err != nil && ctx.Err() == nil {
+				report(fmt.Errorf("feed stopped: %w", err))
+			}
+		}()
+	}
+	return nil
+}
+
+func (l *Live) Next(ctx context.Context) (Event, error) {
+	select {
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	case ev := <-l.events:
+		return ev, nil
+	}
+}
+
+// Client returns the perps client for perps markets and the spot client otherwise.
+func (l *Live) Client(market string) client.OrderFillClient {
+	if util.IsPerpsMarket(market) {
+		return l.c.PerpsClient()
+	}
+	return l.c.SpotClient()
+}
+
+func (l *Live) Now() time.Time {
+	return time.Now()
+}
+
+func (l *Live) send(ctx context.Context, ev Event) {
+	select {
+	case <-ctx.Done():
+	case l.events <- ev:
+	}
+}
+
+// accountPoller turns fills and open order snapshots of one market into events.
+type accountPoller struct {
+	l      *Live
+	c      client.OrderFillClient
+	market string
+	since  time.Time
+	seen   map[string]time.Time   // Times of the fills already sent by ID, pruned once older than since
+	open   map[string]model.Order // Open orders of the previous poll
+}
+
+func (p *accountPoller) poll(ctx context.Context) error {
+	fills, err := p.fills()
+	if err != nil {
+		return fmt.Errorf("failed to get fills of %s: %w", p.market, err)
+	}
+	sort.Slice(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	for _, f := range fills {
+		if _, ok := p.seen[f.ID]; ok {
+			continue
+		}
+		p.seen[f.ID] = f.Time
+		p.l.send(ctx, Event{Kind: EventFill, Time: f.Time, Market: p.market, Fill: f})
+		if f.Time.After(p.since) {
+			p.since = f.Time
+		}
+	}
+	// Fills are requested from since at millisecond precision, so older ones are never returned again.
+	for id, t := range p.seen {
+		if t.Before(p.since.Truncate(time.Millisecond)) {
+			delete(p.seen, id)
+		}
+	}
+
+	orders, err := oms.OpenOrders(p.c, p.market)
+	if err != nil {
+		return fmt.Errorf("failed to get open orders of %s: %w", p.market, err)
+	}
+	open := make(map[string]model.Order, len(orders))
+	for _, o := range orders {
+		open[o.OrderID] = o
+		prev, ok := p.open[o.OrderID]
+		if !ok || !prev.FilledSize.Equal(o.FilledSize) || prev.Status != o.Status {
+			o := o
+			p.l.send(ctx, Event{Kind: EventOrder, Time: time.Now(), Market: p.market, Order: &o})
+		}
+	}
+
+	var errs []error
+	for id, prev := range p.open {
+		if _, ok := open[id]; ok {
+			continue
+		}
+		// The order closed since the previous poll; its final state tells how.
+		o, err := p.c.GetOrder(&api.GetOrderRequest{OrderID: id})
+		if err != nil {
+			// Kept as open so that the next poll looks it up again.
+			open[id] = prev
+			errs = append(errs, fmt.Errorf("failed to get closed order %s: %w", id, err))
+			continue
+		}
+		p.l.send(ctx, Event{Kind: EventOrder, Time: time.Now(), Market: p.market, Order: o})
+	}
+	p.open = open
+	return errors.Join(errs...)
+}
+
+// fills returns every fill of the market since p.since, following pagination.
+func (p *accountPoller) fills() ([]*model.Fill, error) {
+	req := &api.GetFillsRequest{Market: p.market}
+	req.StartMs = p.since.UnixMilli()
+	var res []*model.Fill
+	for {
+		resp, err := p.c.GetFillsPage(req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.Fills...)
+		if resp.PageInfo.NextCursor == "" || resp.PageInfo.NextCursor == req.Cursor || len(resp.Fills) == 0 {
+			return res, nil
+		}
+		req.Cursor = resp.PageInfo.NextCursor
+	}
+}
+
+// every calls fn immediately and then at every interval until ctx is done.
+func every(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestAccountPollerPagesAndPrunesFills(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fill := func(id string, after time.Duration) *model.Fill {
+		return &model.Fill{ID: id, Market: "AVAX-USDC", Time: start.Add(after)}
+	}
+
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockSpotClient(ctrl)
+	c.EXPECT().GetOrders(gomock.Any()).Return(&api.GetOrdersResponse{}, nil).AnyTimes()
+	gomock.InOrder(
+		c.EXPECT().GetFillsPage(gomock.Any()).Return(&api.GetFillsResponse{
+			PageInfo: api.PageInfo{NextCursor: "2"},
+			Fills:    []*model.Fill{fill("a", time.Second), fill("b", 2*time.Second)},
+		}, nil),
+		c.EXPECT().GetFillsPage(gomock.Any()).DoAndReturn(func(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+			if req.Cursor != "2" {
+				t.Errorf("second page requested with cursor %q, want 2", req.Cursor)
+			}
+			return &api.GetFillsResponse{Fills: []*model.Fill{fill("c", 3*time.Second)}}, nil
+		}),
+		// The next poll starts at the last fill, which the API returns again.
+		c.EXPECT().GetFillsPage(gomock.Any()).DoAndReturn(func(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+			if want := start.Add(3 * time.Second).UnixMilli(); req.StartMs != want {
+				t.Errorf("second poll starts at %d, want %d", req.StartMs, want)
+			}
+			return &api.GetFillsResponse{Fills: []*model.Fill{fill("c", 3*time.Second), fill("d", 4*time.Second)}}, nil
+		}),
+	)
+
+	l := &Live{events: make(chan Event, 16)}
+	p := &accountPoller{l: l, c: c, market: "AVAX-USDC", since: start, seen: map[string]time.Time{}}
+	for i := 0; i < 2; i++ {
+		if err := p.poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(l.events)
+	var ids []string
+	for ev := range l.events {
+		ids = append(ids, ev.Fill.ID)
+	}
+	if len(ids) != 4 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" || ids[3] != "d" {
+		t.Fatalf("fill events = %v, want a, b, c, d once each", ids)
+	}
+	if len(p.seen) != 1 {
+		t.Fatalf("poller remembers %d fills, want only the one at since", len(p.seen))
+	}
+}
+
+func TestAccountPollerRetriesClosedOrderLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := mocks.NewMockSpotClient(ctrl)
+	c.EXPECT().GetFillsPage(gomock.Any()).Return(&api.GetFillsResponse{}, nil).AnyTimes()
+	open := model.Order{OrderID: "o1", Status: model.OrderStatusOpen}
+	gomock.InOrder(
+		c.EXPECT().GetOrders(gomock.Any()).Return(&api.GetOrdersResponse{Orders: []model.Order{open}}, nil),
+		c.EXPECT().GetOrders(gomock.Any()).Return(&api.GetOrdersResponse{}, nil).Times(3),
+	)
+	gomock.InOrder(
+		c.EXPECT().GetOrder(gomock.Any()).Return(nil, errors.New("503 service unavailable")),
+		c.EXPECT().GetOrder(gomock.Any()).Return(&model.Order{OrderID: "o1", Status: model.OrderStatusFullyFilled}, nil),
+	)
+
+	l := &Live{events: make(chan Event, 16)}
+	p := &accountPoller{l: l, c: c, market: "AVAX-USDC", seen: map[string]time.Time{}}
+	wantErr := []bool{false, true, false, false}
+	for i, want := range wantErr {
+		if err := p.poll(context.Background()); (err != nil) != want {
+			t.Fatalf("poll %d error = %v, want error %v", i+1, err, want)
+		}
+	}
+
+	close(l.events)
+	var statuses []model.OrderStatus
+	for ev := range l.events {
+		statuses = append(statuses, ev.Order.Status)
+	}
+	if len(statuses) != 2 || statuses[0] != model.OrderStatusOpen || statuses[1] != model.OrderStatusFullyFilled {
+		t.Fatalf("order events = %v, want open then fully filled", statuses)
+	}
+}
@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/client"
+)
+
+// Paper is a Backend polling live market data and matching orders in a Sim, never trading.
+type Paper struct {
+	live    *Live
+	sim     *Sim
+	pending []Event
+}
+
+var _ Backend = (*Paper)(nil)
+
+// NewPaper initializes a Paper backend reading market data through c.
+func NewPaper(c client.Client, cfg LiveConfig, simCfg SimConfig) *Paper {
+	live := NewLive(c, cfg)
+	live.account = false
+	return &Paper{live: live, sim: NewSim(simCfg, time.Now)}
+}
+
+// Sim returns the simulated exchange of the backend, to inspect its orders and fills.
+func (p *Paper) Sim() *Sim {
+	return p.sim
+}
+
+func (p *Paper) Start(ctx context.Context, onError func(error)) error {
+	return p.live.Start(ctx, onError)
+}
+
+// Next returns the simulated fills and order updates before the next market data event.
+func (p *Paper) Next(ctx context.Context) (Event, error) {
+	if ev, ok := p.nextPending(); ok {
+		return ev, nil
+	}
+	ev, err := p.live.Next(ctx)
+	if err != nil {
+		return Event{}, err
+	}
+	if ev.Kind == EventBook {
+		p.sim.OnBook(ev.Market, ev.Book)
+	}
+	return ev, nil
+}
+
+func (p *Paper) nextPending() (Event, bool) {
+	p.pending = append(p.pending, p.sim.drain()...)
+	if len(p.pending) == 0 {
+		return Event{}, false
+	}
+	ev := p.pending[0]
+	p.pending = p.pending[1:]
+	return ev, true
+}
+
+// Client returns the Sim for every market.
+func (p *Paper) Client(string) client.OrderFillClient {
+	return p.sim
+}
+
+func (p *Paper) Now() time.Time {
+	return time.Now()
+}
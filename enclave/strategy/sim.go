@@ -0,0 +1,362 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var errNotSupported = errors.New("not supported by the simulator")
+
+// SimConfig configures the simulated exchange of paper and backtest backends.
+type SimConfig struct {
+	MakerFee decimal.Decimal // Fee of resting fills as a fraction of their cost
+	TakerFee decimal.Decimal // Fee of aggressive fills as a fraction of their cost
+}
+
+// Sim is a simulated exchange matching orders against the latest book of each market.
+// Aggressive orders take the book's liquidity level by level, which is then consumed until the next book.
+// Resting orders fill completely at their price once the opposite side of a new book reaches it.
+type Sim struct {
+	cfg SimConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	seq    int
+	books  map[string]*model.OrderBook
+	orders map[string]*model.Order
+	ids    []string // Order IDs in creation order
+	fills  []*model.Fill
+	events []Event // Fills and order updates not yet delivered
+}
+
+var _ client.OrderFillClient = (*Sim)(nil)
+
+// NewSim initializes a Sim using now as its clock.
+func NewSim(cfg SimConfig, now func() time.Time) *Sim {
+	return &Sim{
+		cfg:    cfg,
+		now:    now,
+		books:  map[string]*model.OrderBook{},
+		orders: map[string]*model.Order{},
+	}
+}
+
+// OnBook replaces the book of market and fills the resting orders it reaches.
+func (s *Sim) OnBook(market string, book *model.OrderBook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book = copyBook(book)
+	s.books[market] = book
+
+	bid, hasBid := book.BestBid()
+	ask, hasAsk := book.BestAsk()
+	for _, id := range s.ids {
+		o := s.orders[id]
+		if o.Market != market || o.Status != model.OrderStatusOpen {
+			continue
+		}
+		if o.Side == model.OrderSideBuy && hasAsk && ask.LessThanOrEqual(o.Price) ||
+			o.Side == model.OrderSideSell && hasBid && bid.GreaterThanOrEqual(o.Price) {
+			s.fill(o, o.Size.Sub(o.FilledSize), o.Price, s.cfg.MakerFee)
+			s.update(o)
+		}
+	}
+}
+
+// drain returns and forgets the events produced since the last call.
+func (s *Sim) drain() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func (s *Sim) AddOrder(req *api.AddOrderRequest) (*model.Order, error) {
+	switch {
+	case req.Market == "":
+		return nil, errors.New("market is required")
+	case req.Side != model.OrderSideBuy && req.Side != model.OrderSideSell:
+		return nil, fmt.Errorf("invalid side %q", req.Side)
+	case !req.Size.IsPositive():
+		return nil, errors.New("size must be positive, quote sizes are not supported by the simulator")
+	case req.Type == model.OrderTypeLimit && !req.Price.IsPositive():
+		return nil, errors.New("limit orders require a price")
+	case req.Type != model.OrderTypeLimit && req.Type != model.OrderTypeMarket:
+		return nil, fmt.Errorf("invalid order type %q", req.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.ClientOrderID != "" {
+		for _, o := range s.orders {
+			if o.ClientOrderID == req.ClientOrderID {
+				return nil, fmt.Errorf("duplicate client order ID %q", req.ClientOrderID)
+			}
+		}
+	}
+
+	book := s.books[req.Market]
+	if book == nil {
+		book = &model.OrderBook{}
+		s.books[req.Market] = book
+	}
+	levels := &book.Asks
+	if req.Side == model.OrderSideSell {
+		levels = &book.Bids
+	}
+	crosses := func(price decimal.Decimal) bool {
+		if req.Type == model.OrderTypeMarket {
+			return true
+		}
+		if req.Side == model.OrderSideBuy {
+			return price.LessThanOrEqual(req.Price)
+		}
+		return price.GreaterThanOrEqual(req.Price)
+	}
+	if req.PostOnly && len(*levels) > 0 && crosses((*levels)[0][0]) {
+		return nil, errors.New("post-only order would take liquidity")
+	}
+
+	s.seq++
+	o := &model.Order{
+		ClientOrderID: req.ClientOrderID,
+		CreatedAt:     s.now(),
+		Market:        req.Market,
+		OrderID:       strconv.Itoa(s.seq),
+		Price:         req.Price,
+		Side:          req.Side,
+		Size:          req.Size,
+		Status:        model.OrderStatusOpen,
+		Type:          req.Type,
+		TimeInForce:   req.TimeInForce,
+	}
+	s.orders[o.OrderID] = o
+	s.ids = append(s.ids, o.OrderID)
+
+	// Take liquidity level by level, consuming it from the book.
+	for len(*levels) > 0 && o.Size.GreaterThan(o.FilledSize) && crosses((*levels)[0][0]) {
+		level := (*levels)[0]
+		size := decimal.Min(level[1], o.Size.Sub(o.FilledSize))
+		s.fill(o, size, level[0], s.cfg.TakerFee)
+		if level[1] = level[1].Sub(size); !level[1].IsPositive() {
+			*levels = (*levels)[1:]
+		}
+	}
+	if o.Status == model.OrderStatusOpen && (req.Type == model.OrderTypeMarket || req.TimeInForce == model.TimeInForceIOC) {
+		o.Status = model.OrderStatusCanceled
+		o.CanceledAt = s.now()
+		o.CancelReason = "unfilled remainder of an immediate order"
+	}
+	s.update(o)
+	return copyOrder(o), nil
+}
+
+// fill records a fill of size at price for o. It must be called with mu held.
+func (s *Sim) fill(o *model.Order, size, price, feeRate decimal.Decimal) {
+	now := s.now()
+	cost := size.Mul(price)
+	f := &model.Fill{
+		ClientOrderID: o.ClientOrderID,
+		Fee:           cost.Mul(feeRate),
+		FilledCost:    cost,
+		ID:            strconv.Itoa(len(s.fills) + 1),
+		Market:        o.Market,
+		OrderID:       o.OrderID,
+		Price:         price,
+		Side:          o.Side,
+		Size:          size,
+		Time:          now,
+	}
+	s.fills = append(s.fills, f)
+
+	o.FilledSize = o.FilledSize.Add(size)
+	o.FilledCost = o.FilledCost.Add(cost)
+	o.Fee = o.Fee.Add(f.Fee)
+	if !o.FilledSize.LessThan(o.Size) {
+		o.Status = model.OrderStatusFullyFilled
+		o.FilledAt = now
+	}
+	fc := *f
+	s.events = append(s.events, Event{Kind: EventFill, Time: now, Market: o.Market, Fill: &fc})
+}
+
+// update queues an order update event. It must be called with mu held.
+func (s *Sim) update(o *model.Order) {
+	s.events = append(s.events, Event{Kind: EventOrder, Time: s.now(), Market: o.Market, Order: copyOrder(o)})
+}
+
+func (s *Sim) find(orderID, clientOrderID string) (*model.Order, error) {
+	if orderID != "" {
+		if o, ok := s.orders[orderID]; ok {
+			return o, nil
+		}
+	} else if clientOrderID != "" {
+		for _, o := range s.orders {
+			if o.ClientOrderID == clientOrderID {
+				return o, nil
+			}
+		}
+	}
+	return nil, errors.New("order not found")
+}
+
+func (s *Sim) GetOrder(req *api.GetOrderRequest) (*model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, err := s.find(req.OrderID, req.ClientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	return copyOrder(o), nil
+}
+
+func (s *Sim) GetOrders(req *api.GetOrdersRequest) (*api.GetOrdersResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &api.GetOrdersResponse{}
+	for i := len(s.ids) - 1; i >= 0; i-- {
+		o := s.orders[s.ids[i]]
+		if req.Market != "" && o.Market != req.Market || req.Status != "" && o.Status != req.Status {
+			continue
+		}
+		res.Orders = append(res.Orders, *o)
+	}
+	return res, nil
+}
+
+func (s *Sim) CancelOrder(req *api.CancelOrderRequest) (*model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, err := s.find(req.OrderID, req.ClientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != model.OrderStatusOpen {
+		return nil, fmt.Errorf("order %s is %s", o.OrderID, o.Status)
+	}
+	s.cancel(o)
+	return copyOrder(o), nil
+}
+
+func (s *Sim) CancelOrders(req *api.CancelOrdersRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.ids {
+		o := s.orders[id]
+		if o.Status == model.OrderStatusOpen && (req.Market == "" || o.Market == req.Market) {
+			s.cancel(o)
+		}
+	}
+	return nil
+}
+
+// cancel closes o. It must be called with mu held.
+func (s *Sim) cancel(o *model.Order) {
+	o.Status = model.OrderStatusCanceled
+	o.CanceledAt = s.now()
+	o.CancelReason = "canceled by user"
+	s.update(o)
+}
+
+func (s *Sim) GetDepth(req *api.GetDepthRequest) (*model.OrderBook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.books[req.Market]
+	if !ok {
+		return nil, fmt.Errorf("no book for %s yet", req.Market)
+	}
+	return copyBook(book), nil
+}
+
+func (s *Sim) GetFills(req *api.GetFillsRequest) ([]*model.Fill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.Fill
+	for i := len(s.fills) - 1; i >= 0; i-- {
+		f := s.fills[i]
+		ms := f.Time.UnixMilli()
+		if req.Market != "" && f.Market != req.Market || req.StartMs != 0 && ms < req.StartMs || req.EndMs != 0 && ms > req.EndMs {
+			continue
+		}
+		fc := *f
+		res = append(res, &fc)
+		if req.Limit > 0 && len(res) == req.Limit {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s *Sim) GetFillsPage(req *api.GetFillsRequest) (*api.GetFillsResponse, error) {
+	fills, err := s.GetFills(req)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetFillsResponse{Fills: fills}, nil
+}
+
+func (s *Sim) GetFillsByID(req *api.GetFillsByIDRequest) ([]*model.Fill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.Fill
+	for _, f := range s.fills {
+		if req.OrderID != "" && f.OrderID == req.OrderID || req.OrderID == "" && req.ClientOrderID != "" && f.ClientOrderID == req.ClientOrderID {
+			fc := *f
+			res = append(res, &fc)
+		}
+	}
+	return res, nil
+}
+
+func (s *Sim) GetOrdersCSV(*api.GetOrdersCSVRequest) (string, error) {
+	return "", errNotSupported
+}
+
+func (s *Sim) GetOrdersCSVStream(*api.GetOrdersCSVRequest) (io.ReadCloser, error) {
+	return nil, errNotSupported
+}
+
+func (s *Sim) GetFillsCSV(*api.GetFillsCSVRequest) (string, error) {
+	return "", errNotSupported
+}
+
+func (s *Sim) GetFillsCSVStream(*api.GetFillsCSVRequest) (io.ReadCloser, error) {
+	return nil, errNotSupported
+}
+
+func copyOrder(o *model.Order) *model.Order {
+	c := *o
+	return &c
+}
+
+// copyBook deep-copies a book, sorting bids descending and asks ascending.
+func copyBook(b *model.OrderBook) *model.OrderBook {
+	c := &model.OrderBook{}
+	for _, l := range b.Bids {
+		if len(l) < 2 {
+			continue
+		}
+		c.Bids = append(c.Bids, append([]decimal.Decimal(nil), l...))
+	}
+	for _, l := range b.Asks {
+		if len(l) < 2 {
+			continue
+		}
+		c.Asks = append(c.Asks, append([]decimal.Decimal(nil), l...))
+	}
+	sort.SliceStable(c.Bids, func(i, j int) bool { return c.Bids[i][0].GreaterThan(c.Bids[j][0]) })
+	sort.SliceStable(c.Asks, func(i, j int) bool { return c.Asks[i][0].LessThan(c.Asks[j][0]) })
+	return c
+}
@@ -0,0 +1,212 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+func testBook() *model.OrderBook {
+	return &model.OrderBook{
+		Asks: [][]decimal.Decimal{{d("101"), d("1")}, {d("102"), d("2")}},
+		Bids: [][]decimal.Decimal{{d("99"), d("1")}, {d("98"), d("2")}},
+	}
+}
+
+func newTestSim() *Sim {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSim(SimConfig{MakerFee: d("0.001"), TakerFee: d("0.002")}, func() time.Time { return now })
+	s.OnBook("AVAX-USDC", testBook())
+	return s
+}
+
+func TestSimAddOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        api.AddOrderRequest
+		wantErr    bool
+		wantStatus model.OrderStatus
+		wantFilled string
+		wantCost   string
+		wantFee    string
+	}{
+		{
+			name:       "market buy walks the asks",
+			req:        api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("2"), Type: model.OrderTypeMarket},
+			wantStatus: model.OrderStatusFullyFilled,
+			wantFilled: "2",
+			wantCost:   "203",
+			wantFee:    "0.406",
+		},
+		{
+			name:       "market sell walks the bids",
+			req:        api.AddOrderRequest{Side: model.OrderSideSell, Size: d("1.5"), Type: model.OrderTypeMarket},
+			wantStatus: model.OrderStatusFullyFilled,
+			wantFilled: "1.5",
+			wantCost:   "148",
+			wantFee:    "0.296",
+		},
+		{
+			name:       "market order larger than the book",
+			req:        api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("5"), Type: model.OrderTypeMarket},
+			wantStatus: model.OrderStatusCanceled,
+			wantFilled: "3",
+			wantCost:   "305",
+			wantFee:    "0.61",
+		},
+		{
+			name:       "limit buy rests its remainder",
+			req:        api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("1.5"), Price: d("101"), Type: model.OrderTypeLimit, TimeInForce: model.TimeInForceGTC},
+			wantStatus: model.OrderStatusOpen,
+			wantFilled: "1",
+			wantCost:   "101",
+			wantFee:    "0.202",
+		},
+		{
+			name:       "IOC limit buy cancels its remainder",
+			req:        api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("1.5"), Price: d("101"), Type: model.OrderTypeLimit, TimeInForce: model.TimeInForceIOC},
+			wantStatus: model.OrderStatusCanceled,
+			wantFilled: "1",
+			wantCost:   "101",
+			wantFee:    "0.202",
+		},
+		{
+			name:       "limit sell below the bids rests",
+			req:        api.AddOrderRequest{Side: model.OrderSideSell, Size: d("1"), Price: d("100"), Type: model.OrderTypeLimit, TimeInForce: model.TimeInForceGTC},
+			wantStatus: model.OrderStatusOpen,
+			wantFilled: "0",
+			wantCost:   "0",
+			wantFee:    "0",
+		},
+		{
+			name:    "post-only order that would take",
+			req:     api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("1"), Price: d("101"), Type: model.OrderTypeLimit, PostOnly: true},
+			wantErr: true,
+		},
+		{
+			name:    "limit order without price",
+			req:     api.AddOrderRequest{Side: model.OrderSideBuy, Size: d("1"), Type: model.OrderTypeLimit},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSim()
+			req := tt.req
+			req.Market = "AVAX-USDC"
+			o, err := s.AddOrder(&req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddOrder() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if o.Status != tt.wantStatus || !o.FilledSize.Equal(d(tt.wantFilled)) || !o.FilledCost.Equal(d(tt.wantCost)) || !o.Fee.Equal(d(tt.wantFee)) {
+				t.Fatalf("order = %s filled %s for %s with fee %s, want %s filled %s for %s with fee %s",
+					o.Status, o.FilledSize, o.FilledCost, o.Fee, tt.wantStatus, tt.wantFilled, tt.wantCost, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestSimConsumesLiquidity(t *testing.T) {
+	s := newTestSim()
+	for i, want := range []string{"101", "102", "102"} {
+		o, err := s.AddOrder(&api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Size: d("1"), Type: model.OrderTypeMarket})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !o.FilledCost.Equal(d(want)) {
+			t.Fatalf("buy %d filled for %s, want %s", i+1, o.FilledCost, want)
+		}
+	}
+	if book := testBook(); !book.Asks[0][1].Equal(d("1")) {
+		t.Fatal("the simulator consumed the caller's book")
+	}
+
+	s.OnBook("AVAX-USDC", testBook())
+	o, err := s.AddOrder(&api.AddOrderRequest{Market: "AVAX-USDC", Side: model.OrderSideBuy, Size: d("1"), Type: model.OrderTypeMarket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.FilledCost.Equal(d("101")) {
+		t.Fatalf("buy after a new book filled for %s, want 101", o.FilledCost)
+	}
+}
+
+func TestSimOnBookFillsRestingOrders(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     model.OrderSide
+		price    string
+		book     *model.OrderBook
+		wantFill bool
+	}{
+		{
+			name:     "ask reaches a bid",
+			side:     model.OrderSideBuy,
+			price:    "100",
+			book:     &model.OrderBook{Asks: [][]decimal.Decimal{{d("100"), d("1")}}},
+			wantFill: true,
+		},
+		{
+			name:  "ask above a bid",
+			side:  model.OrderSideBuy,
+			price: "100",
+			book:  &model.OrderBook{Asks: [][]decimal.Decimal{{d("100.5"), d("1")}}},
+		},
+		{
+			name:     "bid reaches an ask, unsorted book",
+			side:     model.OrderSideSell,
+			price:    "100",
+			book:     &model.OrderBook{Bids: [][]decimal.Decimal{{d("99"), d("1")}, {d("100.5"), d("1")}}},
+			wantFill: true,
+		},
+		{
+			name:  "bid below an ask",
+			side:  model.OrderSideSell,
+			price: "100",
+			book:  &model.OrderBook{Bids: [][]decimal.Decimal{{d("99.5"), d("1")}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSim()
+			o, err := s.AddOrder(&api.AddOrderRequest{Market: "AVAX-USDC", Side: tt.side, Size: d("2"), Price: d(tt.price), Type: model.OrderTypeLimit, TimeInForce: model.TimeInForceGTC})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.Status != model.OrderStatusOpen {
+				t.Fatalf("order is %s before the new book, want open", o.Status)
+			}
+			s.drain()
+
+			s.OnBook("AVAX-USDC", tt.book)
+			o, err = s.GetOrder(&api.GetOrderRequest{OrderID: o.OrderID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filled := o.Status == model.OrderStatusFullyFilled; filled != tt.wantFill {
+				t.Fatalf("order is %s, want filled %v", o.Status, tt.wantFill)
+			}
+			if !tt.wantFill {
+				return
+			}
+			// Resting orders fill at their own price and pay the maker fee.
+			if !o.FilledCost.Equal(d(tt.price).Mul(d("2"))) || !o.Fee.Equal(o.FilledCost.Mul(d("0.001"))) {
+				t.Fatalf("order filled for %s with fee %s", o.FilledCost, o.Fee)
+			}
+			if events := s.drain(); len(events) != 2 || events[0].Kind != EventFill || events[1].Kind != EventOrder {
+				t.Fatalf("events = %+v, want a fill then an order update", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package strategy runs event-driven trading strategies against live, paper or backtest backends.
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+	"github.com/yangnei/enclave-go/enclave/oms"
+)
+
+type EventKind string
+
+const (
+	EventBook    EventKind = "book"    // New order book of a market
+	EventFill    EventKind = "fill"    // New fill of the account
+	EventOrder   EventKind = "order"   // An order of the account was added, filled or closed
+	EventFunding EventKind = "funding" // New estimated funding rate of a perps market
+	EventTimer   EventKind = "timer"   // Timer interval elapsed
+)
+
+// Event is one input of a strategy. Exactly one of Book, Fill, Order and Funding is set, except for timer events.
+type Event struct {
+	Kind    EventKind          `json:"kind"`
+	Time    time.Time          `json:"time"`
+	Market  string             `json:"market,omitempty"`
+	Book    *model.OrderBook   `json:"book,omitempty"`
+	Fill    *model.Fill        `json:"fill,omitempty"`
+	Order   *model.Order       `json:"order,omitempty"`
+	Funding *model.FundingRate `json:"funding,omitempty"`
+}
+
+// Strategy reacts to events. Callbacks of a strategy are never called concurrently.
+// An error returned by a callback other than OnStart is reported and does not stop the strategy.
+type Strategy interface {
+	OnStart(env *Env) error
+	OnBook(env *Env, market string, book *model.OrderBook) error
+	OnFill(env *Env, fill *model.Fill) error
+	OnOrderUpdate(env *Env, order *model.Order) error
+	OnFundingRate(env *Env, rate *model.FundingRate) error
+	OnTimer(env *Env, now time.Time) error
+	OnStop(env *Env) error
+}
+
+// Base implements every callback as a no-op, for strategies to embed and override the ones they need.
+type Base struct{}
+
+func (Base) OnStart(*Env) error                           { return nil }
+func (Base) OnBook(*Env, string, *model.OrderBook) error  { return nil }
+func (Base) OnFill(*Env, *model.Fill) error               { return nil }
+func (Base) OnOrderUpdate(*Env, *model.Order) error       { return nil }
+func (Base) OnFundingRate(*Env, *model.FundingRate) error { return nil }
+func (Base) OnTimer(*Env, time.Time) error                { return nil }
+func (Base) OnStop(*Env) error                            { return nil }
+
+// Backend produces events and executes orders: live, paper or backtest.
+type Backend interface {
+	// Start begins producing events. Background work stops when ctx is done; errors that do not stop it go to onError.
+	Start(ctx context.Context, onError func(error)) error
+	// Next blocks until the next event and returns io.EOF once no event will come anymore.
+	Next(ctx context.Context) (Event, error)
+	// Client returns the client orders of market are sent through.
+	Client(market string) client.OrderFillClient
+	// Now returns the current time of the backend, simulated in backtests.
+	Now() time.Time
+}
+
+// Env is what callbacks act through. Strategies should use its clock and clients rather than their own, so that
+// they behave the same on every backend.
+type Env struct {
+	ctx     context.Context
+	backend Backend
+	batch   *oms.BatchOptions
+}
+
+// Context returns the context of the run.
+func (e *Env) Context() context.Context {
+	return e.ctx
+}
+
+// Now returns the current time of the backend.
+func (e *Env) Now() time.Time {
+	return e.backend.Now()
+}
+
+// Client returns the order client of market.
+func (e *Env) Client(market string) client.OrderFillClient {
+	return e.backend.Client(market)
+}
+
+// OMS returns the order management handle of market.
+func (e *Env) OMS(market string) *OMS {
+	return &OMS{c: e.backend.Client(market), market: market, batch: e.batch}
+}
+
+// OMS sends the orders of one market through the backend.
+type OMS struct {
+	c      client.OrderFillClient
+	market string
+	batch  *oms.BatchOptions
+}
+
+// Place adds an order in the market of the OMS.
+func (o *OMS) Place(req api.AddOrderRequest) (*model.Order, error) {
+	req.Market = o.market
+	return o.c.AddOrder(&req)
+}
+
+// PlaceOrders adds orders in the market of the OMS, see oms.PlaceOrders.
+func (o *OMS) PlaceOrders(reqs []api.AddOrderRequest) []oms.PlaceResult {
+	for i := range reqs {
+		reqs[i].Market = o.market
+	}
+	return oms.PlaceOrders(o.c, reqs, o.batch)
+}
+
+// Cancel cancels an order by ID.
+func (o *OMS) Cancel(orderID string) (*model.Order, error) {
+	return o.c.CancelOrder(&api.CancelOrderRequest{OrderID: orderID})
+}
+
+// CancelAll cancels every open order of the market.
+func (o *OMS) CancelAll() error {
+	return o.c.CancelOrders(&api.CancelOrdersRequest{Market: o.market})
+}
+
+// CancelOrdersByID cancels orders by ID, see oms.CancelOrdersByID.
+func (o *OMS) CancelOrdersByID(ids []string) []oms.CancelResult {
+	return oms.CancelOrdersByID(o.c, ids, o.batch)
+}
+
+// CancelMatching cancels the open orders of the market selected by f, see oms.CancelMatching.
+func (o *OMS) CancelMatching(f oms.CancelFilter) ([]oms.CancelResult, error) {
+	f.Market = o.market
+	return oms.CancelMatching(o.c, &f, o.batch)
+}
+
+// Replace cancels and replaces an order, see oms.Replace.
+func (o *OMS) Replace(req *oms.ReplaceRequest) (*oms.ReplaceResult, error) {
+	return oms.Replace(o.c, req)
+}
+
+// OpenOrders lists the open orders of the market.
+func (o *OMS) OpenOrders() ([]model.Order, error) {
+	return oms.OpenOrders(o.c, o.market)
+}
+
+// Config configures Run.
+type Config struct {
+	Batch   *oms.BatchOptions // Options of the OMS batch operations
+	Record  io.Writer         // Optional destination of book and funding events as JSON lines, replayable with NewBacktest
+	OnError func(error)       // Called with callback and backend errors, may be nil
+}
+
+// Run starts backend and calls the callbacks of s with its events, one at a time, until the backend is exhausted
+// or ctx is done. OnStop is called in both cases; it returns ctx.Err() in the latter. The goroutines of the backend
+// are stopped before Run returns.
+func Run(ctx context.Context, backend Backend, s Strategy, cfg Config) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	report := func(err error) {
+		if cfg.OnError != nil {
+			cfg.OnError(err)
+		}
+	}
+	if err := backend.Start(ctx, report); err != nil {
+		return fmt.Errorf("failed to start backend: %w", err)
+	}
+
+	env := &Env{ctx: ctx, backend: backend, batch: cfg.Batch}
+	if err := s.OnStart(env); err != nil {
+		return fmt.Errorf("failed to start strategy: %w", err)
+	}
+
+	var (
+		enc    *json.Encoder
+		runErr error
+	)
+	if cfg.Record != nil {
+		enc = json.NewEncoder(cfg.Record)
+	}
+	for {
+		ev, err := backend.Next(ctx)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				runErr = err
+			}
+			break
+		}
+		if enc != nil && (ev.Kind == EventBook || ev.Kind == EventFunding) {
+			if err := enc.Encode(ev); err != nil {
+				report(fmt.Errorf("failed to record event: %w", err))
+			}
+		}
+		if err := dispatch(env, s, &ev); err != nil {
+			report(fmt.Errorf("%s callback: %w", ev.Kind, err))
+		}
+	}
+
+	if err := s.OnStop(env); err != nil {
+		return errors.Join(runErr, fmt.Errorf("failed to stop strategy: %w", err))
+	}
+	return runErr
+}
+
+func dispatch(env *Env, s Strategy, ev *Event) error {
+	switch ev.Kind {
+	case EventBook:
+		return s.OnBook(env, ev.Market, ev.Book)
+	case EventFill:
+		return s.OnFill(env, ev.Fill)
+	case EventOrder:
+		return s.OnOrderUpdate(env, ev.Order)
+	case EventFunding:
+		return s.OnFundingRate(env, ev.Funding)
+	case EventTimer:
+		return s.OnTimer(env, ev.Time)
+	}
+	return fmt.Errorf("unknown event kind %q", ev.Kind)
+}
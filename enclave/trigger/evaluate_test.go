@@ -0,0 +1,262 @@
+package trigger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var d = decimal.RequireFromString
+
+// fixedSource quotes price, observed age before the engine's clock.
+type fixedSource struct {
+	price string
+	age   time.Duration
+	now   func() time.Time
+}
+
+func (s *fixedSource) Quote(market string) (Quote, error) {
+	return Quote{Price: d(s.price), Time: s.now().Add(-s.age)}, nil
+}
+
+func TestCondition(t *testing.T) {
+	sell := &Trigger{Side: model.OrderSideSell, StopLoss: d("90"), TakeProfit: d("110")}
+	buy := &Trigger{Side: model.OrderSideBuy, StopLoss: d("110"), TakeProfit: d("90")}
+	tests := []struct {
+		name    string
+		trigger *Trigger
+		price   string
+		want    Leg
+	}{
+		{"sell below stop loss", sell, "89", LegStopLoss},
+		{"sell at stop loss", sell, "90", LegStopLoss},
+		{"sell between legs", sell, "100", ""},
+		{"sell at take profit", sell, "110", LegTakeProfit},
+		{"buy above stop loss", buy, "111", LegStopLoss},
+		{"buy between legs", buy, "100", ""},
+		{"buy below take profit", buy, "89", LegTakeProfit},
+		{"stop loss only", &Trigger{Side: model.OrderSideSell, StopLoss: d("90")}, "150", ""},
+		{"take profit only", &Trigger{Side: model.OrderSideSell, TakeProfit: d("110")}, "50", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leg, ok := tt.trigger.condition(d(tt.price))
+			if leg != tt.want || ok != (tt.want != "") {
+				t.Fatalf("condition(%s) = %q, %v, want %q", tt.price, leg, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateFires(t *testing.T) {
+	tests := []struct {
+		name      string
+		trigger   Trigger
+		price     string
+		age       time.Duration
+		wantLeg   Leg
+		wantType  model.OrderType
+		wantPrice string // Limit price of the order, empty for market orders
+		wantEvent EventKind
+	}{
+		{
+			name:      "stop loss sells at market",
+			trigger:   Trigger{Side: model.OrderSideSell, StopLoss: d("90")},
+			price:     "89",
+			wantLeg:   LegStopLoss,
+			wantType:  model.OrderTypeMarket,
+			wantEvent: EventFired,
+		},
+		{
+			name:      "take profit buys with a limit past the trigger",
+			trigger:   Trigger{Side: model.OrderSideBuy, TakeProfit: d("90"), Type: model.OrderTypeLimit, LimitOffset: d("0.5")},
+			price:     "89",
+			wantLeg:   LegTakeProfit,
+			wantType:  model.OrderTypeLimit,
+			wantPrice: "90.5",
+			wantEvent: EventFired,
+		},
+		{
+			name:      "OCO fires the leg reached",
+			trigger:   Trigger{Side: model.OrderSideSell, StopLoss: d("90"), TakeProfit: d("110"), Type: model.OrderTypeLimit, LimitOffset: d("1")},
+			price:     "111",
+			wantLeg:   LegTakeProfit,
+			wantType:  model.OrderTypeLimit,
+			wantPrice: "109",
+			wantEvent: EventFired,
+		},
+		{
+			name:    "OCO between legs stays armed",
+			trigger: Trigger{Side: model.OrderSideSell, StopLoss: d("90"), TakeProfit: d("110")},
+			price:   "100",
+		},
+		{
+			name:      "stale quote is not evaluated",
+			trigger:   Trigger{Side: model.OrderSideSell, StopLoss: d("90")},
+			price:     "80",
+			age:       time.Minute,
+			wantEvent: EventStale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := func() time.Time { return now }
+			ctrl := gomock.NewController(t)
+			spot := mocks.NewMockSpotClient(ctrl)
+			if tt.wantLeg != "" {
+				spot.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+					if req.Type != tt.wantType || req.Side != tt.trigger.Side || !req.Size.Equal(d("2")) {
+						t.Errorf("order = %+v, want a %s %s order of 2", req, tt.wantType, tt.trigger.Side)
+					}
+					if tt.wantPrice != "" && !req.Price.Equal(d(tt.wantPrice)) {
+						t.Errorf("limit price = %s, want %s", req.Price, tt.wantPrice)
+					}
+					return &model.Order{OrderID: "o1"}, nil
+				})
+			}
+
+			var events []Event
+			e, err := New(spot, Config{
+				StatePath:     filepath.Join(t.TempDir(), "triggers.json"),
+				Sources:       map[string]PriceSource{"fixed": &fixedSource{price: tt.price, age: tt.age, now: clock}},
+				DefaultSource: "fixed",
+				OnEvent:       func(ev Event) { events = append(events, ev) },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.now = clock
+
+			tr := tt.trigger
+			tr.Market, tr.Size = "AVAX-USDC", d("2")
+			added, err := e.Add(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The second evaluation must not fire an OCO's other leg or report the same staleness again.
+			for i := 0; i < 2; i++ {
+				if err := e.Evaluate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := e.Triggers()[0]
+			wantStatus := StatusArmed
+			if tt.wantLeg != "" {
+				wantStatus = StatusFired
+			}
+			if got.ID != added.ID || got.Status != wantStatus || got.FiredLeg != tt.wantLeg {
+				t.Fatalf("trigger = %+v, want %s by %q", got, wantStatus, tt.wantLeg)
+			}
+			if tt.wantEvent == "" {
+				if len(events) != 0 {
+					t.Fatalf("events = %+v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Kind != tt.wantEvent {
+				t.Fatalf("events = %+v, want one %s event", events, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestStaleQuoteRecovers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	ctrl := gomock.NewController(t)
+	spot := mocks.NewMockSpotClient(ctrl)
+	// The last fill is an hour old until a new one arrives.
+	gomock.InOrder(
+		spot.EXPECT().GetFills(gomock.Any()).Return([]*model.Fill{{Price: d("80"), Time: now.Add(-time.Hour)}}, nil),
+		spot.EXPECT().GetFills(gomock.Any()).Return([]*model.Fill{{Price: d("80"), Time: now.Add(-time.Second)}}, nil),
+	)
+	spot.EXPECT().AddOrder(gomock.Any()).Return(&model.Order{OrderID: "o1"}, nil)
+
+	var kinds []EventKind
+	e, err := New(spot, Config{
+		StatePath:     filepath.Join(t.TempDir(), "triggers.json"),
+		DefaultSource: SourceLastFill,
+		OnEvent:       func(ev Event) { kinds = append(kinds, ev.Kind) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = clock
+	if _, err := e.Add(Trigger{Market: "AVAX-USDC", Side: model.OrderSideSell, Size: d("1"), StopLoss: d("90")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := e.Evaluate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(kinds) != 3 || kinds[0] != EventStale || kinds[1] != EventRecovered || kinds[2] != EventFired {
+		t.Fatalf("events = %v, want stale, recovered, fired", kinds)
+	}
+}
+
+// blockingSource blocks every quote until release is closed.
+type blockingSource struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSource) Quote(market string) (Quote, error) {
+	close(s.started)
+	<-s.release
+	return Quote{Price: d("100"), Time: time.Now()}, nil
+}
+
+func TestEvaluateDoesNotBlockCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	spot := mocks.NewMockSpotClient(ctrl)
+	source := &blockingSource{started: make(chan struct{}), release: make(chan struct{})}
+	e, err := New(spot, Config{
+		StatePath:     filepath.Join(t.TempDir(), "triggers.json"),
+		Sources:       map[string]PriceSource{"blocking": source},
+		DefaultSource: "blocking",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := e.Add(Trigger{Market: "AVAX-USDC", Side: model.OrderSideSell, Size: d("1"), StopLoss: d("110")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- e.Evaluate() }()
+	<-source.started
+
+	canceled := make(chan error)
+	go func() { canceled <- e.Cancel(tr.ID) }()
+	select {
+	case err := <-canceled:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Cancel blocked on the evaluation's quote")
+	}
+
+	// The quote meets the stop loss, but the trigger was canceled meanwhile.
+	close(source.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Triggers()[0].Status; got != StatusCanceled {
+		t.Fatalf("status = %s, want %s", got, StatusCanceled)
+	}
+}
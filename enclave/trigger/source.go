@@ -0,0 +1,74 @@
+package trigger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+)
+
+// Names of the built-in price sources.
+const (
+	SourceMid      = "mid"      // Midpoint of the best bid and ask
+	SourceLastFill = "lastFill" // Price of the account's own last fill in the market
+)
+
+// Quote is a price and the time it was observed at.
+type Quote struct {
+	Price decimal.Decimal `json:"price"`
+	Time  time.Time       `json:"time"`
+}
+
+// PriceSource provides the price triggers are evaluated against.
+type PriceSource interface {
+	Quote(market string) (Quote, error)
+}
+
+type depthMid struct {
+	c   client.OrderFillClient
+	now func() time.Time
+}
+
+// DepthMid returns a PriceSource quoting the order book mid, timed when the book was fetched.
+func DepthMid(c client.OrderFillClient) PriceSource {
+	return &depthMid{c: c, now: time.Now}
+}
+
+func (s *depthMid) Quote(market string) (Quote, error) {
+	book, err := s.c.GetDepth(&api.GetDepthRequest{Market: market, Depth: 1})
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to get depth: %w", err)
+	}
+	mid, ok := book.Mid()
+	if !ok {
+		return Quote{}, errors.New("order book has no bid or no ask")
+	}
+	return Quote{Price: mid, Time: s.now()}, nil
+}
+
+type lastFill struct {
+	c client.OrderFillClient
+}
+
+// LastFill returns a PriceSource quoting the price of the account's own last fill in the market, timed when the fill
+// happened. The API has no public trade feed, so the price only follows the market while the account trades in it.
+func LastFill(c client.OrderFillClient) PriceSource {
+	return &lastFill{c: c}
+}
+
+func (s *lastFill) Quote(market string) (Quote, error) {
+	req := &api.GetFillsRequest{Market: market}
+	req.Limit = 1
+	fills, err := s.c.GetFills(req)
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to get fills: %w", err)
+	}
+	if len(fills) == 0 {
+		return Quote{}, errors.New("no fills")
+	}
+	return Quote{Price: fills[0].Price, Time: fills[0].Time}, nil
+}
@@ -0,0 +1,52 @@
+package trigger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// load reads the triggers persisted at path. A missing file holds no triggers.
+func load(path string) (map[string]*Trigger, error) {
+	triggers := map[string]*Trigger{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return triggers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read triggers: %w", err)
+	}
+
+	var list []*Trigger
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode triggers: %w", err)
+	}
+	for _, t := range list {
+		triggers[t.ID] = t
+	}
+	return triggers, nil
+}
+
+// save atomically replaces the persisted triggers. It must be called with mu held.
+func (e *Engine) save() error {
+	list := make([]*Trigger, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := e.cfg.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write triggers: %w", err)
+	}
+	if err := os.Rename(tmp, e.cfg.StatePath); err != nil {
+		return fmt.Errorf("failed to write triggers: %w", err)
+	}
+	return nil
+}
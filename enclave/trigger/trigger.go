@@ -0,0 +1,466 @@
+// Package trigger implements client-side stop-loss, take-profit and OCO triggers for spot markets.
+package trigger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var ErrNotFound = errors.New("trigger not found")
+
+type Status string
+
+const (
+	StatusArmed    Status = "armed"    // Watching the price
+	StatusFiring   Status = "firing"   // Condition met, order being submitted
+	StatusFired    Status = "fired"    // Order submitted
+	StatusCanceled Status = "canceled" // Canceled before firing
+	StatusFailed   Status = "failed"   // Order rejected
+)
+
+type Leg string
+
+const (
+	LegStopLoss   Leg = "stopLoss"
+	LegTakeProfit Leg = "takeProfit"
+)
+
+// Trigger submits an order when the price reaches its stop loss or its take profit. With both set, it is an OCO:
+// the first leg to fire submits the order and the other one is done.
+type Trigger struct {
+	ID          string          `json:"id"`
+	Market      string          `json:"market"`
+	Side        model.OrderSide `json:"side"`        // Side of the order submitted, sell to protect a holding
+	Size        decimal.Decimal `json:"size"`        // Size of the order submitted
+	StopLoss    decimal.Decimal `json:"stopLoss"`    // Price at which the position is cut, zero for none
+	TakeProfit  decimal.Decimal `json:"takeProfit"`  // Price at which the profit is taken, zero for none
+	Type        model.OrderType `json:"type"`        // Market, the default, or limit
+	LimitOffset decimal.Decimal `json:"limitOffset"` // Distance past the trigger price a limit order is priced at
+	Source      string          `json:"source"`      // Name of the price source, defaults to the engine's default
+
+	Status        Status          `json:"status"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	FiredAt       time.Time       `json:"firedAt,omitempty"`
+	FiredLeg      Leg             `json:"firedLeg,omitempty"`
+	FirePrice     decimal.Decimal `json:"firePrice"`
+	ClientOrderID string          `json:"clientOrderId,omitempty"`
+	OrderID       string          `json:"orderId,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// condition returns the leg whose condition price meets, if any.
+func (t *Trigger) condition(price decimal.Decimal) (Leg, bool) {
+	sell := t.Side == model.OrderSideSell
+	if t.StopLoss.IsPositive() && (sell && price.LessThanOrEqual(t.StopLoss) || !sell && price.GreaterThanOrEqual(t.StopLoss)) {
+		return LegStopLoss, true
+	}
+	if t.TakeProfit.IsPositive() && (sell && price.GreaterThanOrEqual(t.TakeProfit) || !sell && price.LessThanOrEqual(t.TakeProfit)) {
+		return LegTakeProfit, true
+	}
+	return "", false
+}
+
+func (t *Trigger) validate() error {
+	switch {
+	case t.Market == "":
+		return errors.New("market is required")
+	case t.Side != model.OrderSideBuy && t.Side != model.OrderSideSell:
+		return fmt.Errorf("invalid side %q", t.Side)
+	case !t.Size.IsPositive():
+		return errors.New("size must be positive")
+	case !t.StopLoss.IsPositive() && !t.TakeProfit.IsPositive():
+		return errors.New("stop loss or take profit is required")
+	case t.Type != model.OrderTypeMarket && t.Type != model.OrderTypeLimit:
+		return fmt.Errorf("invalid order type %q", t.Type)
+	case t.StopLoss.IsPositive() && t.TakeProfit.IsPositive():
+		if t.Side == model.OrderSideSell && !t.StopLoss.LessThan(t.TakeProfit) {
+			return errors.New("stop loss of a sell trigger must be below its take profit")
+		}
+		if t.Side == model.OrderSideBuy && !t.StopLoss.GreaterThan(t.TakeProfit) {
+			return errors.New("stop loss of a buy trigger must be above its take profit")
+		}
+	}
+	return nil
+}
+
+type EventKind string
+
+const (
+	EventFired     EventKind = "fired"     // A trigger submitted its order
+	EventFailed    EventKind = "failed"    // A trigger could not submit its order
+	EventStale     EventKind = "stale"     // A price became too old to evaluate triggers against
+	EventRecovered EventKind = "recovered" // A stale price is fresh again
+)
+
+// Event describes something that happened to the triggers of a market.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    EventKind `json:"kind"`
+	Market  string    `json:"market"`
+	Source  string    `json:"source"`
+	Quote   Quote     `json:"quote"`
+	Trigger *Trigger  `json:"trigger,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Config configures an Engine.
+type Config struct {
+	Interval      time.Duration          // Time between two evaluations, defaults to 5 seconds
+	StatePath     string                 // File the triggers are persisted to, required
+	Sources       map[string]PriceSource // Additional or replacement price sources by name
+	DefaultSource string                 // Source of triggers that do not name one, defaults to SourceMid
+	MaxAge        time.Duration          // Age beyond which a quote is stale and triggers are not evaluated, defaults to 30 seconds
+	OnEvent       func(Event)            // Called for every event, may be nil
+}
+
+// Engine evaluates triggers against prices and submits their orders through a spot client.
+type Engine struct {
+	spot    client.SpotClient
+	cfg     Config
+	sources map[string]PriceSource
+	now     func() time.Time
+
+	// evalMu serializes evaluations, which make network calls and run callbacks without holding mu. Only evaluations
+	// change firing triggers and stale; every change to a trigger is made with mu held.
+	evalMu sync.Mutex
+	stale  map[string]bool // By source and market, to report changes only
+
+	mu       sync.Mutex
+	triggers map[string]*Trigger
+}
+
+// New initializes an Engine and loads the triggers persisted at cfg.StatePath.
+func New(spot client.SpotClient, cfg Config) (*Engine, error) {
+	if cfg.StatePath == "" {
+		return nil, errors.New("state path is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.DefaultSource == "" {
+		cfg.DefaultSource = SourceMid
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 30 * time.Second
+	}
+
+	sources := map[string]PriceSource{
+		SourceMid:      DepthMid(spot),
+		SourceLastFill: LastFill(spot),
+	}
+	for name, s := range cfg.Sources {
+		sources[name] = s
+	}
+	if _, ok := sources[cfg.DefaultSource]; !ok {
+		return nil, fmt.Errorf("unknown default source %q", cfg.DefaultSource)
+	}
+
+	triggers, err := load(cfg.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{
+		spot:     spot,
+		cfg:      cfg,
+		sources:  sources,
+		now:      time.Now,
+		triggers: triggers,
+		stale:    map[string]bool{},
+	}, nil
+}
+
+// Add validates, persists and arms t, and returns it with its ID.
+func (e *Engine) Add(t Trigger) (Trigger, error) {
+	if t.Type == "" {
+		t.Type = model.OrderTypeMarket
+	}
+	if t.Source == "" {
+		t.Source = e.cfg.DefaultSource
+	}
+	if err := t.validate(); err != nil {
+		return Trigger{}, err
+	}
+	if _, ok := e.sources[t.Source]; !ok {
+		return Trigger{}, fmt.Errorf("unknown source %q", t.Source)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Trigger{}, err
+	}
+	t.ID = id
+	t.Status = StatusArmed
+	t.CreatedAt = e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.triggers[t.ID] = &t
+	if err := e.save(); err != nil {
+		delete(e.triggers, t.ID)
+		return Trigger{}, err
+	}
+	return t, nil
+}
+
+// Cancel disarms the trigger with the given ID.
+func (e *Engine) Cancel(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, ok := e.triggers[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if t.Status != StatusArmed {
+		return fmt.Errorf("trigger %s is %s", id, t.Status)
+	}
+	t.Status = StatusCanceled
+	return e.save()
+}
+
+// Triggers returns a copy of every trigger, oldest first.
+func (e *Engine) Triggers() []Trigger {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	res := make([]Trigger, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		res = append(res, *t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+	return res
+}
+
+// Run evaluates the triggers immediately and then at every interval until ctx is done.
+// Errors of an evaluation are reported to onError, which may be nil.
+func (e *Engine) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Evaluate(); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate resolves triggers left firing by a previous process or a failed submission, then fetches one quote per source and market
+// and fires the armed triggers whose condition is met. Add, Cancel and Triggers do not wait for its network calls.
+func (e *Engine) Evaluate() error {
+	e.evalMu.Lock()
+	defer e.evalMu.Unlock()
+
+	type key struct{ source, market string }
+	var firing []*Trigger
+	armed := map[key][]*Trigger{}
+	e.mu.Lock()
+	for _, t := range e.triggers {
+		switch t.Status {
+		case StatusFiring:
+			firing = append(firing, t)
+		case StatusArmed:
+			k := key{t.Source, t.Market}
+			armed[k] = append(armed[k], t)
+		}
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, t := range firing {
+		if err := e.resolve(t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for k, triggers := range armed {
+		source, ok := e.sources[k.source]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown source %q", k.source))
+			continue
+		}
+		q, err := source.Quote(k.market)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get %s price of %s: %w", k.source, k.market, err))
+			continue
+		}
+		if !e.fresh(k.source, k.market, q) {
+			continue
+		}
+		for _, t := range triggers {
+			if leg, ok := t.condition(q.Price); ok {
+				e.fire(t, leg, q)
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.save(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// fresh reports whether q is recent enough, emitting an event when the staleness of a price changes.
+func (e *Engine) fresh(source, market string, q Quote) bool {
+	k := source + "/" + market
+	stale := e.now().Sub(q.Time) > e.cfg.MaxAge || !q.Price.IsPositive()
+	if stale != e.stale[k] {
+		e.stale[k] = stale
+		kind, msg := EventRecovered, "price is fresh again"
+		if stale {
+			kind, msg = EventStale, fmt.Sprintf("price from %s is older than %s, triggers are not evaluated", q.Time.Format(time.RFC3339), e.cfg.MaxAge)
+		}
+		e.emit(Event{Kind: kind, Market: market, Source: source, Quote: q, Message: msg})
+	}
+	return !stale
+}
+
+// fire submits the order of t. The trigger is persisted as firing first so that a crash cannot submit it twice.
+func (e *Engine) fire(t *Trigger, leg Leg, q Quote) {
+	e.mu.Lock()
+	if t.Status != StatusArmed {
+		// Canceled while the quote was fetched.
+		e.mu.Unlock()
+		return
+	}
+	t.Status = StatusFiring
+	t.FiredAt = e.now()
+	t.FiredLeg = leg
+	t.FirePrice = q.Price
+	t.ClientOrderID = "trg-" + t.ID
+	err := e.save()
+	if err != nil {
+		t.Status = StatusArmed
+	}
+	snapshot := copyTrigger(t)
+	e.mu.Unlock()
+
+	if err != nil {
+		e.emit(Event{Kind: EventFailed, Market: t.Market, Source: t.Source, Quote: q, Trigger: snapshot, Message: err.Error()})
+		return
+	}
+	e.submit(t, q)
+}
+
+// submit sends the order of a firing trigger. A definite rejection fails the trigger; on any other error the order
+// may or may not exist, so the trigger stays firing and the next evaluation resolves it by its client order ID.
+func (e *Engine) submit(t *Trigger, q Quote) {
+	req := &api.AddOrderRequest{
+		ClientOrderID: t.ClientOrderID,
+		Market:        t.Market,
+		Side:          t.Side,
+		Size:          t.Size,
+		Type:          t.Type,
+	}
+	if t.Type == model.OrderTypeLimit {
+		price := t.StopLoss
+		if t.FiredLeg == LegTakeProfit {
+			price = t.TakeProfit
+		}
+		if t.Side == model.OrderSideSell {
+			req.Price = price.Sub(t.LimitOffset)
+		} else {
+			req.Price = price.Add(t.LimitOffset)
+		}
+		req.TimeInForce = model.TimeInForceGTC
+	}
+
+	o, err := e.spot.AddOrder(req)
+	if err != nil {
+		msg := fmt.Sprintf("failed to submit %s order: %v", t.FiredLeg, err)
+		e.mu.Lock()
+		if rejected(err) {
+			t.Status = StatusFailed
+			t.Error = err.Error()
+		} else {
+			msg += ", its state is checked on the next evaluation"
+		}
+		snapshot := copyTrigger(t)
+		e.mu.Unlock()
+		e.emit(Event{Kind: EventFailed, Market: t.Market, Source: t.Source, Quote: q, Trigger: snapshot, Message: msg})
+		return
+	}
+	e.mu.Lock()
+	t.Status = StatusFired
+	t.OrderID = o.OrderID
+	snapshot := copyTrigger(t)
+	e.mu.Unlock()
+	e.emit(Event{Kind: EventFired, Market: t.Market, Source: t.Source, Quote: q, Trigger: snapshot,
+		Message: fmt.Sprintf("%s fired at %s, %s order %s submitted", t.FiredLeg, t.FirePrice, t.Side, o.OrderID)})
+}
+
+// resolve settles a trigger that was left firing, by a previous process or an ambiguous submission, by looking its
+// order up. An order the exchange does not know was never sent and is submitted now with the same client order ID;
+// on any other error the trigger stays firing and is resolved again on the next evaluation.
+func (e *Engine) resolve(t *Trigger) error {
+	o, err := e.spot.GetOrder(&api.GetOrderRequest{ClientOrderID: t.ClientOrderID})
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		e.submit(t, Quote{Price: t.FirePrice, Time: t.FiredAt})
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to resolve firing trigger %s: %w", t.ID, err)
+	}
+	e.mu.Lock()
+	t.Status = StatusFired
+	t.OrderID = o.OrderID
+	snapshot := copyTrigger(t)
+	e.mu.Unlock()
+	e.emit(Event{Kind: EventFired, Market: t.Market, Source: t.Source, Trigger: snapshot,
+		Message: fmt.Sprintf("order %s of firing trigger found", o.OrderID)})
+	return nil
+}
+
+// rejected reports whether err is the exchange refusing a request, as opposed to a failure that leaves its outcome
+// unknown.
+func rejected(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch code := apiErr.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return false
+	default:
+		return code >= 400 && code < 500
+	}
+}
+
+func (e *Engine) emit(ev Event) {
+	if e.cfg.OnEvent == nil {
+		return
+	}
+	ev.Time = e.now()
+	e.cfg.OnEvent(ev)
+}
+
+func copyTrigger(t *Trigger) *Trigger {
+	c := *t
+	return &c
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate trigger ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
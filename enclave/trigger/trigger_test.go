@@ -0,0 +1,111 @@
+package trigger
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+func TestResolveFiring(t *testing.T) {
+	tests := []struct {
+		name     string
+		getErr   error
+		order    *model.Order
+		addErr   error
+		resubmit bool
+		want     Status
+		wantErr  bool
+	}{
+		{
+			name:  "order found",
+			order: &model.Order{OrderID: "o1"},
+			want:  StatusFired,
+		},
+		{
+			name:     "order not found is resubmitted",
+			getErr:   &client.APIError{StatusCode: http.StatusNotFound, Message: "order not found"},
+			resubmit: true,
+			want:     StatusFired,
+		},
+		{
+			name:     "resubmission rejected",
+			getErr:   &client.APIError{StatusCode: http.StatusNotFound, Message: "order not found"},
+			resubmit: true,
+			addErr:   &client.APIError{StatusCode: http.StatusBadRequest, Message: "insufficient balance"},
+			want:     StatusFailed,
+		},
+		{
+			name:     "resubmission timed out",
+			getErr:   &client.APIError{StatusCode: http.StatusNotFound, Message: "order not found"},
+			resubmit: true,
+			addErr:   errors.New("context deadline exceeded"),
+			want:     StatusFiring,
+		},
+		{
+			name:    "server error keeps firing",
+			getErr:  &client.APIError{StatusCode: http.StatusBadGateway, Message: "bad gateway"},
+			want:    StatusFiring,
+			wantErr: true,
+		},
+		{
+			name:    "network error keeps firing",
+			getErr:  errors.New("connection reset by peer"),
+			want:    StatusFiring,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			spot := mocks.NewMockSpotClient(ctrl)
+			spot.EXPECT().GetOrder(gomock.Any()).Return(tt.order, tt.getErr)
+			if tt.resubmit {
+				spot.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(req *api.AddOrderRequest) (*model.Order, error) {
+					if req.ClientOrderID != "trg-t1" {
+						t.Errorf("resubmitted with client order ID %q, want trg-t1", req.ClientOrderID)
+					}
+					if tt.addErr != nil {
+						return nil, tt.addErr
+					}
+					return &model.Order{OrderID: "o2"}, nil
+				})
+			}
+
+			e, err := New(spot, Config{StatePath: filepath.Join(t.TempDir(), "triggers.json")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := &Trigger{
+				ID:            "t1",
+				Market:        "AVAX-USDC",
+				Side:          model.OrderSideSell,
+				Size:          decimal.NewFromInt(1),
+				StopLoss:      decimal.NewFromInt(10),
+				Type:          model.OrderTypeMarket,
+				Source:        SourceMid,
+				Status:        StatusFiring,
+				FiredLeg:      LegStopLoss,
+				ClientOrderID: "trg-t1",
+			}
+			e.triggers[tr.ID] = tr
+
+			err = e.Evaluate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tr.Status != tt.want {
+				t.Fatalf("status = %s, want %s", tr.Status, tt.want)
+			}
+		})
+	}
+}
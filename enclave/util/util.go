@@ -1,6 +1,9 @@
 package util
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 func GetPointer[T any](val T) (res *T) {
 	res = &val
@@ -15,3 +18,18 @@ func MustMarshalIndent[T any](s T) string {
 func NewTradingPair(base, quote string) string {
 	return base + "-" + quote
 }
+
+// SplitTradingPair returns the base and quote coins of a spot market such as "AVAX-USDC".
+// Perps markets such as "AVAX-USD.P" are not spot pairs and report ok as false.
+func SplitTradingPair(market string) (base, quote string, ok bool) {
+	base, quote, ok = strings.Cut(market, "-")
+	if !ok || IsPerpsMarket(market) {
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+// IsPerpsMarket reports whether market is a perpetual futures market, e.g., "AVAX-USD.P".
+func IsPerpsMarket(market string) bool {
+	return strings.HasSuffix(market, ".P")
+}
@@ -0,0 +1,277 @@
+package withdrawal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/api"
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+var (
+	ErrAddressNotAllowed = errors.New("address is not in the address book")
+	ErrLimitExceeded     = errors.New("withdrawal exceeds the remaining daily limit")
+	ErrRequestMismatch   = errors.New("idempotency key was already used for a different withdrawal")
+)
+
+// AddressValidator checks that an address can receive a coin, e.g., *chain.Validator.
+type AddressValidator interface {
+	Validate(coin, address string) error
+}
+
+// PriceFunc returns the USD price of one unit of symbol.
+type PriceFunc func(symbol string) (decimal.Decimal, error)
+
+// Config configures a Service.
+type Config struct {
+	Store        Store            // Required; persists records so retries reuse the same CustomerWithdrawalID
+	Price        PriceFunc        // Converts amounts to USD for the limit check; USD stablecoins are priced at 1 when nil
+	PollInterval time.Duration    // Time between two status polls, defaults to 10 seconds
+	Validator    AddressValidator // Optional check of the address format for the coin's network
+}
+
+// Request describes a withdrawal to send.
+type Request struct {
+	Key     string          // Idempotency key chosen by the caller, e.g., a payout ID
+	Address string          // Destination address
+	Amount  decimal.Decimal // Amount of the coin to withdraw
+	Symbol  string          // Symbol of the coin to withdraw
+}
+
+// Transition is a withdrawal status observed while polling.
+type Transition struct {
+	Status        string    `json:"status"`                  // Status reported by the exchange
+	Confirmations int64     `json:"confirmations,omitempty"` // Confirmation number reported with the status
+	Time          time.Time `json:"time"`                    // Time the status was first observed
+}
+
+// Result is the outcome of a withdrawal.
+type Result struct {
+	CustomerWithdrawalID string        `json:"customerWithdrawalId"` // ID generated for the withdrawal
+	WithdrawalID         string        `json:"withdrawalId"`         // ID assigned by the exchange
+	TxID                 string        `json:"txid"`                 // Blockchain transaction ID, set once broadcast
+	Status               string        `json:"status"`               // Final status
+	Transitions          []*Transition `json:"transitions"`          // Status changes observed, in order
+}
+
+// Succeeded reports whether the withdrawal was confirmed.
+func (r *Result) Succeeded() bool {
+	return r.Status == model.WithdrawalStatusConfirmed
+}
+
+// Service sends withdrawals only to address book entries and within the daily limit, and tracks them to completion.
+type Service struct {
+	client client.Client
+	cfg    Config
+}
+
+// NewService initializes a Service.
+func NewService(c client.Client, cfg Config) (*Service, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("store is required")
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 10 * time.Second
+	}
+	return &Service{client: c, cfg: cfg}, nil
+}
+
+// Withdraw checks, submits and polls a withdrawal until it reaches a terminal status or ctx is done.
+// Calling Withdraw again with the same Key resumes the existing withdrawal instead of sending a new one.
+func (s *Service) Withdraw(ctx context.Context, req *Request) (*Result, error) {
+	if req.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	record, err := s.cfg.Store.Load(req.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load withdrawal record: %w", err)
+	}
+
+	if record == nil {
+		if err := s.Check(req); err != nil {
+			return nil, err
+		}
+
+		id, err := newCustomerWithdrawalID()
+		if err != nil {
+			return nil, err
+		}
+		record = &Record{
+			Key:                  req.Key,
+			CustomerWithdrawalID: id,
+			Address:              req.Address,
+			Amount:               req.Amount,
+			Symbol:               req.Symbol,
+			CreatedAt:            time.Now(),
+		}
+		if err := s.cfg.Store.Save(record); err != nil {
+			return nil, fmt.Errorf("failed to save withdrawal record: %w", err)
+		}
+	} else if record.Address != req.Address || !record.Amount.Equal(req.Amount) || !strings.EqualFold(record.Symbol, req.Symbol) {
+		return nil, ErrRequestMismatch
+	}
+
+	if !record.Submitted {
+		if err := s.submit(record); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.poll(ctx, record)
+}
+
+// Check verifies the destination format and address book entry, and the USD value against the remaining withdrawal limit.
+func (s *Service) Check(req *Request) error {
+	if s.cfg.Validator != nil {
+		if err := s.cfg.Validator.Validate(req.Symbol, req.Address); err != nil {
+			return err
+		}
+	}
+
+	book, err := s.client.GetAddressBook()
+	if err != nil {
+		return err
+	}
+	if !inAddressBook(book, req.Address) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, req.Address)
+	}
+
+	limit, err := s.client.GetWithdrawalLimit()
+	if err != nil {
+		return err
+	}
+	price, err := s.price(req.Symbol)
+	if err != nil {
+		return err
+	}
+
+	value := req.Amount.Mul(price)
+	remaining := limit.WithdrawalLimitUsd.Sub(limit.CurrentWithdrawalsUsd)
+	if value.GreaterThan(remaining) {
+		return fmt.Errorf("%w: %s USD requested, %s USD remaining", ErrLimitExceeded, value.StringFixed(2), remaining.StringFixed(2))
+	}
+	return nil
+}
+
+// submit sends the withdrawal unless the exchange already knows the CustomerWithdrawalID from an earlier attempt.
+// It only sends once the exchange answers that the ID is unknown; any other failure to look it up is returned with
+// the record left unsubmitted, so that a retry checks again rather than risking a second withdrawal.
+func (s *Service) submit(record *Record) error {
+	status, err := s.client.GetWithdrawalStatus(&api.GetWithdrawalStatusRequest{
+		CustomerWithdrawalId: record.CustomerWithdrawalID,
+	})
+	switch {
+	case err == nil && status != nil && status.WithdrawalID != "":
+		record.WithdrawalID = status.WithdrawalID
+	case err == nil:
+		return errors.New("failed to check for an earlier submission: withdrawal status has no withdrawal ID")
+	case !errors.Is(err, client.ErrNotFound):
+		return fmt.Errorf("failed to check for an earlier submission: %w", err)
+	default:
+		res, err := s.client.Withdraw(&api.WithdrawRequest{
+			Address:              record.Address,
+			Amount:               record.Amount,
+			CustomerWithdrawalID: record.CustomerWithdrawalID,
+			Symbol:               record.Symbol,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to submit withdrawal: %w", err)
+		}
+		record.WithdrawalID = res.WithdrawalID
+	}
+
+	record.Submitted = true
+	if err := s.cfg.Store.Save(record); err != nil {
+		return fmt.Errorf("failed to save withdrawal record: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) poll(ctx context.Context, record *Record) (*Result, error) {
+	res := &Result{
+		CustomerWithdrawalID: record.CustomerWithdrawalID,
+		WithdrawalID:         record.WithdrawalID,
+	}
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := s.client.GetWithdrawalStatus(&api.GetWithdrawalStatusRequest{
+			CustomerWithdrawalId: record.CustomerWithdrawalID,
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to poll withdrawal status: %w", err)
+		}
+
+		if status.WithdrawalStatus != res.Status {
+			res.Transitions = append(res.Transitions, &Transition{
+				Status:        status.WithdrawalStatus,
+				Confirmations: status.ConfirmationNumber,
+				Time:          time.Now(),
+			})
+			res.Status = status.WithdrawalStatus
+		}
+		if status.TxID != "" {
+			res.TxID = status.TxID
+		}
+		if status.WithdrawalID != "" {
+			res.WithdrawalID = status.WithdrawalID
+		}
+		if IsTerminal(res.Status) {
+			return res, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) price(symbol string) (decimal.Decimal, error) {
+	if s.cfg.Price != nil {
+		return s.cfg.Price(symbol)
+	}
+	switch strings.ToUpper(symbol) {
+	case "USDC", "USDT", "USD":
+		return decimal.NewFromInt(1), nil
+	default:
+		return decimal.Zero, fmt.Errorf("no USD price available for %s", symbol)
+	}
+}
+
+// IsTerminal reports whether a withdrawal status is final.
+func IsTerminal(status string) bool {
+	return status == model.WithdrawalStatusConfirmed || status == model.WithdrawalStatusFailed
+}
+
+func inAddressBook(book *model.AddressBook, address string) bool {
+	for _, a := range book.AddressBook {
+		if a == address || (strings.HasPrefix(a, "0x") && strings.EqualFold(a, address)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCustomerWithdrawalID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate customer withdrawal ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package withdrawal
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+
+	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/client/mocks"
+	"github.com/yangnei/enclave-go/enclave/model"
+)
+
+type memoryStore map[string]*Record
+
+func (m memoryStore) Load(key string) (*Record, error) {
+	return m[key], nil
+}
+
+func (m memoryStore) Save(record *Record) error {
+	r := *record
+	m[record.Key] = &r
+	return nil
+}
+
+func TestNewServiceRequiresStore(t *testing.T) {
+	if _, err := NewService(nil, Config{}); err == nil {
+		t.Fatal("NewService without a store succeeded")
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name      string
+		statusErr error
+		status    *model.WithdrawalStatus
+		withdraw  bool
+		wantErr   bool
+		wantID    string
+	}{
+		{
+			name:      "unknown ID is sent",
+			statusErr: &client.APIError{StatusCode: http.StatusNotFound, Message: "withdrawal not found"},
+			withdraw:  true,
+			wantID:    "new",
+		},
+		{
+			name:   "known ID is adopted",
+			status: &model.WithdrawalStatus{WithdrawalID: "earlier"},
+			wantID: "earlier",
+		},
+		{
+			name:      "server error is not sent",
+			statusErr: &client.APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"},
+			wantErr:   true,
+		},
+		{
+			name:      "rate limit is not sent",
+			statusErr: &client.APIError{StatusCode: http.StatusTooManyRequests, Message: "slow down"},
+			wantErr:   true,
+		},
+		{
+			name:      "network error is not sent",
+			statusErr: errors.New("dial tcp: i/o timeout"),
+			wantErr:   true,
+		},
+		{
+			name:    "status without ID is not sent",
+			status:  &model.WithdrawalStatus{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockClient(ctrl)
+			c.EXPECT().GetWithdrawalStatus(gomock.Any()).Return(tt.status, tt.statusErr)
+			if tt.withdraw {
+				c.EXPECT().Withdraw(gomock.Any()).Return(&model.NewWithdrawal{WithdrawalID: "new"}, nil)
+			}
+
+			store := memoryStore{}
+			s, err := NewService(c, Config{Store: store})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := &Record{Key: "k", CustomerWithdrawalID: "cw", Amount: decimal.NewFromInt(1), Symbol: "USDC"}
+			err = s.submit(record)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("submit() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if record.Submitted || store["k"] != nil {
+					t.Fatal("record was marked submitted after a failed lookup")
+				}
+				return
+			}
+			if !store["k"].Submitted || store["k"].WithdrawalID != tt.wantID {
+				t.Fatalf("saved record = %+v, want submitted with ID %s", store["k"], tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package withdrawal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Record is the persisted state of a withdrawal attempt, keyed by the caller's idempotency key.
+type Record struct {
+	Key                  string          `json:"key"`                    // Caller-supplied idempotency key
+	CustomerWithdrawalID string          `json:"customerWithdrawalId"`   // ID generated for the withdrawal and sent to the exchange
+	WithdrawalID         string          `json:"withdrawalId,omitempty"` // ID assigned by the exchange once submitted
+	Address              string          `json:"address"`                // Destination address
+	Amount               decimal.Decimal `json:"amount"`                 // Amount of the coin to withdraw
+	Symbol               string          `json:"symbol"`                 // Symbol of the coin to withdraw
+	Submitted            bool            `json:"submitted"`              // Whether the exchange accepted the withdrawal
+	CreatedAt            time.Time       `json:"createdAt"`              // Time the record was created
+}
+
+// Store persists withdrawal records so that a retried request reuses the same CustomerWithdrawalID.
+type Store interface {
+	// Load returns the record for key, or nil if there is none.
+	Load(key string) (*Record, error)
+	Save(record *Record) error
+}
+
+// FileStore keeps records in a single JSON file.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore initializes a FileStore backed by path. The file is created on first save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load(key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return records[key], nil
+}
+
+func (s *FileStore) Save(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	records[record.Key] = record
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileStore) read() (map[string]*Record, error) {
+	records := make(map[string]*Record)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -2,10 +2,16 @@ module github.com/yangnei/enclave-go
 
 go 1.23.2
 
-require github.com/shopspring/decimal v1.4.0
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/golang/mock v1.6.0
+	github.com/shopspring/decimal v1.4.0
+	golang.org/x/crypto v0.28.0
+	golang.org/x/time v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/golang/mock v1.6.0 // indirect
 	golang.org/x/mod v0.21.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.26.0 // indirect
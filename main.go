@@ -3,29 +3,29 @@ package main
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/shopspring/decimal"
 
 	"github.com/yangnei/enclave-go/enclave"
 	"github.com/yangnei/enclave-go/enclave/api"
-	"github.com/yangnei/enclave-go/enclave/client"
+	"github.com/yangnei/enclave-go/enclave/config"
 	"github.com/yangnei/enclave-go/enclave/model"
 	"github.com/yangnei/enclave-go/enclave/util"
 )
 
 func main() {
-	enclaveClient := client.NewClient(
-		os.Getenv("enclave_key"),
-		os.Getenv("enclave_secret"),
-		enclave.SandboxApiUrl,
-	)
+	// The demo places real orders, so it always targets the sandbox, whatever the profile says.
+	settings, err := config.Resolve("", &config.Overrides{BaseURL: enclave.SandboxApiUrl})
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	enclaveClient := settings.NewClient()
 
 	spot := func() {
 		tradingPair := util.NewTradingPair("AVAX", "USDC")
 
 		// Create a limit Order
-		order, err := enclaveClient.AddOrder(&api.AddOrderRequest{
+		order, err := enclaveClient.SpotClient().AddOrder(&api.AddOrderRequest{
 			Market: tradingPair,
 			Side:   model.OrderSideBuy,
 			Size:   decimal.NewFromInt(1),
@@ -37,7 +37,7 @@ func main() {
 		fmt.Println("Order Created:", util.MustMarshalIndent(order))
 
 		// Get Order
-		order, err = enclaveClient.GetOrder(&api.GetOrderRequest{
+		order, err = enclaveClient.SpotClient().GetOrder(&api.GetOrderRequest{
 			OrderID: order.OrderID,
 		})
 		if err != nil {
@@ -46,7 +46,7 @@ func main() {
 		fmt.Println("Order Retrieved:", util.MustMarshalIndent(order))
 
 		// Cancel Order
-		order, err = enclaveClient.CancelOrder(&api.CancelOrderRequest{
+		order, err = enclaveClient.SpotClient().CancelOrder(&api.CancelOrderRequest{
 			OrderID: order.OrderID,
 		})
 		if err != nil {
@@ -55,7 +55,7 @@ func main() {
 		fmt.Println("Order Canceled:", util.MustMarshalIndent(order))
 
 		// Create a market Order
-		order, err = enclaveClient.AddOrder(&api.AddOrderRequest{
+		order, err = enclaveClient.SpotClient().AddOrder(&api.AddOrderRequest{
 			Market: tradingPair,
 			Side:   model.OrderSideSell,
 			Size:   decimal.NewFromInt(1),
@@ -67,7 +67,7 @@ func main() {
 		fmt.Println("Order Created:", util.MustMarshalIndent(order))
 
 		// Create another market Order
-		order, err = enclaveClient.AddOrder(&api.AddOrderRequest{
+		order, err = enclaveClient.SpotClient().AddOrder(&api.AddOrderRequest{
 			Market: tradingPair,
 			Side:   model.OrderSideBuy,
 			Size:   decimal.NewFromInt(1),
@@ -79,7 +79,7 @@ func main() {
 		fmt.Println("Order Created:", util.MustMarshalIndent(order))
 
 		// Create another market Order
-		order, err = enclaveClient.AddOrder(&api.AddOrderRequest{
+		order, err = enclaveClient.SpotClient().AddOrder(&api.AddOrderRequest{
 			Market: tradingPair,
 			Side:   model.OrderSideSell,
 			Size:   decimal.NewFromInt(1),
@@ -91,7 +91,7 @@ func main() {
 		fmt.Println("Order Created:", util.MustMarshalIndent(order))
 
 		// Get Fills
-		fills, err := enclaveClient.GetFills(&api.GetFillsRequest{
+		fills, err := enclaveClient.SpotClient().GetFills(&api.GetFillsRequest{
 			Market: tradingPair,
 		})
 		if err != nil {
@@ -100,7 +100,7 @@ func main() {
 		fmt.Println("Fills Retrieved:", util.MustMarshalIndent(fills))
 
 		// Get Depth
-		depth, err := enclaveClient.GetDepth(&api.GetDepthRequest{
+		depth, err := enclaveClient.SpotClient().GetDepth(&api.GetDepthRequest{
 			Market: tradingPair,
 		})
 		if err != nil {
@@ -110,19 +110,19 @@ func main() {
 	}
 
 	perps := func() {
-		positions, err := enclaveClient.GetPositions()
+		positions, err := enclaveClient.PerpsClient().GetPositions()
 		if err != nil {
 			log.Fatalf("Error getting positions: %v", err)
 		}
 		fmt.Println("Positions Retrieved:", util.MustMarshalIndent(positions))
 
-		balances, err := enclaveClient.GetBalance()
+		balances, err := enclaveClient.PerpsClient().GetBalance()
 		if err != nil {
 			log.Fatalf("Error getting balance: %v", err)
 		}
 		fmt.Println("Balance Retrieved:", util.MustMarshalIndent(balances))
 
-		transfer, err := enclaveClient.Transfer(&api.TransferRequest{
+		transfer, err := enclaveClient.PerpsClient().Transfer(&api.TransferRequest{
 			Amount: decimal.NewFromInt(1),
 			Symbol: "usdc",
 		})
@@ -131,7 +131,7 @@ func main() {
 		}
 		fmt.Println("Transfer Created:", util.MustMarshalIndent(transfer))
 
-		transfer, err = enclaveClient.Transfer(&api.TransferRequest{
+		transfer, err = enclaveClient.PerpsClient().Transfer(&api.TransferRequest{
 			Amount: decimal.NewFromInt(-1),
 			Symbol: "usdc",
 		})